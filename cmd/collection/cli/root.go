@@ -33,12 +33,11 @@ func Execute() {
 func init() {
 	// Define Default Configuration
 	rootCmd.PersistentFlags().String("browser_user_agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.93 Safari/537.36", "browser user agent used for the data scraper")
-	rootCmd.PersistentFlags().String("team_tag", "FOLLY", "team tag to track stats")
-	rootCmd.PersistentFlags().Int("team_id", 1411729, "nitro type team id to cross check stats against")
+
+	rootCmd.PersistentFlags().String("metrics_addr", ":9090", "address to serve the prometheus metrics on (disabled if empty)")
 
 	viper.BindPFlag("browser_user_agent", rootCmd.PersistentFlags().Lookup("browser_user_agent"))
-	viper.BindPFlag("team_tag", rootCmd.PersistentFlags().Lookup("team_tag"))
-	viper.BindPFlag("team_id", rootCmd.PersistentFlags().Lookup("team_id"))
+	viper.BindPFlag("metrics_addr", rootCmd.PersistentFlags().Lookup("metrics_addr"))
 
 	// Initialize cli
 	cobra.OnInitialize(cli.InitConfig(rootCmd), func() {
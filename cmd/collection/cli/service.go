@@ -1,12 +1,16 @@
 package cli
 
 import (
+	"errors"
+	"net/http"
 	"nt-folly-xmaxx-comp/internal/app/collection/cron"
 	"nt-folly-xmaxx-comp/internal/pkg/db"
+	"nt-folly-xmaxx-comp/internal/pkg/metrics"
 	"nt-folly-xmaxx-comp/pkg/nitrotype/clients"
 	"os"
 	"os/signal"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -15,21 +19,10 @@ import (
 var serviceCmd = &cobra.Command{
 	Use:   "service",
 	Short: "Service that collects Nitro Type Team stats.",
-	Long:  "Service that collects Nitro Type Team stats. This is done every 10 minutes.",
+	Long:  "Service that collects Nitro Type Team stats. This is done whenever a competition of an active event starts or finishes.",
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := cmd.Context()
 
-		teamTag := viper.GetString("team_tag")
-		if teamTag == "" {
-			logger.Error("team_tag is required")
-			return
-		}
-		teamID := viper.GetInt("team_id")
-		if teamID <= 0 {
-			logger.Error("team_id is required")
-			return
-		}
-
 		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
 		if err != nil {
 			logger.Error("unable to connect to database", zap.Error(err))
@@ -37,9 +30,36 @@ var serviceCmd = &cobra.Command{
 		}
 		apiClient := clients.NewAPIClientBrowser(viper.GetString("browser_user_agent"))
 
+		// Start Metrics Listener
+		if metricsAddr := viper.GetString("metrics_addr"); metricsAddr != "" {
+			err = metrics.RegisterPool("collection", conn)
+			if err != nil {
+				logger.Error("unable to register db pool metrics", zap.Error(err))
+				return
+			}
+			err = cron.RegisterMetrics(ctx, conn, logger)
+			if err != nil {
+				logger.Error("unable to register collection metrics", zap.Error(err))
+				return
+			}
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			server := &http.Server{
+				Addr:    metricsAddr,
+				Handler: mux,
+			}
+			go func() {
+				if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.Fatal("metrics - listener failed to start", zap.Error(err))
+				}
+			}()
+			defer server.Close()
+			logger.Sugar().Infof("metrics - hosting on %s", metricsAddr)
+		}
+
 		// Start Scheduler Service
 		logger.Info("cron - service started")
-		c := cron.NewCronService(ctx, conn, logger, apiClient, teamTag, teamID)
+		c := cron.NewCronService(ctx, conn, logger, apiClient)
 		c.Start()
 		defer c.Stop()
 
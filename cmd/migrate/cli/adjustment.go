@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/adjustments"
+	"nt-folly-xmaxx-comp/internal/pkg/db"
+	"nt-folly-xmaxx-comp/internal/pkg/manage"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// adjustmentCmd represents the adjustment command
+var adjustmentCmd = &cobra.Command{
+	Use:   "adjustment",
+	Short: "manages the manual point adjustments.",
+	Long:  "Manages the manual point adjustments (bonuses and penalties).",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Usage()
+	},
+}
+
+// adjustmentAddCmd represents the adjustment add command
+var adjustmentAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "gives a racer bonus points or a penalty.",
+	Long:  "Gives a racer bonus points (positive) or a penalty (negative), optionally against a competition.",
+	Run: func(cmd *cobra.Command, args []string) {
+		username, err := cmd.Flags().GetString("username")
+		if err != nil {
+			logger.Error("unable to read username flag", zap.Error(err))
+			return
+		}
+		competitionIDValue, err := cmd.Flags().GetString("competition_id")
+		if err != nil {
+			logger.Error("unable to read competition_id flag", zap.Error(err))
+			return
+		}
+		var competitionID *string
+		if competitionIDValue != "" {
+			competitionID = &competitionIDValue
+		}
+		points, err := cmd.Flags().GetInt("points")
+		if err != nil {
+			logger.Error("unable to read points flag", zap.Error(err))
+			return
+		}
+		reason, err := cmd.Flags().GetString("reason")
+		if err != nil {
+			logger.Error("unable to read reason flag", zap.Error(err))
+			return
+		}
+		author, err := cmd.Flags().GetString("author")
+		if err != nil {
+			logger.Error("unable to read author flag", zap.Error(err))
+			return
+		}
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		userID, err := manage.GetUserID(ctx, conn, username)
+		if err != nil {
+			logger.Error("failed to find user", zap.String("username", username), zap.Error(err))
+			return
+		}
+		adjustment, err := adjustments.Add(ctx, conn, userID, competitionID, points, reason, author)
+		if err != nil {
+			logger.Error("failed to add adjustment", zap.Error(err))
+			return
+		}
+		logger.Info("adjustment added", zap.String("adjustmentID", adjustment.ID))
+	},
+}
+
+// adjustmentListCmd represents the adjustment list command
+var adjustmentListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "lists the adjustments.",
+	Long:  "Lists the adjustments, optionally filtered by racer and competition.",
+	Run: func(cmd *cobra.Command, args []string) {
+		username, err := cmd.Flags().GetString("username")
+		if err != nil {
+			logger.Error("unable to read username flag", zap.Error(err))
+			return
+		}
+		competitionIDValue, err := cmd.Flags().GetString("competition_id")
+		if err != nil {
+			logger.Error("unable to read competition_id flag", zap.Error(err))
+			return
+		}
+		var competitionID *string
+		if competitionIDValue != "" {
+			competitionID = &competitionIDValue
+		}
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		var userID *string
+		if username != "" {
+			id, err := manage.GetUserID(ctx, conn, username)
+			if err != nil {
+				logger.Error("failed to find user", zap.String("username", username), zap.Error(err))
+				return
+			}
+			userID = &id
+		}
+		list, err := adjustments.List(ctx, conn, userID, competitionID)
+		if err != nil {
+			logger.Error("failed to list adjustments", zap.Error(err))
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSER\tCOMPETITION\tPOINTS\tREASON\tAUTHOR\tCREATED")
+		for _, a := range list {
+			competition := "-"
+			if a.CompetitionID != nil {
+				competition = *a.CompetitionID
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n", a.ID, a.UserID, competition, a.Points, a.Reason, a.Author, a.CreatedAt.Format(time.RFC3339))
+		}
+		w.Flush()
+	},
+}
+
+// adjustmentRemoveCmd represents the adjustment remove command
+var adjustmentRemoveCmd = &cobra.Command{
+	Use:   "remove <adjustment id>",
+	Short: "removes an adjustment.",
+	Long:  "Removes an adjustment.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		err = adjustments.Remove(ctx, conn, args[0])
+		if err != nil {
+			logger.Error("failed to remove adjustment", zap.Error(err))
+			return
+		}
+		logger.Info("adjustment removed", zap.String("adjustmentID", args[0]))
+	},
+}
+
+func init() {
+	adjustmentAddCmd.Flags().String("username", "", "nitro type username of the racer")
+	adjustmentAddCmd.Flags().String("competition_id", "", "competition the adjustment belongs to (optional)")
+	adjustmentAddCmd.Flags().Int("points", 0, "points to give (negative for a penalty)")
+	adjustmentAddCmd.Flags().String("reason", "", "reason shown with the adjustment")
+	adjustmentAddCmd.Flags().String("author", "", "captain making the adjustment")
+
+	adjustmentListCmd.Flags().String("username", "", "only list the adjustments of this racer")
+	adjustmentListCmd.Flags().String("competition_id", "", "only list the adjustments of this competition")
+
+	adjustmentCmd.AddCommand(adjustmentAddCmd)
+	adjustmentCmd.AddCommand(adjustmentListCmd)
+	adjustmentCmd.AddCommand(adjustmentRemoveCmd)
+
+	rootCmd.AddCommand(adjustmentCmd)
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/apikeys"
+	"nt-folly-xmaxx-comp/internal/pkg/db"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// apiKeyCmd represents the apikey command
+var apiKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "manages the admin api keys.",
+	Long:  "Manages the API keys giving access to the admin mutations.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Usage()
+	},
+}
+
+// apiKeyCreateCmd represents the apikey create command
+var apiKeyCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "creates an api key.",
+	Long:  "Creates an API key and prints it. Only its hash is stored, so it can't be printed again.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		key, token, err := apikeys.Create(ctx, conn, args[0])
+		if err != nil {
+			logger.Error("failed to create api key", zap.Error(err))
+			return
+		}
+		logger.Info("api key created", zap.String("apiKeyID", key.ID))
+		fmt.Println(token)
+	},
+}
+
+// apiKeyListCmd represents the apikey list command
+var apiKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "lists the api keys.",
+	Long:  "Lists the API keys, including the revoked ones.",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		list, err := apikeys.List(ctx, conn)
+		if err != nil {
+			logger.Error("failed to list api keys", zap.Error(err))
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tLAST USED\tREVOKED\tCREATED")
+		for _, k := range list {
+			lastUsed, revoked := "-", "-"
+			if k.LastUsedAt != nil {
+				lastUsed = k.LastUsedAt.Format(time.RFC3339)
+			}
+			if k.RevokedAt != nil {
+				revoked = k.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, lastUsed, revoked, k.CreatedAt.Format(time.RFC3339))
+		}
+		w.Flush()
+	},
+}
+
+// apiKeyRevokeCmd represents the apikey revoke command
+var apiKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke <api key id>",
+	Short: "revokes an api key.",
+	Long:  "Revokes an API key, requests made with it are rejected straight away.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		err = apikeys.Revoke(ctx, conn, args[0])
+		if err != nil {
+			logger.Error("failed to revoke api key", zap.Error(err))
+			return
+		}
+		logger.Info("api key revoked", zap.String("apiKeyID", args[0]))
+	},
+}
+
+func init() {
+	apiKeyCmd.AddCommand(apiKeyCreateCmd)
+	apiKeyCmd.AddCommand(apiKeyListCmd)
+	apiKeyCmd.AddCommand(apiKeyRevokeCmd)
+
+	rootCmd.AddCommand(apiKeyCmd)
+}
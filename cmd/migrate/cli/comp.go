@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/db"
+	"nt-folly-xmaxx-comp/internal/pkg/manage"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// compCmd represents the comp command
+var compCmd = &cobra.Command{
+	Use:   "comp",
+	Short: "inspects and repairs competitions.",
+	Long:  "Inspects and repairs competitions.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Usage()
+	},
+}
+
+// compAuditCmd represents the comp audit command
+var compAuditCmd = &cobra.Command{
+	Use:   "audit <competition id>",
+	Short: "prints how a competition's results were produced.",
+	Long:  "Prints the team logs used by a competition, each member's counters and delta, the derived stats and the category results.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		audit, err := manage.AuditCompetition(ctx, conn, args[0])
+		if err != nil {
+			logger.Error("failed to audit comp", zap.Error(err))
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "competition:\t%s (%s)\n", audit.ID, audit.EventName)
+		fmt.Fprintf(w, "status:\t%s\n", audit.Status)
+		fmt.Fprintf(w, "time:\t%s - %s\n", audit.FromAt.Format(time.RFC3339), audit.ToAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "multiplier:\t%dx\n", audit.Multiplier)
+		fmt.Fprintf(w, "tie policy:\t%s\n", audit.TiePolicy)
+		fmt.Fprintf(w, "prev log:\t%s\n", formatAuditTeamLog(audit.PrevLog))
+		fmt.Fprintf(w, "next log:\t%s\n", formatAuditTeamLog(audit.NextLog))
+		w.Flush()
+		if len(audit.Members) == 0 {
+			fmt.Println("\nno member stats were recorded")
+			return
+		}
+
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "USERNAME\tSTATUS\tPREV (PLAYED/TYPED/ERRS/SECS)\tNEXT (PLAYED/TYPED/ERRS/SECS)\tDELTA (PLAYED/TYPED/ERRS/SECS)\tRECORDED\tWPM\tACCURACY\tPOINTS")
+		for _, m := range audit.Members {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\t%s\t%s\t%s\n",
+				m.Username, formatAuditString(m.Status),
+				formatAuditCounters(m.Prev), formatAuditCounters(m.Next), formatAuditCounters(m.Delta),
+				m.Recorded, formatAuditFloat(m.Speed), formatAuditFloat(m.Accuracy), formatAuditFloat(m.Points),
+			)
+		}
+		w.Flush()
+
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "USERNAME\tCATEGORY\tSCORE\tRANK\tTIED\tREWARD\tNOTE")
+		for _, m := range audit.Members {
+			for _, c := range m.Categories {
+				rank := "-"
+				if c.Rank != nil {
+					rank = strconv.Itoa(*c.Rank)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%.2f\t%s\t%d\t%d\t%s\n", m.Username, c.Name, c.Score, rank, c.Tied, c.Reward, formatAuditString(c.IneligibleReason))
+			}
+		}
+		w.Flush()
+	},
+}
+
+// compRecomputeCmd represents the comp recompute command
+var compRecomputeCmd = &cobra.Command{
+	Use:   "recompute <competition id>",
+	Short: "rebuilds a competition's records and results.",
+	Long:  "Rebuilds a finished competition's user records from its team logs and recomputes the results (including the competitions after it).",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		logger.Info("comp recompute started", zap.String("competitionID", args[0]))
+		competitionIDs, err := manage.RecomputeCompetition(ctx, conn, args[0])
+		if err != nil {
+			logger.Error("failed to recompute comp", zap.Error(err))
+			return
+		}
+		logger.Info("comp recompute finished", zap.Int("competitions", len(competitionIDs)))
+	},
+}
+
+// formatAuditTeamLog describes a team log request used in an audit.
+func formatAuditTeamLog(l *manage.AuditTeamLog) string {
+	if l == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s at %s (%s, log %s, hash %s)", l.RequestID, l.CreatedAt.Format(time.RFC3339), l.ResponseType, l.LogID, l.Hash)
+}
+
+// formatAuditCounters formats member counters as played/typed/errs/secs.
+func formatAuditCounters(c manage.AuditCounters) string {
+	if c.Played == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d/%d/%d", *c.Played, *c.Typed, *c.Errs, *c.Secs)
+}
+
+// formatAuditFloat formats an optional audit value.
+func formatAuditFloat(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", *v)
+}
+
+// formatAuditString formats an optional audit text.
+func formatAuditString(v *string) string {
+	if v == nil {
+		return "-"
+	}
+	return *v
+}
+
+func init() {
+	compCmd.AddCommand(compAuditCmd)
+	compCmd.AddCommand(compRecomputeCmd)
+
+	rootCmd.AddCommand(compCmd)
+}
@@ -0,0 +1,325 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"nt-folly-xmaxx-comp/internal/pkg/db"
+	"nt-folly-xmaxx-comp/internal/pkg/export"
+	"nt-folly-xmaxx-comp/internal/pkg/manage"
+	"nt-folly-xmaxx-comp/internal/pkg/reports"
+	"nt-folly-xmaxx-comp/internal/pkg/utils"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "exports results as csv or ndjson.",
+	Long:  "Exports leaderboards, standings, racer history and user records straight from the database (the API server isn't needed).",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Usage()
+	},
+}
+
+// exportLeaderboardCmd represents the export leaderboard command
+var exportLeaderboardCmd = &cobra.Command{
+	Use:   "leaderboard <competition id>",
+	Short: "exports a competition leaderboard.",
+	Long:  "Exports a competition leaderboard, ordered by total points (or rank when a category is given).",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := reports.LeaderboardFilter{}
+		category, err := cmd.Flags().GetString("category")
+		if err != nil {
+			logger.Error("unable to read category flag", zap.Error(err))
+			return
+		}
+		if category != "" {
+			filter.Category = &category
+		}
+		filter.Limit, err = cmd.Flags().GetInt("limit")
+		if err != nil {
+			logger.Error("unable to read limit flag", zap.Error(err))
+			return
+		}
+		if filter.Limit < 0 {
+			logger.Error("limit flag must not be negative")
+			return
+		}
+		filter.IncludeIneligible, err = cmd.Flags().GetBool("include_ineligible")
+		if err != nil {
+			logger.Error("unable to read include_ineligible flag", zap.Error(err))
+			return
+		}
+		usernames, err := cmd.Flags().GetStringSlice("usernames")
+		if err != nil {
+			logger.Error("unable to read usernames flag", zap.Error(err))
+			return
+		}
+		format, out, err := getExportOutput(cmd)
+		if err != nil {
+			logger.Error("unable to open export output", zap.Error(err))
+			return
+		}
+		defer out.Close()
+
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		if len(usernames) > 0 {
+			filter.UserIDs = []string{}
+			for _, username := range usernames {
+				userID, err := manage.GetUserID(ctx, conn, username)
+				if err != nil {
+					logger.Error("failed to find user", zap.String("username", username), zap.Error(err))
+					return
+				}
+				filter.UserIDs = append(filter.UserIDs, userID)
+			}
+		}
+		err = export.Leaderboard(ctx, conn, out, format, args[0], filter)
+		if err != nil {
+			logger.Error("failed to export leaderboard", zap.Error(err))
+			return
+		}
+	},
+}
+
+// exportStandingsCmd represents the export standings command
+var exportStandingsCmd = &cobra.Command{
+	Use:   "standings <event id>",
+	Short: "exports the standings of an event.",
+	Long:  "Exports the overall standings of an event, ranking the racers by their points.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		timeRange, err := getExportTimeRange(cmd)
+		if err != nil {
+			logger.Error("unable to read time range flags", zap.Error(err))
+			return
+		}
+		filter := reports.StandingsFilter{
+			TimeRange: timeRange,
+		}
+		category, err := cmd.Flags().GetString("category")
+		if err != nil {
+			logger.Error("unable to read category flag", zap.Error(err))
+			return
+		}
+		if category != "" {
+			filter.Category = &category
+		}
+		ranking, err := cmd.Flags().GetString("ranking")
+		if err != nil {
+			logger.Error("unable to read ranking flag", zap.Error(err))
+			return
+		}
+		switch strings.ToUpper(ranking) {
+		case "STANDARD":
+		case "DENSE":
+			filter.Dense = true
+		default:
+			logger.Error("ranking flag is invalid", zap.String("ranking", ranking))
+			return
+		}
+		format, out, err := getExportOutput(cmd)
+		if err != nil {
+			logger.Error("unable to open export output", zap.Error(err))
+			return
+		}
+		defer out.Close()
+
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		err = export.Standings(ctx, conn, out, format, args[0], filter)
+		if err != nil {
+			logger.Error("failed to export standings", zap.Error(err))
+			return
+		}
+	},
+}
+
+// exportHistoryCmd represents the export history command
+var exportHistoryCmd = &cobra.Command{
+	Use:   "history <username>",
+	Short: "exports a racer's competition results.",
+	Long:  "Exports a racer's category results in each finished competition, most recent first.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		timeRange, err := getExportTimeRange(cmd)
+		if err != nil {
+			logger.Error("unable to read time range flags", zap.Error(err))
+			return
+		}
+		format, out, err := getExportOutput(cmd)
+		if err != nil {
+			logger.Error("unable to open export output", zap.Error(err))
+			return
+		}
+		defer out.Close()
+
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		userID, err := manage.GetUserID(ctx, conn, args[0])
+		if err != nil {
+			logger.Error("failed to find user", zap.String("username", args[0]), zap.Error(err))
+			return
+		}
+		err = export.UserHistory(ctx, conn, out, format, userID, timeRange)
+		if err != nil {
+			logger.Error("failed to export history", zap.Error(err))
+			return
+		}
+	},
+}
+
+// exportRecordsCmd represents the export records command
+var exportRecordsCmd = &cobra.Command{
+	Use:   "records",
+	Short: "exports the raw user records.",
+	Long:  "Exports the raw user records collected from the team logs, optionally filtered by racer, competition and time range.",
+	Run: func(cmd *cobra.Command, args []string) {
+		timeRange, err := getExportTimeRange(cmd)
+		if err != nil {
+			logger.Error("unable to read time range flags", zap.Error(err))
+			return
+		}
+		filter := export.RecordsFilter{
+			TimeRange: timeRange,
+		}
+		username, err := cmd.Flags().GetString("username")
+		if err != nil {
+			logger.Error("unable to read username flag", zap.Error(err))
+			return
+		}
+		competitionID, err := cmd.Flags().GetString("competition_id")
+		if err != nil {
+			logger.Error("unable to read competition_id flag", zap.Error(err))
+			return
+		}
+		if competitionID != "" {
+			filter.CompetitionID = &competitionID
+		}
+		format, out, err := getExportOutput(cmd)
+		if err != nil {
+			logger.Error("unable to open export output", zap.Error(err))
+			return
+		}
+		defer out.Close()
+
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		if username != "" {
+			userID, err := manage.GetUserID(ctx, conn, username)
+			if err != nil {
+				logger.Error("failed to find user", zap.String("username", username), zap.Error(err))
+				return
+			}
+			filter.UserID = &userID
+		}
+		err = export.UserRecords(ctx, conn, out, format, filter)
+		if err != nil {
+			logger.Error("failed to export records", zap.Error(err))
+			return
+		}
+	},
+}
+
+// getExportOutput reads the format and opens the output file (stdout if no file is given).
+func getExportOutput(cmd *cobra.Command) (export.Format, io.WriteCloser, error) {
+	formatValue, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to read format flag: %w", err)
+	}
+	format, err := export.ParseFormat(formatValue)
+	if err != nil {
+		return "", nil, err
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to read output flag: %w", err)
+	}
+	if output == "" {
+		return format, os.Stdout, nil
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to create output file: %w", err)
+	}
+	return format, f, nil
+}
+
+// getExportTimeRange reads the optional time_from and time_to flags, rounded the same way as the API time ranges.
+func getExportTimeRange(cmd *cobra.Command) (*reports.TimeRange, error) {
+	timeFromValue, err := cmd.Flags().GetString("time_from")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read time_from flag: %w", err)
+	}
+	timeToValue, err := cmd.Flags().GetString("time_to")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read time_to flag: %w", err)
+	}
+	if timeFromValue == "" && timeToValue == "" {
+		return nil, nil
+	}
+	timeFrom, err := parseTimeFlag(timeFromValue, time.UTC)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse time_from flag: %w", err)
+	}
+	timeTo, err := parseTimeFlag(timeToValue, time.UTC)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse time_to flag: %w", err)
+	}
+	timeFrom, timeTo = utils.TimeRound(timeFrom), utils.TimeRound(timeTo)
+	if !timeFrom.Before(timeTo) {
+		return nil, fmt.Errorf("time from must be before time to")
+	}
+	return &reports.TimeRange{TimeFrom: timeFrom, TimeTo: timeTo}, nil
+}
+
+func init() {
+	exportCmd.PersistentFlags().String("format", "csv", "export format (csv or ndjson)")
+	exportCmd.PersistentFlags().String("output", "", "file to write the export to (stdout if empty)")
+
+	exportLeaderboardCmd.Flags().String("category", "", "rank the racers by this category instead of their total points")
+	exportLeaderboardCmd.Flags().Int("limit", 0, "only export the top racers (all of them if 0)")
+	exportLeaderboardCmd.Flags().Bool("include_ineligible", true, "whether to include the racers that aren't eligible")
+	exportLeaderboardCmd.Flags().StringSlice("usernames", nil, "only export these racers (comma separated)")
+
+	exportStandingsCmd.Flags().String("category", "", "only count the rewards of this category")
+	exportStandingsCmd.Flags().String("ranking", "STANDARD", "how ties are ranked (STANDARD or DENSE)")
+
+	for _, c := range []*cobra.Command{exportStandingsCmd, exportHistoryCmd, exportRecordsCmd} {
+		c.Flags().String("time_from", "", "only export from this time, RFC3339 or UTC (e.g. 2021-12-01T00:01)")
+		c.Flags().String("time_to", "", "only export until this time, RFC3339 or UTC (e.g. 2021-12-25T00:01)")
+	}
+
+	exportRecordsCmd.Flags().String("username", "", "only export the records of this racer")
+	exportRecordsCmd.Flags().String("competition_id", "", "only export the records of this competition")
+
+	exportCmd.AddCommand(exportLeaderboardCmd)
+	exportCmd.AddCommand(exportStandingsCmd)
+	exportCmd.AddCommand(exportHistoryCmd)
+	exportCmd.AddCommand(exportRecordsCmd)
+
+	rootCmd.AddCommand(exportCmd)
+}
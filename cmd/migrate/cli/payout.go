@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"nt-folly-xmaxx-comp/internal/pkg/db"
+	"nt-folly-xmaxx-comp/internal/pkg/manage"
+	"nt-folly-xmaxx-comp/internal/pkg/payouts"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// payoutCmd represents the payout command
+var payoutCmd = &cobra.Command{
+	Use:   "payout",
+	Short: "manages the prize payouts.",
+	Long:  "Manages the prize payouts (in-game cash or gifts) and the outstanding balances.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Usage()
+	},
+}
+
+// payoutCreateCmd represents the payout create command
+var payoutCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "records a pending payout.",
+	Long:  "Records a pending payout to a racer for an event.",
+	Run: func(cmd *cobra.Command, args []string) {
+		eventID, err := cmd.Flags().GetString("event_id")
+		if err != nil {
+			logger.Error("unable to read event_id flag", zap.Error(err))
+			return
+		}
+		username, err := cmd.Flags().GetString("username")
+		if err != nil {
+			logger.Error("unable to read username flag", zap.Error(err))
+			return
+		}
+		amount, err := cmd.Flags().GetInt("amount")
+		if err != nil {
+			logger.Error("unable to read amount flag", zap.Error(err))
+			return
+		}
+		note, err := cmd.Flags().GetString("note")
+		if err != nil {
+			logger.Error("unable to read note flag", zap.Error(err))
+			return
+		}
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		userID, err := manage.GetUserID(ctx, conn, username)
+		if err != nil {
+			logger.Error("failed to find user", zap.String("username", username), zap.Error(err))
+			return
+		}
+		payout, err := payouts.Create(ctx, conn, eventID, userID, amount, note)
+		if err != nil {
+			logger.Error("failed to create payout", zap.Error(err))
+			return
+		}
+		logger.Info("payout created", zap.String("payoutID", payout.ID))
+	},
+}
+
+// payoutStatusCmd represents the payout status command
+var payoutStatusCmd = &cobra.Command{
+	Use:   "status <payout id>",
+	Short: "moves a payout to its next status.",
+	Long:  "Moves a payout to its next status (PENDING, SENT then CONFIRMED).",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		status, err := cmd.Flags().GetString("status")
+		if err != nil {
+			logger.Error("unable to read status flag", zap.Error(err))
+			return
+		}
+		var note *string
+		if cmd.Flags().Changed("note") {
+			noteValue, err := cmd.Flags().GetString("note")
+			if err != nil {
+				logger.Error("unable to read note flag", zap.Error(err))
+				return
+			}
+			note = &noteValue
+		}
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		payout, err := payouts.SetStatus(ctx, conn, args[0], strings.ToUpper(status), note)
+		if err != nil {
+			logger.Error("failed to update payout status", zap.Error(err))
+			return
+		}
+		logger.Info("payout status updated", zap.String("payoutID", payout.ID), zap.String("status", payout.Status))
+	},
+}
+
+// payoutCancelCmd represents the payout cancel command
+var payoutCancelCmd = &cobra.Command{
+	Use:   "cancel <payout id>",
+	Short: "cancels a pending payout.",
+	Long:  "Cancels a payout that hasn't been sent yet.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		err = payouts.Cancel(ctx, conn, args[0])
+		if err != nil {
+			logger.Error("failed to cancel payout", zap.Error(err))
+			return
+		}
+		logger.Info("payout cancelled", zap.String("payoutID", args[0]))
+	},
+}
+
+// payoutBalancesCmd represents the payout balances command
+var payoutBalancesCmd = &cobra.Command{
+	Use:   "balances",
+	Short: "prints the payout balances as CSV.",
+	Long:  "Prints each racer's earned, paid and outstanding balance of an event as CSV.",
+	Run: func(cmd *cobra.Command, args []string) {
+		eventID, err := cmd.Flags().GetString("event_id")
+		if err != nil {
+			logger.Error("unable to read event_id flag", zap.Error(err))
+			return
+		}
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		balances, err := payouts.Balances(ctx, conn, eventID)
+		if err != nil {
+			logger.Error("failed to query payout balances", zap.Error(err))
+			return
+		}
+		err = payouts.WriteBalancesCSV(os.Stdout, balances)
+		if err != nil {
+			logger.Error("failed to write payout balances", zap.Error(err))
+			return
+		}
+	},
+}
+
+// payoutSetRateCmd represents the payout set-rate command
+var payoutSetRateCmd = &cobra.Command{
+	Use:   "set-rate",
+	Short: "changes an event's payout rate.",
+	Long:  "Changes the in-game cash paid for each reward point of an event.",
+	Run: func(cmd *cobra.Command, args []string) {
+		eventID, err := cmd.Flags().GetString("event_id")
+		if err != nil {
+			logger.Error("unable to read event_id flag", zap.Error(err))
+			return
+		}
+		rate, err := cmd.Flags().GetFloat64("rate")
+		if err != nil {
+			logger.Error("unable to read rate flag", zap.Error(err))
+			return
+		}
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		err = payouts.SetRate(ctx, conn, eventID, rate)
+		if err != nil {
+			logger.Error("failed to set payout rate", zap.Error(err))
+			return
+		}
+		logger.Info("payout rate updated", zap.String("eventID", eventID), zap.Float64("rate", rate))
+	},
+}
+
+func init() {
+	payoutCreateCmd.Flags().String("event_id", "", "event the payout is for")
+	payoutCreateCmd.Flags().String("username", "", "nitro type username of the racer")
+	payoutCreateCmd.Flags().Int("amount", 0, "in-game cash (or value of the gift) paid out")
+	payoutCreateCmd.Flags().String("note", "", "note about the payout (e.g. gift sent)")
+
+	payoutStatusCmd.Flags().String("status", "SENT", "new payout status (SENT or CONFIRMED)")
+	payoutStatusCmd.Flags().String("note", "", "replaces the payout note")
+
+	payoutBalancesCmd.Flags().String("event_id", "", "event to list the balances of")
+
+	payoutSetRateCmd.Flags().String("event_id", "", "event to update")
+	payoutSetRateCmd.Flags().Float64("rate", 1, "in-game cash paid for each reward point")
+
+	payoutCmd.AddCommand(payoutCreateCmd)
+	payoutCmd.AddCommand(payoutStatusCmd)
+	payoutCmd.AddCommand(payoutCancelCmd)
+	payoutCmd.AddCommand(payoutBalancesCmd)
+	payoutCmd.AddCommand(payoutSetRateCmd)
+
+	rootCmd.AddCommand(payoutCmd)
+}
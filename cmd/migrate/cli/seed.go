@@ -1,8 +1,14 @@
 package cli
 
 import (
-	"nt-folly-xmaxx-comp/internal/app/migrate/seed"
+	"fmt"
 	"nt-folly-xmaxx-comp/internal/pkg/db"
+	"nt-folly-xmaxx-comp/internal/pkg/manage"
+	"nt-folly-xmaxx-comp/internal/pkg/seed"
+	"nt-folly-xmaxx-comp/internal/pkg/utils"
+	"os"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -12,8 +18,8 @@ import (
 // dbSeedCompetition represents the seed-comp command
 var dbSeedCompetition = &cobra.Command{
 	Use:   "seed-comp",
-	Short: "runs db migrate drop command.",
-	Long:  "Runs DB Migration Drop command.",
+	Short: "seeds an event and its competitions.",
+	Long:  "Seeds an event and its competitions between the time range. Use --preview to print the schedule without saving it.",
 	Run: func(cmd *cobra.Command, args []string) {
 		timeFromValue, err := cmd.Flags().GetString("time_from")
 		if err != nil {
@@ -25,35 +31,252 @@ var dbSeedCompetition = &cobra.Command{
 			logger.Error("unable to read time_to flag", zap.Error(err))
 			return
 		}
-		timeFrom, err := time.Parse(time.RFC3339, timeFromValue)
+		tiePolicy, err := cmd.Flags().GetString("tie_policy")
+		if err != nil {
+			logger.Error("unable to read tie_policy flag", zap.Error(err))
+			return
+		}
+		tiePolicy = strings.ToUpper(tiePolicy)
+		if tiePolicy != "SHARE" && tiePolicy != "FULL" && tiePolicy != "BREAK" {
+			logger.Error("tie_policy flag is invalid", zap.String("tiePolicy", tiePolicy))
+			return
+		}
+		tieBreakerValue, err := cmd.Flags().GetString("tie_breaker")
+		if err != nil {
+			logger.Error("unable to read tie_breaker flag", zap.Error(err))
+			return
+		}
+		var tieBreaker *string
+		if tieBreakerValue != "" {
+			tieBreakerValue = strings.ToUpper(tieBreakerValue)
+			tieBreaker = &tieBreakerValue
+		} else if tiePolicy == "BREAK" {
+			logger.Error("tie_breaker flag is required when breaking ties")
+			return
+		}
+		tieBreakerDirection, err := cmd.Flags().GetString("tie_breaker_direction")
+		if err != nil {
+			logger.Error("unable to read tie_breaker_direction flag", zap.Error(err))
+			return
+		}
+		tieBreakerDirection = strings.ToUpper(tieBreakerDirection)
+		timezone, err := cmd.Flags().GetString("timezone")
+		if err != nil {
+			logger.Error("unable to read timezone flag", zap.Error(err))
+			return
+		}
+		loc, err := utils.LoadTimezone(timezone)
+		if err != nil {
+			logger.Error("timezone flag is invalid", zap.Error(err))
+			return
+		}
+		timeFrom, err := parseTimeFlag(timeFromValue, loc)
 		if err != nil {
 			logger.Error("unable to parse time_from flag", zap.Error(err))
 			return
 		}
-		timeTo, err := time.Parse(time.RFC3339, timeToValue)
+		timeTo, err := parseTimeFlag(timeToValue, loc)
 		if err != nil {
 			logger.Error("unable to parse time_to flag", zap.Error(err))
 			return
 		}
+		eventName, err := cmd.Flags().GetString("event_name")
+		if err != nil {
+			logger.Error("unable to read event_name flag", zap.Error(err))
+			return
+		}
+		teamTag, err := cmd.Flags().GetString("team_tag")
+		if err != nil {
+			logger.Error("unable to read team_tag flag", zap.Error(err))
+			return
+		}
+		teamID, err := cmd.Flags().GetInt("team_id")
+		if err != nil {
+			logger.Error("unable to read team_id flag", zap.Error(err))
+			return
+		}
+		rules, err := cmd.Flags().GetString("rules")
+		if err != nil {
+			logger.Error("unable to read rules flag", zap.Error(err))
+			return
+		}
+		windowMinutes, err := cmd.Flags().GetInt("window")
+		if err != nil {
+			logger.Error("unable to read window flag", zap.Error(err))
+			return
+		}
+		minRaces, err := cmd.Flags().GetInt("min_races")
+		if err != nil {
+			logger.Error("unable to read min_races flag", zap.Error(err))
+			return
+		}
+		minSecs, err := cmd.Flags().GetInt("min_secs")
+		if err != nil {
+			logger.Error("unable to read min_secs flag", zap.Error(err))
+			return
+		}
+		minTyped, err := cmd.Flags().GetInt("min_typed")
+		if err != nil {
+			logger.Error("unable to read min_typed flag", zap.Error(err))
+			return
+		}
+		payoutRate, err := cmd.Flags().GetFloat64("payout_rate")
+		if err != nil {
+			logger.Error("unable to read payout_rate flag", zap.Error(err))
+			return
+		}
+		config := seed.CompetitionConfig{
+			EventName:           eventName,
+			TeamTag:             teamTag,
+			TeamID:              teamID,
+			Rules:               rules,
+			TimeFrom:            timeFrom,
+			TimeTo:              timeTo,
+			Timezone:            timezone,
+			WindowMinutes:       windowMinutes,
+			TiePolicy:           tiePolicy,
+			TieBreaker:          tieBreaker,
+			TieBreakerDirection: tieBreakerDirection,
+			MinRaces:            minRaces,
+			MinSecs:             minSecs,
+			MinTyped:            minTyped,
+			PayoutRate:          payoutRate,
+			Multipliers: &seed.MultiplierSchedule{
+				Weights: seed.DefaultMultiplierWeights,
+			},
+		}
+		schedulePath, err := cmd.Flags().GetString("multiplier_schedule")
+		if err != nil {
+			logger.Error("unable to read multiplier_schedule flag", zap.Error(err))
+			return
+		}
+		if schedulePath != "" {
+			config.Multipliers, err = seed.LoadMultiplierSchedule(schedulePath)
+			if err != nil {
+				logger.Error("unable to load multiplier schedule", zap.Error(err))
+				return
+			}
+			if len(config.Multipliers.Weights) == 0 {
+				config.Multipliers.Weights = seed.DefaultMultiplierWeights
+			}
+		}
+		multiplierSeed, err := cmd.Flags().GetInt64("seed")
+		if err != nil {
+			logger.Error("unable to read seed flag", zap.Error(err))
+			return
+		}
+		if multiplierSeed != 0 {
+			config.Multipliers.Seed = multiplierSeed
+		}
+		if config.Multipliers.Seed == 0 {
+			config.Multipliers.Seed = time.Now().UnixNano()
+		}
+
+		preview, err := cmd.Flags().GetBool("preview")
+		if err != nil {
+			logger.Error("unable to read preview flag", zap.Error(err))
+			return
+		}
+		if preview {
+			plan, err := seed.PlanCompetition(config)
+			if err != nil {
+				logger.Error("failed to plan comp", zap.Error(err))
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "seed: %d\n", config.Multipliers.Seed)
+			fmt.Fprintln(w, "FROM\tTO\tMULTIPLIER")
+			for _, c := range plan {
+				fmt.Fprintf(w, "%s\t%s\t%dx\n", c.FromAt.Format(time.RFC3339), c.ToAt.Format(time.RFC3339), c.Multiplier)
+			}
+			w.Flush()
+			return
+		}
+
 		ctx := cmd.Context()
 		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
 		if err != nil {
 			logger.Error("db connection failed", zap.Error(err))
 			return
 		}
-		logger.Info("db seed comp started")
-		err = seed.SetupCompetition(ctx, conn, timeFrom, timeTo)
+		logger.Info("db seed comp started", zap.Int64("seed", config.Multipliers.Seed))
+		eventID, err := seed.SetupCompetition(ctx, conn, config)
 		if err != nil {
 			logger.Error("failed to db seed comp", zap.Error(err))
 			return
 		}
-		logger.Info("db seed comp finished")
+		logger.Info("db seed comp finished", zap.String("eventID", eventID))
+	},
+}
+
+// parseTimeFlag parses a RFC3339 time, or a local time (2006-01-02T15:04) in the event timezone.
+func parseTimeFlag(value string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04", value, loc)
+}
+
+// dbSetMultiplier represents the set-multiplier command
+var dbSetMultiplier = &cobra.Command{
+	Use:   "set-multiplier",
+	Short: "changes a competition multiplier.",
+	Long:  "Changes a competition multiplier and recomputes the results if it has already finished.",
+	Run: func(cmd *cobra.Command, args []string) {
+		competitionID, err := cmd.Flags().GetString("competition_id")
+		if err != nil {
+			logger.Error("unable to read competition_id flag", zap.Error(err))
+			return
+		}
+		if competitionID == "" {
+			logger.Error("competition_id flag is required")
+			return
+		}
+		multiplier, err := cmd.Flags().GetInt("multiplier")
+		if err != nil {
+			logger.Error("unable to read multiplier flag", zap.Error(err))
+			return
+		}
+		ctx := cmd.Context()
+		conn, err := db.ConnectPool(ctx, db.GetConnectionString(), logger)
+		if err != nil {
+			logger.Error("db connection failed", zap.Error(err))
+			return
+		}
+		logger.Info("db set multiplier started")
+		err = manage.SetMultiplier(ctx, conn, competitionID, multiplier)
+		if err != nil {
+			logger.Error("failed to set multiplier", zap.Error(err))
+			return
+		}
+		logger.Info("db set multiplier finished")
 	},
 }
 
 func init() {
-	dbSeedCompetition.Flags().String("time_from", "", "comp time from (it'll round down to the nearest 1st minute)")
-	dbSeedCompetition.Flags().String("time_to", "", "comp time to (it'll round down to the nearest 1st minute)")
+	dbSeedCompetition.Flags().String("event_name", "Folly Xmaxx", "name of the event")
+	dbSeedCompetition.Flags().String("team_tag", "FOLLY", "team tag to track stats")
+	dbSeedCompetition.Flags().Int("team_id", 1411729, "nitro type team id to cross check stats against")
+	dbSeedCompetition.Flags().String("rules", "", "event rules shown to the racers")
+	dbSeedCompetition.Flags().Int("window", 10, "length of each competition in minutes")
+	dbSeedCompetition.Flags().String("time_from", "", "comp time from, RFC3339 or local to the timezone (it'll round down to the nearest 1st minute)")
+	dbSeedCompetition.Flags().String("time_to", "", "comp time to, RFC3339 or local to the timezone (it'll round down to the nearest 1st minute)")
+	dbSeedCompetition.Flags().String("timezone", "UTC", "IANA timezone of the event (e.g. Australia/Brisbane)")
+	dbSeedCompetition.Flags().String("tie_policy", "FULL", "how tied racers are paid (SHARE, FULL or BREAK)")
+	dbSeedCompetition.Flags().String("tie_breaker", "", "metric used to break ties with the BREAK tie policy (e.g. PLAYED)")
+	dbSeedCompetition.Flags().String("tie_breaker_direction", "DESC", "sort direction of the tie breaker metric (ASC or DESC)")
+	dbSeedCompetition.Flags().Int("min_races", 1, "races needed in a competition to be ranked")
+	dbSeedCompetition.Flags().Int("min_secs", 0, "seconds of racing needed in a competition to be ranked")
+	dbSeedCompetition.Flags().Int("min_typed", 0, "characters typed needed in a competition to be ranked")
+	dbSeedCompetition.Flags().Float64("payout_rate", 1, "in-game cash paid for each reward point")
+	dbSeedCompetition.Flags().String("multiplier_schedule", "", "JSON file declaring the multiplier rules (weights, happyHours and explicit)")
+	dbSeedCompetition.Flags().Int64("seed", 0, "random seed for the weighted multipliers (a random seed is recorded if not set)")
+	dbSeedCompetition.Flags().Bool("preview", false, "prints the competition schedule without saving it")
+
+	dbSetMultiplier.Flags().String("competition_id", "", "competition to update")
+	dbSetMultiplier.Flags().Int("multiplier", 1, "new multiplier")
 
 	rootCmd.AddCommand(dbSeedCompetition)
+	rootCmd.AddCommand(dbSetMultiplier)
 }
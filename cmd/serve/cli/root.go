@@ -5,6 +5,7 @@ import (
 	"log"
 	"nt-folly-xmaxx-comp/internal/pkg/cli"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -38,6 +39,14 @@ func init() {
 	rootCmd.PersistentFlags().String("cors_allowed_headers", "Accept,Authorization,Cache-Control,Content-Type,DNT,If-Modified-Since,Keep-Alive,Origin,User-Agent,X-Requested-With", "allowed http headers for CORS")
 	rootCmd.PersistentFlags().Bool("cors_allow_credentials", true, "whether to allow credentials for CORS")
 	rootCmd.PersistentFlags().Int("cors_max_age", 1728000, "TTL to cache CORS")
+	rootCmd.PersistentFlags().String("browser_user_agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.93 Safari/537.36", "browser user agent used to refresh the provisional leaderboards")
+
+	rootCmd.PersistentFlags().Int("cache_max_entries", 1000, "maximum number of leaderboards and standings to cache (disabled if 0)")
+	rootCmd.PersistentFlags().Duration("cache_ttl", 10*time.Minute, "how long a cached result is kept if its competition isn't updated")
+	rootCmd.PersistentFlags().Int("apq_cache_size", 1000, "number of automatic persisted queries to keep")
+	rootCmd.PersistentFlags().Duration("http_cache_max_age", 5*time.Minute, "max-age of the GET responses with only finished competitions (revalidated if 0)")
+	rootCmd.PersistentFlags().Int("gql_max_complexity", 10000, "maximum cost of a GQL operation, list fields cost as much as the items they can return (disabled if 0)")
+	rootCmd.PersistentFlags().Int("gql_max_depth", 12, "maximum nesting of the fields of a GQL operation (disabled if 0)")
 
 	viper.BindPFlag("api_addr", rootCmd.PersistentFlags().Lookup("api_addr"))
 	viper.BindPFlag("cors_allowed_origins", rootCmd.PersistentFlags().Lookup("cors_allowed_origins"))
@@ -45,6 +54,13 @@ func init() {
 	viper.BindPFlag("cors_allowed_headers", rootCmd.PersistentFlags().Lookup("cors_allowed_headers"))
 	viper.BindPFlag("cors_allow_credentials", rootCmd.PersistentFlags().Lookup("cors_allow_credentials"))
 	viper.BindPFlag("cors_max_age", rootCmd.PersistentFlags().Lookup("cors_max_age"))
+	viper.BindPFlag("browser_user_agent", rootCmd.PersistentFlags().Lookup("browser_user_agent"))
+	viper.BindPFlag("cache_max_entries", rootCmd.PersistentFlags().Lookup("cache_max_entries"))
+	viper.BindPFlag("cache_ttl", rootCmd.PersistentFlags().Lookup("cache_ttl"))
+	viper.BindPFlag("apq_cache_size", rootCmd.PersistentFlags().Lookup("apq_cache_size"))
+	viper.BindPFlag("http_cache_max_age", rootCmd.PersistentFlags().Lookup("http_cache_max_age"))
+	viper.BindPFlag("gql_max_complexity", rootCmd.PersistentFlags().Lookup("gql_max_complexity"))
+	viper.BindPFlag("gql_max_depth", rootCmd.PersistentFlags().Lookup("gql_max_depth"))
 
 	// Setup CLI
 	cobra.OnInitialize(cli.InitConfig(rootCmd), func() {
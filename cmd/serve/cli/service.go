@@ -4,7 +4,12 @@ import (
 	"errors"
 	"net/http"
 	"nt-folly-xmaxx-comp/internal/app/serve/api"
+	"nt-folly-xmaxx-comp/internal/app/serve/cache"
+	"nt-folly-xmaxx-comp/internal/app/serve/httpcache"
+	"nt-folly-xmaxx-comp/internal/app/serve/pubsub"
 	"nt-folly-xmaxx-comp/internal/pkg/db"
+	"nt-folly-xmaxx-comp/internal/pkg/metrics"
+	"nt-folly-xmaxx-comp/pkg/nitrotype/clients"
 	"os"
 	"os/signal"
 	"runtime"
@@ -29,7 +34,30 @@ var serviceCmd = &cobra.Command{
 			return
 		}
 
+		err = metrics.RegisterPool("serve", conn)
+		if err != nil {
+			logger.Error("unable to register db pool metrics", zap.Error(err))
+			return
+		}
+
+		// Start listening to the collector notifications
+		broker := pubsub.NewBroker(conn, logger)
+		go broker.Start(ctx)
+
+		// Cache the results until the collector updates them
+		resultCache := cache.New(viper.GetInt("cache_max_entries"), viper.GetDuration("cache_ttl"))
+		go resultCache.Listen(ctx, broker, logger)
+
+		// Derive the ETags from the sync version
+		syncVersion, err := httpcache.NewSyncVersion(ctx, conn, logger)
+		if err != nil {
+			logger.Error("unable to load sync version", zap.Error(err))
+			return
+		}
+		go syncVersion.Listen(ctx, broker)
+
 		// Start API Service
+		apiClient := clients.NewAPIClientBrowser(viper.GetString("browser_user_agent"))
 		corsOptions := &cors.Options{
 			AllowedOrigins:   strings.Split(viper.GetString("cors_allowed_origins"), ","),
 			AllowedMethods:   strings.Split(viper.GetString("cors_allowed_methods"), ","),
@@ -37,8 +65,20 @@ var serviceCmd = &cobra.Command{
 			AllowCredentials: viper.GetBool("cors_allow_credentials"),
 			MaxAge:           viper.GetInt("cors_max_age"),
 		}
+		cacheOptions := &api.CacheOptions{
+			APQCacheSize: viper.GetInt("apq_cache_size"),
+			MaxAge:       viper.GetDuration("http_cache_max_age"),
+		}
+		if cacheOptions.APQCacheSize < 1 {
+			logger.Error("apq_cache_size must be at least 1")
+			return
+		}
+		limitOptions := &api.LimitOptions{
+			MaxDepth:      viper.GetInt("gql_max_depth"),
+			MaxComplexity: viper.GetInt("gql_max_complexity"),
+		}
 		apiAddr := viper.GetString("api_addr")
-		apiService := api.NewAPIService(conn, logger, corsOptions)
+		apiService := api.NewAPIService(conn, broker, resultCache, syncVersion, apiClient, logger, corsOptions, cacheOptions, limitOptions)
 		server := &http.Server{
 			Addr:    apiAddr,
 			Handler: apiService,
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"nt-folly-xmaxx-comp/internal/pkg/records"
+	"nt-folly-xmaxx-comp/internal/pkg/results"
 	"nt-folly-xmaxx-comp/internal/pkg/utils"
 	"nt-folly-xmaxx-comp/pkg/nitrotype"
 	"time"
@@ -18,410 +21,528 @@ import (
 )
 
 // NewCronService creates a new cron service ready to be activated
-func NewCronService(ctx context.Context, conn *pgxpool.Pool, log *zap.Logger, apiClient nitrotype.APIClient, teamTag string, teamID int) *cron.Cron {
+func NewCronService(ctx context.Context, conn *pgxpool.Pool, log *zap.Logger, apiClient nitrotype.APIClient) *cron.Cron {
 	logger := zapr.NewLogger(log)
 	c := cron.New(
 		cron.WithChain(cron.DelayIfStillRunning(logger)),
 	)
-	c.AddFunc("1,11,21,31,41,51 * * * *", syncTeams(ctx, conn, log, apiClient, teamTag, teamID))
+	c.AddFunc("* * * * *", syncEvents(ctx, conn, log, apiClient))
 	return c
 }
 
-// syncTeams is the scheduled task function that collect Nitro Type Team Logs.
-func syncTeams(ctx context.Context, conn *pgxpool.Pool, log *zap.Logger, apiClient nitrotype.APIClient, teamTag string, teamID int) func() {
-	log = log.With(
-		zap.String("job", "syncTeams"),
-		zap.String("team", teamTag),
-	)
+// eventTeam is a team that has competitions due to start or finish.
+type eventTeam struct {
+	teamTag  string
+	teamID   int
+	eventIDs []string
+}
 
-	return func() {
-		now := time.Now()
-		now = time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
-		updateComp := false
-		updatedNextComp := false
-		updatedPrevComp := false
-		defer func() {
-			if r := recover(); r != nil {
-				log.Error("recovering from panic", zap.Any("panic", r))
-			}
-			if updateComp {
-				log.Info("updating comp on fail stat collection")
-				if !updatedPrevComp {
-					err := updatePreviousComp(context.Background(), conn, now, "FAILED", nil)
-					if err != nil {
-						log.Error("failed to update previous comp", zap.Error(err))
-					}
-				}
-				if !updatedNextComp {
-					err := startNextComp(context.Background(), conn, now)
-					if err != nil {
-						log.Error("failed to update previous comp", zap.Error(err))
-					}
-				}
-			}
-		}()
+// syncEvents is the scheduled task function that checks which active events need their team logs collected.
+func syncEvents(ctx context.Context, conn *pgxpool.Pool, log *zap.Logger, apiClient nitrotype.APIClient) func() {
+	log = log.With(zap.String("job", "syncEvents"))
 
-		log.Info("sync teams started")
+	return func() {
+		now := time.Now().UTC().Truncate(time.Minute)
 
-		var (
-			timeFrom time.Time
-			timeTo   time.Time
-		)
-		q := `SELECT MIN(from_at), MAX(to_at) FROM competitions`
-		err := conn.QueryRow(ctx, q).Scan(&timeFrom, &timeTo)
+		teams, err := getDueTeams(ctx, conn, now)
 		if err != nil {
-			log.Error("unable to query comp dates", zap.Error(err))
+			log.Error("unable to query active events", zap.Error(err))
 			return
 		}
-
-		if now.Before(timeFrom) {
-			log.Info("comp has not started")
-			return
-		} else if now.After(timeTo) {
-			log.Info("comp has finished")
+		if len(teams) == 0 {
+			log.Debug("no competitions are due")
 			return
 		}
-
-		updateComp = true
-
-		// Get Previous Log
-		var (
-			prevRequestID pgtype.UUID
-			prevLogID     pgtype.UUID
-		)
-		q = `
-			SELECT id, api_team_log_id
-			FROM nt_api_team_log_requests
-			WHERE deleted_at IS NULL
-			ORDER BY created_at DESC
-			LIMIT 1`
-		err = conn.QueryRow(ctx, q).Scan(&prevRequestID, &prevLogID)
-		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-			log.Error("unable to query previous log", zap.Error(err))
-			return
+		for _, team := range teams {
+			syncTeam(ctx, conn, log, apiClient, team, now)
 		}
+	}
+}
 
-		// Grab Latest Stats
-		teamData, err := apiClient.GetTeam(teamTag)
-		if err != nil || !teamData.Success || teamData.Data.Info == nil {
-			log.Error("unable to pull team log", zap.Error(err))
+// getDueTeams finds the teams of active events that have a competition to start or finish.
+func getDueTeams(ctx context.Context, conn *pgxpool.Pool, timeAt time.Time) ([]eventTeam, error) {
+	q := `
+		SELECT e.team_tag, e.team_id, array_agg(DISTINCT e.id::text)
+		FROM events e
+			INNER JOIN competitions c ON c.event_id = e.id
+		WHERE e.deleted_at IS NULL
+			AND c.deleted_at IS NULL
+			AND (
+				(c.status = 'DRAFT' AND c.from_at <= $1 AND c.to_at > $1)
+				OR (c.status = 'STARTED' AND c.to_at <= $1)
+			)
+		GROUP BY e.team_tag, e.team_id`
+	rows, err := conn.Query(ctx, q, timeAt)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query due events: %w", err)
+	}
+	defer rows.Close()
+	output := []eventTeam{}
+	for rows.Next() {
+		row := eventTeam{}
+		err := rows.Scan(&row.teamTag, &row.teamID, &row.eventIDs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan due events: %w", err)
+		}
+		output = append(output, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan due events: %w", err)
+	}
+	return output, nil
+}
 
-			// Record Fail Request
-			if prevLogID.Status == pgtype.Present && prevRequestID.Status == pgtype.Present {
-				responseType := "ERROR"
-				description := "Unknown error"
-				if err != nil {
-					description = err.Error()
-				} else if !teamData.Success || teamData.Data.Info == nil {
-					description = "Team API Request Failed"
-				}
-				var newLogID pgtype.UUID
-				q = `
-					INSERT INTO nt_api_team_log_requests (prev_id, api_team_log_id, response_type, description)
-					VALUES ($1, $2, $3, $4)
-					RETURNING id`
-				err = conn.QueryRow(ctx, q, prevRequestID, prevLogID, responseType, description).Scan(&newLogID)
+// syncTeam collects the Nitro Type Team Log of a team and updates its event competitions.
+func syncTeam(ctx context.Context, conn *pgxpool.Pool, log *zap.Logger, apiClient nitrotype.APIClient, team eventTeam, now time.Time) {
+	log = log.With(
+		zap.String("team", team.teamTag),
+		zap.Strings("events", team.eventIDs),
+	)
+	teamTag := team.teamTag
+	teamID := team.teamID
+	eventIDs := team.eventIDs
+
+	start := time.Now()
+	defer func() {
+		syncDuration.WithLabelValues(teamTag).Observe(time.Since(start).Seconds())
+	}()
+
+	updateComp := false
+	updatedNextComp := false
+	updatedPrevComp := false
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("recovering from panic", zap.Any("panic", r))
+		}
+		if updateComp {
+			notifySync(context.Background(), conn, log, team, now, "FAILED")
+			log.Info("updating comp on fail stat collection")
+			if !updatedPrevComp {
+				_, err := updatePreviousComp(context.Background(), conn, eventIDs, now, "FAILED", nil)
 				if err != nil {
-					log.Error("unable to insert request log (error)", zap.Error(err))
+					log.Error("failed to update previous comp", zap.Error(err))
 				}
-				var newLogIDVal string
-				newLogID.AssignTo(&newLogIDVal)
-				err = updatePreviousComp(ctx, conn, now, "FAILED", &newLogIDVal)
+			}
+			if !updatedNextComp {
+				err := startNextComp(context.Background(), conn, eventIDs, now)
 				if err != nil {
-					log.Error("unable to fail comp results", zap.Error(err))
-					return
+					log.Error("failed to update previous comp", zap.Error(err))
 				}
 			}
-			return
 		}
+	}()
 
-		// Check if data doesn't matches team
-		if teamID != teamData.Data.Info.TeamID {
-			log.Error("team has changed", zap.Int("teamID", teamData.Data.Info.TeamID))
-			return
+	log.Info("sync teams started")
+
+	updateComp = true
+
+	// Get Previous Log
+	var (
+		prevRequestID pgtype.UUID
+		prevLogID     pgtype.UUID
+	)
+	q := `
+		SELECT id, api_team_log_id
+		FROM nt_api_team_log_requests
+		WHERE deleted_at IS NULL
+			AND team_tag = $1
+		ORDER BY created_at DESC
+		LIMIT 1`
+	err := conn.QueryRow(ctx, q, teamTag).Scan(&prevRequestID, &prevLogID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Error("unable to query previous log", zap.Error(err))
+		return
+	}
+
+	// Grab Latest Stats
+	teamData, err := apiClient.GetTeam(teamTag)
+	if err != nil || !teamData.Success || teamData.Data.Info == nil {
+		log.Error("unable to pull team log", zap.Error(err))
+		teamRequests.WithLabelValues(teamTag, "ERROR").Inc()
+
+		// Record Fail Request
+		if prevLogID.Status == pgtype.Present && prevRequestID.Status == pgtype.Present {
+			responseType := "ERROR"
+			description := "Unknown error"
+			if err != nil {
+				description = err.Error()
+			} else if !teamData.Success || teamData.Data.Info == nil {
+				description = "Team API Request Failed"
+			}
+			var newLogID pgtype.UUID
+			q = `
+				INSERT INTO nt_api_team_log_requests (prev_id, api_team_log_id, team_tag, response_type, description)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING id`
+			err = conn.QueryRow(ctx, q, prevRequestID, prevLogID, teamTag, responseType, description).Scan(&newLogID)
+			if err != nil {
+				log.Error("unable to insert request log (error)", zap.Error(err))
+			}
+			var newLogIDVal string
+			newLogID.AssignTo(&newLogIDVal)
+			_, err = updatePreviousComp(ctx, conn, eventIDs, now, "FAILED", &newLogIDVal)
+			if err != nil {
+				log.Error("unable to fail comp results", zap.Error(err))
+				return
+			}
 		}
+		return
+	}
+
+	// Check if data doesn't matches team
+	if teamID != teamData.Data.Info.TeamID {
+		log.Error("team has changed", zap.Int("teamID", teamData.Data.Info.TeamID))
+		return
+	}
+
+	// Calculate Hash
+	data, err := json.Marshal(teamData)
+	if err != nil {
+		log.Error("unable to marshal team data", zap.Error(err))
+		return
+	}
+	hash, err := utils.HashData(data)
+	if err != nil {
+		log.Error("unable to calculate team data hash", zap.Error(err))
+		return
+	}
 
-		// Calculate Hash
-		data, err := json.Marshal(teamData)
+	// Insert Team Log
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Error("unable to start recording team data", zap.Error(err))
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	logID := ""
+	responseType := "NEW"
+	description := "New log download"
+	q = `SELECT id FROM nt_api_team_logs WHERE hash = $1`
+	err = tx.QueryRow(ctx, q, hash).Scan(&logID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Error("unable to find existing team log", zap.Error(err))
+		return
+	}
+	if logID == "" {
+		q := `
+			INSERT INTO nt_api_team_logs (hash, log_data)
+			VALUES ($1, $2)
+			ON CONFLICT (hash) DO NOTHING
+			RETURNING id`
+		err = tx.QueryRow(ctx, q, hash, data).Scan(&logID)
 		if err != nil {
-			log.Error("unable to marshal team data", zap.Error(err))
+			log.Error("unable to insert team log", zap.Error(err))
 			return
 		}
-		hash, err := utils.HashData(data)
-		if err != nil {
-			log.Error("unable to calculate team data hash", zap.Error(err))
-			return
+	}
+	if logID == "" {
+		log.Error("unable to find team log id (blank data)")
+		return
+	}
+
+	if prevLogID.Status == pgtype.Present {
+		prevLogIDText := ""
+		prevLogID.AssignTo(&prevLogIDText)
+		if prevLogIDText == logID {
+			responseType = "CACHE"
+			description = "Same log found"
 		}
+	}
+	if prevRequestID.Status != pgtype.Present {
+		prevRequestID.Set(nil)
+	}
+
+	// Insert Team Log Request
+	var newLogID string
+	q = `
+		INSERT INTO nt_api_team_log_requests (prev_id, api_team_log_id, team_tag, response_type, description)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	err = tx.QueryRow(ctx, q, prevRequestID, logID, teamTag, responseType, description).Scan(&newLogID)
+	if err != nil {
+		log.Error("unable to insert team log request", zap.Error(err))
+		return
+	}
+
+	// Commit Transaction
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Error("unable to finish recording team data", zap.Error(err))
+		return
+	}
+	teamRequests.WithLabelValues(teamTag, responseType).Inc()
+	teamMembers.WithLabelValues(teamTag).Set(float64(len(teamData.Data.Members)))
 
-		// Insert Team Log
+	// Calculate Stats (if there was a previous record)
+	finishedIDs := []string{}
+	if prevRequestID.Status == pgtype.Present && newLogID != "" {
 		tx, err := conn.Begin(ctx)
 		if err != nil {
-			log.Error("unable to start recording team data", zap.Error(err))
+			log.Error("unable to start team member stats ", zap.Error(err))
+			_, err = updatePreviousComp(ctx, conn, eventIDs, now, "FAILED", &newLogID)
+			if err == nil {
+				updatedPrevComp = true
+			}
 			return
 		}
 		defer tx.Rollback(ctx)
 
-		logID := ""
-		responseType := "NEW"
-		description := "New log download"
-		q = `SELECT id FROM nt_api_team_logs WHERE hash = $1`
-		err = tx.QueryRow(ctx, q, hash).Scan(&logID)
-		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-			log.Error("unable to find existing team log", zap.Error(err))
-			return
-		}
-		if logID == "" {
-			q := `
-				INSERT INTO nt_api_team_logs (hash, log_data)
-				VALUES ($1, $2)
-				ON CONFLICT (hash) DO NOTHING
-				RETURNING id`
-			err = tx.QueryRow(ctx, q, hash, data).Scan(&logID)
-			if err != nil {
-				log.Error("unable to insert team log", zap.Error(err))
-				return
+		// Record or Update members
+		q = `
+			INSERT INTO users (reference_id, username, display_name, membership_type, status)
+
+			SELECT (m->>'userID')::int AS reference_id,
+				m->>'username' AS username,
+				(
+					CASE 
+						WHEN m->>'displayName' IS NOT NULL AND m->>'displayName' != '' THEN m->>'displayName'
+						ELSE m->>'username'
+					END
+				) AS display_name,
+				(
+					CASE m->>'membership'
+						WHEN 'gold' THEN 'GOLD'
+						ELSE 'BASIC'
+					END
+				) AS membership_type,
+				'NEW' AS status
+			FROM nt_api_team_log_requests r
+				INNER JOIN nt_api_team_logs l ON l.id = r.api_team_log_id AND json_typeof(l.log_data->'data'->'members') = 'array'
+				INNER JOIN json_array_elements(l.log_data->'data'->'members') AS m ON m->>'userID' IS NOT NULL
+					AND (m->>'userID')::INT NOT IN (31927399)
+			WHERE r.id = $1
+			
+			ON CONFLICT (reference_id) DO UPDATE
+			SET username = EXCLUDED.username,
+				display_name = EXCLUDED.display_name,
+				membership_type = EXCLUDED.membership_type`
+		_, err = tx.Exec(ctx, q, newLogID)
+		if err != nil {
+			log.Error("unable to update team member details", zap.Error(err))
+			_, err = updatePreviousComp(ctx, conn, eventIDs, now, "FAILED", &newLogID)
+			if err == nil {
+				updatedPrevComp = true
 			}
-		}
-		if logID == "" {
-			log.Error("unable to find team log id (blank data)")
 			return
 		}
 
-		if prevLogID.Status == pgtype.Present {
-			prevLogIDText := ""
-			prevLogID.AssignTo(&prevLogIDText)
-			if prevLogIDText == logID {
-				responseType = "CACHE"
-				description = "Same log found"
-			}
-		}
-		if prevRequestID.Status != pgtype.Present {
-			prevRequestID.Set(nil)
-		}
-
-		// Insert Team Log Request
-		var newLogID string
+		// Remember the usernames (so renamed members can still be searched for)
 		q = `
-			INSERT INTO nt_api_team_log_requests (prev_id, api_team_log_id, response_type, description)
-			VALUES ($1, $2, $3, $4)
-			RETURNING id`
-		err = tx.QueryRow(ctx, q, prevRequestID, logID, responseType, description).Scan(&newLogID)
+			INSERT INTO user_usernames (user_id, username)
+			SELECT u.id, u.username
+			FROM nt_api_team_log_requests r
+				INNER JOIN nt_api_team_logs l ON l.id = r.api_team_log_id AND json_typeof(l.log_data->'data'->'members') = 'array'
+				INNER JOIN json_array_elements(l.log_data->'data'->'members') AS m ON m->>'userID' IS NOT NULL
+				INNER JOIN users u ON u.reference_id = (m->>'userID')::int
+			WHERE r.id = $1
+			ON CONFLICT (user_id, username) DO NOTHING`
+		_, err = tx.Exec(ctx, q, newLogID)
 		if err != nil {
-			log.Error("unable to insert team log request", zap.Error(err))
+			log.Error("unable to record team member usernames", zap.Error(err))
+			_, err = updatePreviousComp(ctx, conn, eventIDs, now, "FAILED", &newLogID)
+			if err == nil {
+				updatedPrevComp = true
+			}
 			return
 		}
 
-		// Commit Transaction
-		err = tx.Commit(ctx)
+		// Insert in the records
+		recordCount, err := records.Insert(ctx, tx, newLogID)
 		if err != nil {
-			log.Error("unable to finish recording team data", zap.Error(err))
+			log.Error("unable to insert team member records", zap.Error(err))
+			_, err = updatePreviousComp(ctx, conn, eventIDs, now, "FAILED", &newLogID)
+			if err == nil {
+				updatedPrevComp = true
+			}
 			return
 		}
 
-		// Calculate Stats (if there was a previous record)
-		if prevRequestID.Status == pgtype.Present && newLogID != "" {
-			tx, err := conn.Begin(ctx)
-			if err != nil {
-				log.Error("unable to start team member stats ", zap.Error(err))
-				err = updatePreviousComp(ctx, conn, now, "FAILED", &newLogID)
-				if err == nil {
-					updatedPrevComp = true
-				}
-				return
-			}
-			defer tx.Rollback(ctx)
-
-			// Record or Update members
-			q = `
-				INSERT INTO users (reference_id, username, display_name, membership_type, status)
-
-				SELECT (m->>'userID')::int AS reference_id,
-					m->>'username' AS username,
-					(
-						CASE 
-							WHEN m->>'displayName' IS NOT NULL AND m->>'displayName' != '' THEN m->>'displayName'
-							ELSE m->>'username'
-						END
-					) AS display_name,
-					(
-						CASE m->>'membership'
-							WHEN 'gold' THEN 'GOLD'
-							ELSE 'BASIC'
-						END
-					) AS membership_type,
-					'NEW' AS status
-				FROM nt_api_team_log_requests r
-					INNER JOIN nt_api_team_logs l ON l.id = r.api_team_log_id AND json_typeof(l.log_data->'data'->'members') = 'array'
-					INNER JOIN json_array_elements(l.log_data->'data'->'members') AS m ON m->>'userID' IS NOT NULL
-						AND (m->>'userID')::INT NOT IN (31927399)
-				WHERE r.id = $1
-				
-				ON CONFLICT (reference_id) DO UPDATE
-				SET username = EXCLUDED.username,
-					display_name = EXCLUDED.display_name,
-					membership_type = EXCLUDED.membership_type`
-			_, err = tx.Exec(ctx, q, newLogID)
-			if err != nil {
-				log.Error("unable to update team member details", zap.Error(err))
-				err = updatePreviousComp(ctx, conn, now, "FAILED", &newLogID)
-				if err == nil {
-					updatedPrevComp = true
-				}
-				return
+		// Update user participation status
+		q = `
+			UPDATE users u
+			SET status = 'ACTIVE', updated_at = NOW()
+			WHERE status = 'NEW'
+				AND EXISTS (
+					SELECT 1
+					FROM user_records _r 
+					WHERE _r.request_id = $1 
+						AND _r.user_id = u.id
+					LIMIT 1
+				)`
+		_, err = tx.Exec(ctx, q, newLogID)
+		if err != nil {
+			log.Error("unable to update team member update status", zap.Error(err))
+			_, err = updatePreviousComp(ctx, conn, eventIDs, now, "FAILED", &newLogID)
+			if err == nil {
+				updatedPrevComp = true
 			}
+			return
+		}
 
-			// Insert in the records
-			q = `
-				INSERT INTO user_records (request_id, user_id, played, typed, errs, secs, from_at, to_at)
-				SELECT $1 AS request_id,
-					(
-						SELECT _u.id
-						FROM users _u
-						WHERE _u.reference_id = (m1->>'userID')::int
-						LIMIT 1
-					) AS user_id,
-					((m1->>'played')::int - (m2->>'played')::int) AS played,
-					((m1->>'typed')::int - (m2->>'typed')::int) AS typed,
-					((m1->>'errs')::int - (m2->>'errs')::int) AS errs,
-					((m1->>'secs')::int - (m2->>'secs')::int) AS secs,
-					r2.created_at AS from_at,
-					r1.created_at AS to_at
+		// Update users disqualified status
+		q = `
+			UPDATE users u
+			SET status = 'DISQUALIFIED',
+				updated_at = NOW()
+			FROM (
+				SELECT (m2->>'userID')::int AS reference_id
 				FROM nt_api_team_log_requests r1				
 					INNER JOIN nt_api_team_log_requests r2 ON r2.id = r1.prev_id
 						AND r2.api_team_log_id != r1.api_team_log_id
 					INNER JOIN nt_api_team_logs l1 ON l1.id = r1.api_team_log_id AND json_typeof(l1.log_data->'data'->'members') = 'array'
 					INNER JOIN nt_api_team_logs l2 ON l2.id = r2.api_team_log_id AND json_typeof(l2.log_data->'data'->'members') = 'array'
-					INNER JOIN json_array_elements(l1.log_data->'data'->'members') AS m1 ON m1->>'userID' IS NOT NULL AND (m1->>'userID')::int NOT IN (31927399)
-					INNER JOIN json_array_elements(l2.log_data->'data'->'members') AS m2 ON (m1->>'userID')::int = (m2->>'userID')::int
+					LEFT JOIN json_array_elements(l2.log_data->'data'->'members') AS m2 ON m2->>'userID' IS NOT NULL
+					LEFT JOIN json_array_elements(l1.log_data->'data'->'members') AS m1 ON (m1->>'userID')::int = (m2->>'userID')::int
 				WHERE r1.id = $1
 					AND r1.prev_id IS NOT NULL
-					AND ((m1->>'played')::int - (m2->>'played')::int) > 0`
-			_, err = tx.Exec(ctx, q, newLogID)
-			if err != nil {
-				log.Error("unable to insert team member records", zap.Error(err))
-				err = updatePreviousComp(ctx, conn, now, "FAILED", &newLogID)
-				if err == nil {
-					updatedPrevComp = true
-				}
-				return
-			}
-
-			// Update user participation status
-			q = `
-				UPDATE users u
-				SET status = 'ACTIVE', updated_at = NOW()
-				WHERE status = 'NEW'
-					AND EXISTS (
-						SELECT 1
-						FROM user_records _r 
-						WHERE _r.request_id = $1 
-							AND _r.user_id = u.id
-						LIMIT 1
-					)`
-			_, err = tx.Exec(ctx, q, newLogID)
-			if err != nil {
-				log.Error("unable to update team member update status", zap.Error(err))
-				err = updatePreviousComp(ctx, conn, now, "FAILED", &newLogID)
-				if err == nil {
-					updatedPrevComp = true
-				}
-				return
-			}
+					AND (
+						m1->>'userID' IS NULL
+						OR m1->>'status' = 'banned'
+					)
+			) l
+			WHERE u.status != 'DISQUALIFIED'
+				AND u.reference_id = l.reference_id`
 
-			// Update users disqualified status
-			q = `
-				UPDATE users u
-				SET status = 'DISQUALIFIED',
-					updated_at = NOW()
-				FROM (
-					SELECT (m2->>'userID')::int AS reference_id
-					FROM nt_api_team_log_requests r1				
-						INNER JOIN nt_api_team_log_requests r2 ON r2.id = r1.prev_id
-							AND r2.api_team_log_id != r1.api_team_log_id
-						INNER JOIN nt_api_team_logs l1 ON l1.id = r1.api_team_log_id AND json_typeof(l1.log_data->'data'->'members') = 'array'
-						INNER JOIN nt_api_team_logs l2 ON l2.id = r2.api_team_log_id AND json_typeof(l2.log_data->'data'->'members') = 'array'
-						LEFT JOIN json_array_elements(l2.log_data->'data'->'members') AS m2 ON m2->>'userID' IS NOT NULL
-						LEFT JOIN json_array_elements(l1.log_data->'data'->'members') AS m1 ON (m1->>'userID')::int = (m2->>'userID')::int
-					WHERE r1.id = $1
-						AND r1.prev_id IS NOT NULL
-						AND (
-							m1->>'userID' IS NULL
-							OR m1->>'status' = 'banned'
-						)
-				) l
-				WHERE u.status != 'DISQUALIFIED'
-					AND u.reference_id = l.reference_id`
-
-			// Commit Transaction
-			err = tx.Commit(ctx)
-			if err != nil {
-				log.Error("unable to finish team member stats ", zap.Error(err))
-				err = updatePreviousComp(ctx, conn, now, "FAILED", &newLogID)
-				if err == nil {
-					updatedPrevComp = true
-				}
-				return
-			}
-
-			// Update comp results
-			err = updatePreviousComp(ctx, conn, now, "FINISHED", &newLogID)
-			if err != nil {
-				log.Error("unable to update comp results", zap.Error(err))
-				err = updatePreviousComp(ctx, conn, now, "FAILED", &newLogID)
-				if err == nil {
-					updatedPrevComp = true
-				}
-				return
-			}
-			updatedPrevComp = true
-		}
-
-		// Start next comp
-		err = startNextComp(ctx, conn, now)
+		// Commit Transaction
+		err = tx.Commit(ctx)
 		if err != nil {
-			log.Error("unable to update comp status", zap.Error(err))
+			log.Error("unable to finish team member stats ", zap.Error(err))
+			_, err = updatePreviousComp(ctx, conn, eventIDs, now, "FAILED", &newLogID)
+			if err == nil {
+				updatedPrevComp = true
+			}
 			return
 		}
-		updateComp = false
-		updatedNextComp = true
+		recordsInserted.WithLabelValues(teamTag).Add(float64(recordCount))
 
-		// Refresh result table
-		q = `REFRESH MATERIALIZED VIEW competition_results`
-		_, err = conn.Exec(ctx, q)
+		// Update comp results
+		finishedIDs, err = updatePreviousComp(ctx, conn, eventIDs, now, "FINISHED", &newLogID)
 		if err != nil {
-			log.Error("unable to update comp status", zap.Error(err))
+			log.Error("unable to update comp results", zap.Error(err))
+			_, err = updatePreviousComp(ctx, conn, eventIDs, now, "FAILED", &newLogID)
+			if err == nil {
+				updatedPrevComp = true
+			}
 			return
 		}
+		updatedPrevComp = true
+	}
 
-		log.Info("sync teams completed")
+	// Start next comp
+	err = startNextComp(ctx, conn, eventIDs, now)
+	if err != nil {
+		log.Error("unable to update comp status", zap.Error(err))
+		return
 	}
+	updateComp = false
+	updatedNextComp = true
+
+	// Compute the results of the finished comps
+	err = results.Recompute(ctx, conn, finishedIDs...)
+	if err != nil {
+		log.Error("unable to compute comp results", zap.Error(err))
+		notifySync(ctx, conn, log, team, now, "FAILED")
+		return
+	}
+
+	notifySync(ctx, conn, log, team, now, "SUCCESS")
+	lastSyncs.set(teamTag, time.Now())
+	log.Info("sync teams completed")
 }
 
-func startNextComp(ctx context.Context, conn *pgxpool.Pool, timeAt time.Time) error {
+// notifySync lets the API subscribers know how a team sync went.
+func notifySync(ctx context.Context, conn *pgxpool.Pool, log *zap.Logger, team eventTeam, now time.Time, status string) {
+	err := notify.Sync(ctx, conn, notify.SyncStatus{
+		TeamTag:  team.teamTag,
+		EventIDs: team.eventIDs,
+		Status:   status,
+		SyncedAt: now,
+	})
+	if err != nil {
+		log.Error("unable to notify sync status", zap.Error(err))
+	}
+}
+
+func startNextComp(ctx context.Context, conn *pgxpool.Pool, eventIDs []string, timeAt time.Time) error {
 	q := `
 		UPDATE competitions
 		SET status = 'STARTED', updated_at = NOW()
 		WHERE status = 'DRAFT'
-			AND from_at <= $1
-			AND to_at > $1`
-	_, err := conn.Exec(ctx, q, timeAt)
+			AND event_id = ANY($1)
+			AND from_at <= $2
+			AND to_at > $2
+		RETURNING id::text`
+	rows, err := conn.Query(ctx, q, eventIDs, timeAt)
+	if err != nil {
+		return fmt.Errorf("unable to mark comp as started: %w", err)
+	}
+	defer rows.Close()
+	startedIDs := []string{}
+	for rows.Next() {
+		id := ""
+		err := rows.Scan(&id)
+		if err != nil {
+			return fmt.Errorf("unable to scan started comp: %w", err)
+		}
+		startedIDs = append(startedIDs, id)
+	}
+	err = rows.Err()
 	if err != nil {
 		return fmt.Errorf("unable to mark comp as started: %w", err)
 	}
-	return nil
+	return notify.Competitions(ctx, conn, startedIDs...)
 }
 
-func updatePreviousComp(ctx context.Context, conn *pgxpool.Pool, timeAt time.Time, status string, requestID *string) error {
-	prevAt := timeAt.Add(-10 * time.Minute)
+// updatePreviousComp closes the competitions that have finished and returns their ids.
+// Only the competition that finished within the last window gets the request, older ones (missed syncs) are failed.
+// Failed competitions are notified straight away, finished ones are notified once their results are computed.
+func updatePreviousComp(ctx context.Context, conn *pgxpool.Pool, eventIDs []string, timeAt time.Time, status string, requestID *string) ([]string, error) {
 	q := `
-		UPDATE competitions
-		SET status = $2, request_id = $3, updated_at = NOW()
-		WHERE status = 'STARTED'
-			AND from_at <= $1
-			AND to_at > $1`
-	_, err := conn.Exec(ctx, q, prevAt, status, requestID)
+		UPDATE competitions c
+		SET status = (
+				CASE
+					WHEN c.to_at > $2 - make_interval(mins => e.window_minutes) THEN $3
+					ELSE 'FAILED'
+				END
+			),
+			request_id = (
+				CASE
+					WHEN c.to_at > $2 - make_interval(mins => e.window_minutes) THEN $4::uuid
+					ELSE NULL
+				END
+			),
+			updated_at = NOW()
+		FROM events e
+		WHERE e.id = c.event_id
+			AND c.status = 'STARTED'
+			AND c.event_id = ANY($1)
+			AND c.to_at <= $2
+		RETURNING c.id::text`
+	rows, err := conn.Query(ctx, q, eventIDs, timeAt, status, requestID)
 	if err != nil {
-		return fmt.Errorf("unable to update previous comp: %w", err)
+		return nil, fmt.Errorf("unable to update previous comp: %w", err)
+	}
+	defer rows.Close()
+	output := []string{}
+	for rows.Next() {
+		id := ""
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan previous comp: %w", err)
+		}
+		output = append(output, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to update previous comp: %w", err)
+	}
+	if status != "FINISHED" {
+		err = notify.Competitions(ctx, conn, output...)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return nil
+	return output, nil
 }
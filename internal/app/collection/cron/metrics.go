@@ -0,0 +1,185 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	syncDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "folly",
+		Subsystem: "collection",
+		Name:      "sync_duration_seconds",
+		Help:      "Time taken to sync a team, including the team log download.",
+		Buckets:   []float64{1, 2, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"team"})
+
+	teamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "folly",
+		Subsystem: "collection",
+		Name:      "nt_requests_total",
+		Help:      "Number of team log requests made to Nitro Type, by response type (NEW, CACHE or ERROR).",
+	}, []string{"team", "response_type"})
+
+	teamMembers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "folly",
+		Subsystem: "collection",
+		Name:      "team_members",
+		Help:      "Number of members in the latest team log.",
+	}, []string{"team"})
+
+	recordsInserted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "folly",
+		Subsystem: "collection",
+		Name:      "records_inserted_total",
+		Help:      "Number of user records inserted from the team logs.",
+	}, []string{"team"})
+
+	// lastSyncs holds when each team was last synced successfully
+	lastSyncs = &syncTimes{times: map[string]time.Time{}}
+)
+
+// syncTimes keeps track of the last successful sync of each team.
+type syncTimes struct {
+	mu    sync.RWMutex
+	times map[string]time.Time
+}
+
+func (s *syncTimes) set(teamTag string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.After(s.times[teamTag]) {
+		s.times[teamTag] = t
+	}
+}
+
+func (s *syncTimes) all() map[string]time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	output := make(map[string]time.Time, len(s.times))
+	for teamTag, t := range s.times {
+		output[teamTag] = t
+	}
+	return output
+}
+
+// RegisterMetrics adds the collection metrics that are read at scrape time (competitions by status and time since the last sync).
+// The last syncs are loaded from the team log requests, so they survive restarts.
+func RegisterMetrics(ctx context.Context, conn *pgxpool.Pool, log *zap.Logger) error {
+	q := `
+		SELECT team_tag, MAX(created_at)
+		FROM nt_api_team_log_requests
+		WHERE deleted_at IS NULL
+			AND response_type IN ('NEW', 'CACHE')
+		GROUP BY team_tag`
+	rows, err := conn.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("unable to query last syncs: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			teamTag  string
+			syncedAt time.Time
+		)
+		err := rows.Scan(&teamTag, &syncedAt)
+		if err != nil {
+			return fmt.Errorf("unable to scan last syncs: %w", err)
+		}
+		lastSyncs.set(teamTag, syncedAt)
+	}
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("unable to scan last syncs: %w", err)
+	}
+	return prometheus.Register(&statusCollector{
+		conn: conn,
+		log:  log,
+		competitions: prometheus.NewDesc(
+			"folly_collection_competitions",
+			"Number of competitions, by status.",
+			[]string{"status"}, nil,
+		),
+		sinceLastSync: prometheus.NewDesc(
+			"folly_collection_seconds_since_last_sync",
+			"Seconds since the team was last synced successfully.",
+			[]string{"team"}, nil,
+		),
+	})
+}
+
+// statusCollector reports the competition statuses and the time since the last syncs.
+type statusCollector struct {
+	conn *pgxpool.Pool
+	log  *zap.Logger
+
+	competitions  *prometheus.Desc
+	sinceLastSync *prometheus.Desc
+}
+
+func (c *statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.competitions
+	ch <- c.sinceLastSync
+}
+
+func (c *statusCollector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	for teamTag, syncedAt := range lastSyncs.all() {
+		ch <- prometheus.MustNewConstMetric(c.sinceLastSync, prometheus.GaugeValue, now.Sub(syncedAt).Seconds(), teamTag)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	counts, err := countCompetitions(ctx, c.conn)
+	if err != nil {
+		c.log.Error("unable to collect competition metrics", zap.Error(err))
+		ch <- prometheus.NewInvalidMetric(c.competitions, err)
+		return
+	}
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.competitions, prometheus.GaugeValue, float64(count), status)
+	}
+}
+
+// countCompetitions counts the competitions of each status (every status is included, even without competitions).
+func countCompetitions(ctx context.Context, conn *pgxpool.Pool) (map[string]int, error) {
+	counts := map[string]int{
+		"DRAFT":    0,
+		"STARTED":  0,
+		"FINISHED": 0,
+		"FAILED":   0,
+	}
+	q := `
+		SELECT status, COUNT(*)
+		FROM competitions
+		WHERE deleted_at IS NULL
+		GROUP BY status`
+	rows, err := conn.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query competition statuses: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			status string
+			count  int
+		)
+		err := rows.Scan(&status, &count)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan competition statuses: %w", err)
+		}
+		counts[status] = count
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan competition statuses: %w", err)
+	}
+	return counts, nil
+}
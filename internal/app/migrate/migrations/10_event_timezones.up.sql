@@ -0,0 +1,3 @@
+-- timezone is the IANA timezone the event schedule (daily windows, happy hours and report days) is worked out in.
+ALTER TABLE events
+	ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,7 @@
+DROP VIEW leaderboard_points;
+DROP VIEW leaderboard_results;
+DROP TABLE competition_provisional_results;
+
+ALTER TABLE competitions
+	DROP COLUMN provisional_at,
+	DROP COLUMN provisional_log_id;
@@ -0,0 +1,46 @@
+/*************************
+*  Provisional Results  *
+*************************/
+
+-- provisional_at is when the provisional results of a started competition were last refreshed (used to rate limit it).
+ALTER TABLE competitions
+	ADD COLUMN provisional_log_id UUID REFERENCES nt_api_team_logs (id),
+	ADD COLUMN provisional_at TIMESTAMPTZ;
+
+-- competition_provisional_results are the results a started competition would have if it finished with the latest team log.
+-- They're replaced on every refresh and removed once the competition results are computed.
+CREATE TABLE competition_provisional_results (
+	competition_id UUID NOT NULL REFERENCES competitions (id),
+	user_id UUID NOT NULL REFERENCES users (id),
+	category_id UUID NOT NULL REFERENCES categories (id),
+	score DECIMAL NOT NULL,
+	eligible BOOLEAN NOT NULL,
+	ineligible_reason TEXT,
+	rank INT,
+	tied INT NOT NULL,
+	reward INT NOT NULL,
+
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (competition_id, user_id, category_id)
+);
+
+-- leaderboard_results contains the final results of the finished competitions and the provisional results of the started ones.
+CREATE VIEW leaderboard_results AS
+SELECT r.competition_id, r.user_id, r.category_id, r.score, r.eligible, r.ineligible_reason, r.rank, r.tied, r.reward
+FROM competition_results r
+UNION ALL
+SELECT r.competition_id, r.user_id, r.category_id, r.score, r.eligible, r.ineligible_reason, r.rank, r.tied, r.reward
+FROM competition_provisional_results r;
+
+-- leaderboard_points contains every source of points of the leaderboards (including the provisional rewards).
+CREATE VIEW leaderboard_points AS
+SELECT p.user_id,
+	p.competition_id,
+	p.points
+FROM user_points p
+UNION ALL
+SELECT r.user_id,
+	r.competition_id,
+	r.reward AS points
+FROM competition_provisional_results r;
@@ -0,0 +1,43 @@
+-- competition_category_scores is restored before the exclusions it depends on are dropped.
+CREATE OR REPLACE VIEW competition_category_scores AS
+SELECT s.competition_id,
+	s.user_id,
+	cc.category_id,
+	cc.rewards,
+	s.multiplier,
+	c.tie_policy,
+	competition_user_metric(s, cat.metric) AS score,
+	(
+		CASE cat.sort_direction
+			WHEN 'ASC' THEN competition_user_metric(s, cat.metric)
+			ELSE -competition_user_metric(s, cat.metric)
+		END
+	) AS sort_key,
+	(
+		CASE cat.tie_breaker_direction
+			WHEN 'ASC' THEN competition_user_metric(s, cat.tie_breaker)
+			ELSE -competition_user_metric(s, cat.tie_breaker)
+		END
+	) AS tie_breaker_key,
+	(
+		CASE
+			WHEN c.tie_policy != 'BREAK' THEN NULL
+			WHEN c.tie_breaker_direction = 'ASC' THEN competition_user_metric(s, c.tie_breaker)
+			ELSE -competition_user_metric(s, c.tie_breaker)
+		END
+	) AS secondary_key,
+	(
+		CASE
+			WHEN s.played < GREATEST(c.min_races, cat.min_races) THEN format('Needs at least %s races', GREATEST(c.min_races, cat.min_races))
+			WHEN s.secs < GREATEST(c.min_secs, cat.min_secs) THEN format('Needs at least %s seconds of racing', GREATEST(c.min_secs, cat.min_secs))
+			WHEN s.typed < GREATEST(c.min_typed, cat.min_typed) THEN format('Needs at least %s characters typed', GREATEST(c.min_typed, cat.min_typed))
+		END
+	) AS ineligible_reason
+FROM competition_user_stats s
+	INNER JOIN competitions c ON c.id = s.competition_id
+	INNER JOIN competition_categories cc ON cc.competition_id = s.competition_id
+	INNER JOIN categories cat ON cat.id = cc.category_id AND cat.deleted_at IS NULL
+	INNER JOIN users u ON u.id = s.user_id AND u.status != 'DISQUALIFIED';
+
+DROP TABLE exclusions;
+DROP TABLE api_keys;
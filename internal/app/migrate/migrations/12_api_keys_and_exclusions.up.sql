@@ -0,0 +1,85 @@
+/*************
+*  API Keys  *
+*************/
+
+-- api_keys give access to the admin mutations. Only the hash of the key is stored, the key itself is shown once when created.
+CREATE TABLE api_keys (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+	name TEXT NOT NULL,
+	key_hash BYTEA NOT NULL UNIQUE,
+	last_used_at TIMESTAMPTZ,
+
+	revoked_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+/***************
+*  Exclusions  *
+***************/
+
+-- exclusions keep racers out of the results of an event (or every event when there's no event).
+-- Unlike disqualifications, they are decided by the captains rather than detected from the team logs.
+CREATE TABLE exclusions (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+	user_id UUID NOT NULL REFERENCES users (id),
+	event_id UUID REFERENCES events (id),
+	reason TEXT NOT NULL,
+	author TEXT NOT NULL,
+
+	deleted_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX exclusions_user_id_idx ON exclusions (
+	user_id
+);
+
+-- competition_category_scores leaves out the excluded racers.
+CREATE OR REPLACE VIEW competition_category_scores AS
+SELECT s.competition_id,
+	s.user_id,
+	cc.category_id,
+	cc.rewards,
+	s.multiplier,
+	c.tie_policy,
+	competition_user_metric(s, cat.metric) AS score,
+	(
+		CASE cat.sort_direction
+			WHEN 'ASC' THEN competition_user_metric(s, cat.metric)
+			ELSE -competition_user_metric(s, cat.metric)
+		END
+	) AS sort_key,
+	(
+		CASE cat.tie_breaker_direction
+			WHEN 'ASC' THEN competition_user_metric(s, cat.tie_breaker)
+			ELSE -competition_user_metric(s, cat.tie_breaker)
+		END
+	) AS tie_breaker_key,
+	(
+		CASE
+			WHEN c.tie_policy != 'BREAK' THEN NULL
+			WHEN c.tie_breaker_direction = 'ASC' THEN competition_user_metric(s, c.tie_breaker)
+			ELSE -competition_user_metric(s, c.tie_breaker)
+		END
+	) AS secondary_key,
+	(
+		CASE
+			WHEN s.played < GREATEST(c.min_races, cat.min_races) THEN format('Needs at least %s races', GREATEST(c.min_races, cat.min_races))
+			WHEN s.secs < GREATEST(c.min_secs, cat.min_secs) THEN format('Needs at least %s seconds of racing', GREATEST(c.min_secs, cat.min_secs))
+			WHEN s.typed < GREATEST(c.min_typed, cat.min_typed) THEN format('Needs at least %s characters typed', GREATEST(c.min_typed, cat.min_typed))
+		END
+	) AS ineligible_reason
+FROM competition_user_stats s
+	INNER JOIN competitions c ON c.id = s.competition_id
+	INNER JOIN competition_categories cc ON cc.competition_id = s.competition_id
+	INNER JOIN categories cat ON cat.id = cc.category_id AND cat.deleted_at IS NULL
+	INNER JOIN users u ON u.id = s.user_id AND u.status != 'DISQUALIFIED'
+WHERE NOT EXISTS (
+		SELECT 1
+		FROM exclusions x
+		WHERE x.user_id = s.user_id
+			AND x.deleted_at IS NULL
+			AND (x.event_id IS NULL OR x.event_id = c.event_id)
+	);
@@ -0,0 +1,13 @@
+/******************
+*  Sync Version  *
+******************/
+
+-- sync_versions holds the version bumped whenever a team is synced or competition results change, the API derives its
+-- ETags from it. It's a single row bumped in the same transaction as the changes, so a new version is never seen before
+-- the changes it stands for are committed.
+CREATE TABLE sync_versions (
+	id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+	version BIGINT NOT NULL
+);
+
+INSERT INTO sync_versions (version) VALUES (1);
@@ -0,0 +1,4 @@
+DROP INDEX users_display_name_trgm_idx;
+DROP INDEX users_username_trgm_idx;
+DROP TABLE user_usernames;
+DROP EXTENSION IF EXISTS pg_trgm;
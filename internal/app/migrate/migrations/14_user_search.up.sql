@@ -0,0 +1,50 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+/********************
+*  User Usernames  *
+********************/
+
+-- user_usernames keeps every username a racer has been seen with, so they can still be found after renaming.
+CREATE TABLE user_usernames (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+	user_id UUID NOT NULL REFERENCES users (id),
+	username TEXT NOT NULL,
+
+	deleted_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	UNIQUE (user_id, username)
+);
+
+-- The usernames seen so far are taken from the team logs (first seen when the log was recorded).
+INSERT INTO user_usernames (user_id, username, created_at)
+SELECT u.id, m->>'username', MIN(l.created_at)
+FROM nt_api_team_logs l
+	INNER JOIN json_array_elements(l.log_data->'data'->'members') AS m ON m->>'userID' IS NOT NULL
+		AND m->>'username' IS NOT NULL
+	INNER JOIN users u ON u.reference_id = (m->>'userID')::int
+WHERE json_typeof(l.log_data->'data'->'members') = 'array'
+GROUP BY u.id, m->>'username'
+ON CONFLICT (user_id, username) DO NOTHING;
+
+INSERT INTO user_usernames (user_id, username, created_at)
+SELECT u.id, u.username, u.created_at
+FROM users u
+ON CONFLICT (user_id, username) DO NOTHING;
+
+/********************
+*  Search Indexes  *
+********************/
+
+CREATE INDEX users_username_trgm_idx ON users USING GIN (
+	username gin_trgm_ops
+);
+
+CREATE INDEX users_display_name_trgm_idx ON users USING GIN (
+	display_name gin_trgm_ops
+);
+
+CREATE INDEX user_usernames_username_trgm_idx ON user_usernames USING GIN (
+	username gin_trgm_ops
+);
@@ -0,0 +1,93 @@
+DROP MATERIALIZED VIEW competition_results;
+DROP VIEW competition_category_scores;
+DROP VIEW competition_user_stats;
+
+ALTER TABLE competitions
+	ADD COLUMN grind_rewards INT[5] NOT NULL DEFAULT '{10, 7, 5, 3, 1}',
+	ADD COLUMN point_rewards INT[5] NOT NULL DEFAULT '{10, 7, 5, 3, 1}',
+	ADD COLUMN speed_rewards INT[5] NOT NULL DEFAULT '{10, 7, 5, 3, 1}',
+	ADD COLUMN accuracy_rewards INT[5] NOT NULL DEFAULT '{10, 7, 5, 3, 1}';
+
+UPDATE competitions c
+SET grind_rewards = coalesce((
+		SELECT cc.rewards
+		FROM competition_categories cc
+			INNER JOIN categories cat ON cat.id = cc.category_id
+		WHERE cc.competition_id = c.id AND cat.name = 'GRIND'
+	), c.grind_rewards),
+	point_rewards = coalesce((
+		SELECT cc.rewards
+		FROM competition_categories cc
+			INNER JOIN categories cat ON cat.id = cc.category_id
+		WHERE cc.competition_id = c.id AND cat.name = 'POINT'
+	), c.point_rewards),
+	speed_rewards = coalesce((
+		SELECT cc.rewards
+		FROM competition_categories cc
+			INNER JOIN categories cat ON cat.id = cc.category_id
+		WHERE cc.competition_id = c.id AND cat.name = 'SPEED'
+	), c.speed_rewards),
+	accuracy_rewards = coalesce((
+		SELECT cc.rewards
+		FROM competition_categories cc
+			INNER JOIN categories cat ON cat.id = cc.category_id
+		WHERE cc.competition_id = c.id AND cat.name = 'ACCURACY'
+	), c.accuracy_rewards);
+
+ALTER TABLE competitions
+	ALTER COLUMN grind_rewards DROP DEFAULT,
+	ALTER COLUMN point_rewards DROP DEFAULT,
+	ALTER COLUMN speed_rewards DROP DEFAULT,
+	ALTER COLUMN accuracy_rewards DROP DEFAULT;
+
+DROP TABLE
+	competition_categories,
+	categories
+;
+
+CREATE MATERIALIZED VIEW competition_results AS
+SELECT r.competition_id,
+	r.user_id,
+	r.grind,
+	rank() OVER g grind_rank,
+	coalesce(r.grind_rewards[rank() OVER g] * r.multiplier, 0) AS grind_reward,
+	r.accuracy,
+	rank() OVER a AS accuracy_rank,
+	coalesce(r.accuracy_rewards[rank() OVER a] * r.multiplier, 0) AS accuracy_reward,
+	r.speed,
+	rank() OVER s AS speed_rank,
+	coalesce(r.speed_rewards[rank() OVER s] * r.multiplier, 0) AS speed_reward,
+	r.point,
+	rank() OVER p AS point_rank,
+	coalesce(r.point_rewards[rank() OVER p] * r.multiplier, 0) AS point_reward
+FROM (
+	SELECT ur.user_id,
+		c.id AS competition_id,
+		c.multiplier,
+		c.grind_rewards,
+		c.accuracy_rewards,
+		c.speed_rewards,
+		c.point_rewards,
+		ur.played AS grind,
+		((1.0 - (ur.errs / ur.typed::decimal)) * 100.0) AS accuracy,
+		(ur.typed / 5.0 / (ur.secs / 60.0)) AS speed,
+		ROUND(ur.played
+			* (
+				(100.0 + ((ur.typed / 5.0 / (ur.secs / 60.0)) / 2.0))
+					* (1.0 - (ur.errs / ur.typed::decimal))
+			)
+		) AS point
+	FROM competitions c 
+		INNER JOIN user_records ur ON ur.request_id = c.request_id 
+		INNER JOIN users u ON u.id = ur.user_id AND u.status != 'DISQUALIFIED'
+) r
+WINDOW g as (PARTITION BY r.competition_id ORDER BY r.grind DESC),
+	a AS (PARTITION BY r.competition_id ORDER BY r.accuracy DESC, r.grind DESC),
+	s AS (PARTITION BY r.competition_id ORDER BY r.speed DESC, r.grind DESC),
+	p AS (PARTITION BY r.competition_id ORDER BY r.point DESC, r.grind DESC);
+
+CREATE UNIQUE INDEX ON competition_results (competition_id, user_id);
+
+CREATE INDEX competitions_competition_id_idx ON competition_results (
+	competition_id
+);
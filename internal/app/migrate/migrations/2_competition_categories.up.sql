@@ -0,0 +1,158 @@
+/***************************
+*  Competition Categories  *
+***************************/
+
+CREATE TABLE categories (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+	name TEXT NOT NULL UNIQUE,
+	metric TEXT NOT NULL CHECK (metric IN ('PLAYED', 'TYPED', 'ERRS', 'SECS', 'ACCURACY', 'SPEED', 'POINTS', 'ERRORS_PER_RACE', 'SPEED_IMPROVEMENT')),
+	sort_direction TEXT NOT NULL CHECK (sort_direction IN ('ASC', 'DESC')) DEFAULT 'DESC',
+	tie_breaker TEXT CHECK (tie_breaker IN ('PLAYED', 'TYPED', 'ERRS', 'SECS', 'ACCURACY', 'SPEED', 'POINTS', 'ERRORS_PER_RACE', 'SPEED_IMPROVEMENT')),
+	tie_breaker_direction TEXT NOT NULL CHECK (tie_breaker_direction IN ('ASC', 'DESC')) DEFAULT 'DESC',
+	min_races INT NOT NULL CHECK (min_races >= 1) DEFAULT 1,
+	rewards INT[5] NOT NULL,
+	position INT NOT NULL DEFAULT 0,
+
+	deleted_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE competition_categories (
+	competition_id UUID NOT NULL REFERENCES competitions (id),
+	category_id UUID NOT NULL REFERENCES categories (id),
+	rewards INT[5] NOT NULL,
+
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (competition_id, category_id)
+);
+
+INSERT INTO categories (name, metric, sort_direction, tie_breaker, tie_breaker_direction, rewards, position)
+VALUES ('GRIND', 'PLAYED', 'DESC', NULL, 'DESC', '{10, 7, 5, 3, 1}', 1),
+	('POINT', 'POINTS', 'DESC', 'PLAYED', 'DESC', '{10, 7, 5, 3, 1}', 2),
+	('SPEED', 'SPEED', 'DESC', 'PLAYED', 'DESC', '{10, 7, 5, 3, 1}', 3),
+	('ACCURACY', 'ACCURACY', 'DESC', 'PLAYED', 'DESC', '{10, 7, 5, 3, 1}', 4);
+
+INSERT INTO competition_categories (competition_id, category_id, rewards)
+SELECT c.id,
+	cat.id,
+	(
+		CASE cat.name
+			WHEN 'GRIND' THEN c.grind_rewards
+			WHEN 'POINT' THEN c.point_rewards
+			WHEN 'SPEED' THEN c.speed_rewards
+			ELSE c.accuracy_rewards
+		END
+	)
+FROM competitions c
+	CROSS JOIN categories cat;
+
+DROP MATERIALIZED VIEW competition_results;
+
+ALTER TABLE competitions
+	DROP COLUMN grind_rewards,
+	DROP COLUMN point_rewards,
+	DROP COLUMN speed_rewards,
+	DROP COLUMN accuracy_rewards;
+
+/************************
+*  Competition Results  *
+************************/
+
+-- competition_user_stats contains every metric a category can rank on.
+CREATE VIEW competition_user_stats AS
+SELECT c.id AS competition_id,
+	c.multiplier,
+	ur.user_id,
+	ur.played,
+	ur.typed,
+	ur.errs,
+	ur.secs,
+	ur.accuracy,
+	ur.speed,
+	ROUND(ur.played * ((100.0 + (ur.speed / 2.0)) * (ur.accuracy / 100.0))) AS points,
+	(ur.errs / ur.played::decimal) AS errors_per_race,
+	coalesce(ur.speed - (ur.prev_typed / 5.0 / (NULLIF(ur.prev_secs, 0) / 60.0)), 0) AS speed_improvement
+FROM competitions c
+	INNER JOIN (
+		SELECT _ur.request_id,
+			_ur.user_id,
+			_ur.played,
+			_ur.typed,
+			_ur.errs,
+			_ur.secs,
+			((1.0 - (_ur.errs / _ur.typed::decimal)) * 100.0) AS accuracy,
+			(_ur.typed / 5.0 / (_ur.secs / 60.0)) AS speed,
+			SUM(_ur.typed) OVER prev AS prev_typed,
+			SUM(_ur.secs) OVER prev AS prev_secs
+		FROM user_records _ur
+		WHERE _ur.deleted_at IS NULL
+		WINDOW prev AS (PARTITION BY _ur.user_id ORDER BY _ur.to_at ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING)
+	) ur ON ur.request_id = c.request_id;
+
+-- competition_category_scores picks out each category's metric and tie breaker.
+CREATE VIEW competition_category_scores AS
+SELECT s.competition_id,
+	s.user_id,
+	cc.category_id,
+	cc.rewards,
+	s.multiplier,
+	cat.sort_direction,
+	cat.tie_breaker_direction,
+	(
+		CASE cat.metric
+			WHEN 'PLAYED' THEN s.played
+			WHEN 'TYPED' THEN s.typed
+			WHEN 'ERRS' THEN s.errs
+			WHEN 'SECS' THEN s.secs
+			WHEN 'ACCURACY' THEN s.accuracy
+			WHEN 'SPEED' THEN s.speed
+			WHEN 'POINTS' THEN s.points
+			WHEN 'ERRORS_PER_RACE' THEN s.errors_per_race
+			WHEN 'SPEED_IMPROVEMENT' THEN s.speed_improvement
+		END
+	)::decimal AS score,
+	(
+		CASE cat.tie_breaker
+			WHEN 'PLAYED' THEN s.played
+			WHEN 'TYPED' THEN s.typed
+			WHEN 'ERRS' THEN s.errs
+			WHEN 'SECS' THEN s.secs
+			WHEN 'ACCURACY' THEN s.accuracy
+			WHEN 'SPEED' THEN s.speed
+			WHEN 'POINTS' THEN s.points
+			WHEN 'ERRORS_PER_RACE' THEN s.errors_per_race
+			WHEN 'SPEED_IMPROVEMENT' THEN s.speed_improvement
+		END
+	)::decimal AS tie_breaker_score
+FROM competition_user_stats s
+	INNER JOIN competition_categories cc ON cc.competition_id = s.competition_id
+	INNER JOIN categories cat ON cat.id = cc.category_id AND cat.deleted_at IS NULL
+	INNER JOIN users u ON u.id = s.user_id AND u.status != 'DISQUALIFIED'
+WHERE s.played >= cat.min_races;
+
+CREATE MATERIALIZED VIEW competition_results AS
+SELECT r.competition_id,
+	r.user_id,
+	r.category_id,
+	r.score,
+	rank() OVER w AS rank,
+	coalesce(r.rewards[rank() OVER w] * r.multiplier, 0) AS reward
+FROM competition_category_scores r
+WINDOW w AS (
+	PARTITION BY r.competition_id, r.category_id
+	ORDER BY (CASE r.sort_direction WHEN 'ASC' THEN r.score ELSE -r.score END) ASC,
+		(CASE r.tie_breaker_direction WHEN 'ASC' THEN r.tie_breaker_score ELSE -r.tie_breaker_score END) ASC NULLS LAST
+);
+
+CREATE UNIQUE INDEX ON competition_results (competition_id, user_id, category_id);
+
+CREATE INDEX competitions_competition_id_idx ON competition_results (
+	competition_id
+);
+
+CREATE INDEX competitions_user_id_idx ON competition_results (
+	user_id
+);
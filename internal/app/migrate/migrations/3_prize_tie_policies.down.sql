@@ -0,0 +1,78 @@
+DROP MATERIALIZED VIEW competition_results;
+DROP VIEW competition_category_scores;
+DROP FUNCTION competition_user_metric;
+
+ALTER TABLE competitions
+	DROP COLUMN tie_policy,
+	DROP COLUMN tie_breaker,
+	DROP COLUMN tie_breaker_direction;
+
+ALTER TABLE competition_categories
+	ALTER COLUMN rewards TYPE INT[5];
+
+ALTER TABLE categories
+	ALTER COLUMN rewards TYPE INT[5];
+
+CREATE VIEW competition_category_scores AS
+SELECT s.competition_id,
+	s.user_id,
+	cc.category_id,
+	cc.rewards,
+	s.multiplier,
+	cat.sort_direction,
+	cat.tie_breaker_direction,
+	(
+		CASE cat.metric
+			WHEN 'PLAYED' THEN s.played
+			WHEN 'TYPED' THEN s.typed
+			WHEN 'ERRS' THEN s.errs
+			WHEN 'SECS' THEN s.secs
+			WHEN 'ACCURACY' THEN s.accuracy
+			WHEN 'SPEED' THEN s.speed
+			WHEN 'POINTS' THEN s.points
+			WHEN 'ERRORS_PER_RACE' THEN s.errors_per_race
+			WHEN 'SPEED_IMPROVEMENT' THEN s.speed_improvement
+		END
+	)::decimal AS score,
+	(
+		CASE cat.tie_breaker
+			WHEN 'PLAYED' THEN s.played
+			WHEN 'TYPED' THEN s.typed
+			WHEN 'ERRS' THEN s.errs
+			WHEN 'SECS' THEN s.secs
+			WHEN 'ACCURACY' THEN s.accuracy
+			WHEN 'SPEED' THEN s.speed
+			WHEN 'POINTS' THEN s.points
+			WHEN 'ERRORS_PER_RACE' THEN s.errors_per_race
+			WHEN 'SPEED_IMPROVEMENT' THEN s.speed_improvement
+		END
+	)::decimal AS tie_breaker_score
+FROM competition_user_stats s
+	INNER JOIN competition_categories cc ON cc.competition_id = s.competition_id
+	INNER JOIN categories cat ON cat.id = cc.category_id AND cat.deleted_at IS NULL
+	INNER JOIN users u ON u.id = s.user_id AND u.status != 'DISQUALIFIED'
+WHERE s.played >= cat.min_races;
+
+CREATE MATERIALIZED VIEW competition_results AS
+SELECT r.competition_id,
+	r.user_id,
+	r.category_id,
+	r.score,
+	rank() OVER w AS rank,
+	coalesce(r.rewards[rank() OVER w] * r.multiplier, 0) AS reward
+FROM competition_category_scores r
+WINDOW w AS (
+	PARTITION BY r.competition_id, r.category_id
+	ORDER BY (CASE r.sort_direction WHEN 'ASC' THEN r.score ELSE -r.score END) ASC,
+		(CASE r.tie_breaker_direction WHEN 'ASC' THEN r.tie_breaker_score ELSE -r.tie_breaker_score END) ASC NULLS LAST
+);
+
+CREATE UNIQUE INDEX ON competition_results (competition_id, user_id, category_id);
+
+CREATE INDEX competitions_competition_id_idx ON competition_results (
+	competition_id
+);
+
+CREATE INDEX competitions_user_id_idx ON competition_results (
+	user_id
+);
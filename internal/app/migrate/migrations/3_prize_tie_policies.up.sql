@@ -0,0 +1,123 @@
+DROP MATERIALIZED VIEW competition_results;
+DROP VIEW competition_category_scores;
+
+ALTER TABLE categories
+	ALTER COLUMN rewards TYPE INT[];
+
+ALTER TABLE competition_categories
+	ALTER COLUMN rewards TYPE INT[];
+
+ALTER TABLE competitions
+	ADD COLUMN tie_policy TEXT NOT NULL CHECK (tie_policy IN ('SHARE', 'FULL', 'BREAK')) DEFAULT 'FULL',
+	ADD COLUMN tie_breaker TEXT CHECK (tie_breaker IN ('PLAYED', 'TYPED', 'ERRS', 'SECS', 'ACCURACY', 'SPEED', 'POINTS', 'ERRORS_PER_RACE', 'SPEED_IMPROVEMENT')),
+	ADD COLUMN tie_breaker_direction TEXT NOT NULL CHECK (tie_breaker_direction IN ('ASC', 'DESC')) DEFAULT 'DESC';
+
+/************************
+*  Competition Results  *
+************************/
+
+-- competition_user_metric picks out a metric from the user's competition stats.
+CREATE FUNCTION competition_user_metric(s competition_user_stats, metric TEXT) RETURNS DECIMAL AS $$
+	SELECT (
+		CASE metric
+			WHEN 'PLAYED' THEN s.played
+			WHEN 'TYPED' THEN s.typed
+			WHEN 'ERRS' THEN s.errs
+			WHEN 'SECS' THEN s.secs
+			WHEN 'ACCURACY' THEN s.accuracy
+			WHEN 'SPEED' THEN s.speed
+			WHEN 'POINTS' THEN s.points
+			WHEN 'ERRORS_PER_RACE' THEN s.errors_per_race
+			WHEN 'SPEED_IMPROVEMENT' THEN s.speed_improvement
+		END
+	)::decimal
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- competition_category_scores contains each category's score and the keys used to sort it.
+CREATE VIEW competition_category_scores AS
+SELECT s.competition_id,
+	s.user_id,
+	cc.category_id,
+	cc.rewards,
+	s.multiplier,
+	c.tie_policy,
+	competition_user_metric(s, cat.metric) AS score,
+	(
+		CASE cat.sort_direction
+			WHEN 'ASC' THEN competition_user_metric(s, cat.metric)
+			ELSE -competition_user_metric(s, cat.metric)
+		END
+	) AS sort_key,
+	(
+		CASE cat.tie_breaker_direction
+			WHEN 'ASC' THEN competition_user_metric(s, cat.tie_breaker)
+			ELSE -competition_user_metric(s, cat.tie_breaker)
+		END
+	) AS tie_breaker_key,
+	(
+		CASE
+			WHEN c.tie_policy != 'BREAK' THEN NULL
+			WHEN c.tie_breaker_direction = 'ASC' THEN competition_user_metric(s, c.tie_breaker)
+			ELSE -competition_user_metric(s, c.tie_breaker)
+		END
+	) AS secondary_key
+FROM competition_user_stats s
+	INNER JOIN competitions c ON c.id = s.competition_id
+	INNER JOIN competition_categories cc ON cc.competition_id = s.competition_id
+	INNER JOIN categories cat ON cat.id = cc.category_id AND cat.deleted_at IS NULL
+	INNER JOIN users u ON u.id = s.user_id AND u.status != 'DISQUALIFIED'
+WHERE s.played >= cat.min_races;
+
+-- competition_results ranks each category and hands out the prizes using the competition's tie policy:
+-- * SHARE: tied racers split the prizes of the places they cover
+-- * FULL: tied racers all receive the prize of their shared place
+-- * BREAK: ties are broken by the competition tie breaker (anyone still tied receive the full prize)
+CREATE MATERIALIZED VIEW competition_results AS
+SELECT r.competition_id,
+	r.user_id,
+	r.category_id,
+	r.score,
+	r.rank,
+	r.tied,
+	coalesce((
+		CASE r.tie_policy
+			WHEN 'SHARE' THEN ROUND(
+				(
+					SELECT SUM(_p)
+					FROM unnest(r.rewards[r.rank:(r.rank + r.tied - 1)]) _p
+				) * r.multiplier / r.tied::decimal
+			)
+			ELSE r.rewards[r.rank] * r.multiplier
+		END
+	), 0)::int AS reward
+FROM (
+	SELECT _r.competition_id,
+		_r.user_id,
+		_r.category_id,
+		_r.rewards,
+		_r.multiplier,
+		_r.tie_policy,
+		_r.score,
+		_r.rank,
+		count(*) OVER (PARTITION BY _r.competition_id, _r.category_id, _r.rank)::int AS tied
+	FROM (
+		SELECT s.*,
+			(
+				rank() OVER (
+					PARTITION BY s.competition_id, s.category_id
+					ORDER BY s.sort_key ASC, s.tie_breaker_key ASC NULLS LAST, s.secondary_key ASC NULLS LAST
+				)
+			)::int AS rank
+		FROM competition_category_scores s
+	) _r
+) r;
+
+CREATE UNIQUE INDEX ON competition_results (competition_id, user_id, category_id);
+
+CREATE INDEX competitions_competition_id_idx ON competition_results (
+	competition_id
+);
+
+CREATE INDEX competitions_user_id_idx ON competition_results (
+	user_id
+);
@@ -0,0 +1,10 @@
+UPDATE competitions
+SET multiplier = 1
+WHERE multiplier NOT IN (1, 2, 4, 8);
+
+ALTER TABLE competitions
+	DROP COLUMN multiplier_schedule_id,
+	DROP CONSTRAINT competitions_multiplier_check,
+	ADD CONSTRAINT competitions_multiplier_check CHECK (multiplier IN (1, 2, 4, 8));
+
+DROP TABLE multiplier_schedules;
@@ -0,0 +1,14 @@
+CREATE TABLE multiplier_schedules (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+	seed BIGINT NOT NULL,
+	rules JSON NOT NULL,
+
+	deleted_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE competitions
+	ADD COLUMN multiplier_schedule_id UUID REFERENCES multiplier_schedules (id),
+	DROP CONSTRAINT competitions_multiplier_check,
+	ADD CONSTRAINT competitions_multiplier_check CHECK (multiplier >= 1);
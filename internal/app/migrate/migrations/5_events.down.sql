@@ -0,0 +1,10 @@
+ALTER TABLE nt_api_team_log_requests
+	DROP COLUMN team_tag;
+
+ALTER TABLE competitions
+	DROP COLUMN event_id;
+
+DROP TABLE
+	event_categories,
+	events
+;
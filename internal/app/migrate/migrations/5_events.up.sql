@@ -0,0 +1,103 @@
+/***********
+*  Events  *
+***********/
+
+CREATE TABLE events (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+	name TEXT NOT NULL,
+	team_tag TEXT NOT NULL,
+	team_id INT NOT NULL,
+	rules TEXT NOT NULL DEFAULT '',
+	window_minutes INT NOT NULL CHECK (window_minutes > 0) DEFAULT 10,
+	tie_policy TEXT NOT NULL CHECK (tie_policy IN ('SHARE', 'FULL', 'BREAK')) DEFAULT 'FULL',
+	tie_breaker TEXT CHECK (tie_breaker IN ('PLAYED', 'TYPED', 'ERRS', 'SECS', 'ACCURACY', 'SPEED', 'POINTS', 'ERRORS_PER_RACE', 'SPEED_IMPROVEMENT')),
+	tie_breaker_direction TEXT NOT NULL CHECK (tie_breaker_direction IN ('ASC', 'DESC')) DEFAULT 'DESC',
+	from_at TIMESTAMPTZ NOT NULL,
+	to_at TIMESTAMPTZ NOT NULL,
+
+	deleted_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX events_time_range_idx ON events (
+	from_at,
+	to_at
+);
+
+CREATE TABLE event_categories (
+	event_id UUID NOT NULL REFERENCES events (id),
+	category_id UUID NOT NULL REFERENCES categories (id),
+	rewards INT[] NOT NULL,
+
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (event_id, category_id)
+);
+
+ALTER TABLE nt_api_team_log_requests
+	ADD COLUMN team_tag TEXT;
+
+-- Requests take the team tag from their team log, the ones without team data (e.g. errors) take the latest known tag.
+UPDATE nt_api_team_log_requests r
+SET team_tag = l.log_data->'data'->'info'->>'tag'
+FROM nt_api_team_logs l
+WHERE l.id = r.api_team_log_id;
+
+UPDATE nt_api_team_log_requests
+SET team_tag = coalesce(
+	(
+		SELECT _r.team_tag
+		FROM nt_api_team_log_requests _r
+		WHERE _r.team_tag IS NOT NULL
+		ORDER BY _r.created_at DESC
+		LIMIT 1
+	),
+	''
+)
+WHERE team_tag IS NULL;
+
+ALTER TABLE nt_api_team_log_requests
+	ALTER COLUMN team_tag SET NOT NULL;
+
+CREATE INDEX nt_api_team_log_requests_team_tag_idx ON nt_api_team_log_requests (
+	team_tag,
+	created_at
+);
+
+-- The existing competitions belong to one event, run by the team of the latest team log.
+INSERT INTO events (name, team_tag, team_id, from_at, to_at)
+SELECT (t.info->>'name') || ' Xmaxx', t.info->>'tag', (t.info->>'teamID')::int, c.from_at, c.to_at
+FROM (
+		SELECT MIN(from_at) AS from_at, MAX(to_at) AS to_at
+		FROM competitions
+		HAVING COUNT(*) > 0
+	) c
+	CROSS JOIN (
+		SELECT l.log_data->'data'->'info' AS info
+		FROM nt_api_team_log_requests r
+			INNER JOIN nt_api_team_logs l ON l.id = r.api_team_log_id
+		WHERE l.log_data->'data'->'info'->>'teamID' IS NOT NULL
+		ORDER BY r.created_at DESC
+		LIMIT 1
+	) t;
+
+INSERT INTO event_categories (event_id, category_id, rewards)
+SELECT e.id, cat.id, cat.rewards
+FROM events e
+	CROSS JOIN categories cat;
+
+ALTER TABLE competitions
+	ADD COLUMN event_id UUID REFERENCES events (id);
+
+UPDATE competitions
+SET event_id = (SELECT id FROM events LIMIT 1);
+
+ALTER TABLE competitions
+	ALTER COLUMN event_id SET NOT NULL;
+
+CREATE INDEX competitions_event_id_idx ON competitions (
+	event_id,
+	from_at
+);
@@ -0,0 +1,128 @@
+DROP MATERIALIZED VIEW competition_results;
+DROP VIEW competition_category_scores;
+
+ALTER TABLE categories
+	ADD COLUMN min_secs INT NOT NULL CHECK (min_secs >= 0) DEFAULT 0,
+	ADD COLUMN min_typed INT NOT NULL CHECK (min_typed >= 0) DEFAULT 0;
+
+ALTER TABLE events
+	ADD COLUMN min_races INT NOT NULL CHECK (min_races >= 1) DEFAULT 1,
+	ADD COLUMN min_secs INT NOT NULL CHECK (min_secs >= 0) DEFAULT 0,
+	ADD COLUMN min_typed INT NOT NULL CHECK (min_typed >= 0) DEFAULT 0;
+
+ALTER TABLE competitions
+	ADD COLUMN min_races INT NOT NULL CHECK (min_races >= 1) DEFAULT 1,
+	ADD COLUMN min_secs INT NOT NULL CHECK (min_secs >= 0) DEFAULT 0,
+	ADD COLUMN min_typed INT NOT NULL CHECK (min_typed >= 0) DEFAULT 0;
+
+/************************
+*  Competition Results  *
+************************/
+
+-- competition_category_scores contains each category's score, the keys used to sort it and whether the racer is eligible.
+-- The stricter of the competition and category thresholds is used.
+CREATE VIEW competition_category_scores AS
+SELECT s.competition_id,
+	s.user_id,
+	cc.category_id,
+	cc.rewards,
+	s.multiplier,
+	c.tie_policy,
+	competition_user_metric(s, cat.metric) AS score,
+	(
+		CASE cat.sort_direction
+			WHEN 'ASC' THEN competition_user_metric(s, cat.metric)
+			ELSE -competition_user_metric(s, cat.metric)
+		END
+	) AS sort_key,
+	(
+		CASE cat.tie_breaker_direction
+			WHEN 'ASC' THEN competition_user_metric(s, cat.tie_breaker)
+			ELSE -competition_user_metric(s, cat.tie_breaker)
+		END
+	) AS tie_breaker_key,
+	(
+		CASE
+			WHEN c.tie_policy != 'BREAK' THEN NULL
+			WHEN c.tie_breaker_direction = 'ASC' THEN competition_user_metric(s, c.tie_breaker)
+			ELSE -competition_user_metric(s, c.tie_breaker)
+		END
+	) AS secondary_key,
+	(
+		CASE
+			WHEN s.played < GREATEST(c.min_races, cat.min_races) THEN format('Needs at least %s races', GREATEST(c.min_races, cat.min_races))
+			WHEN s.secs < GREATEST(c.min_secs, cat.min_secs) THEN format('Needs at least %s seconds of racing', GREATEST(c.min_secs, cat.min_secs))
+			WHEN s.typed < GREATEST(c.min_typed, cat.min_typed) THEN format('Needs at least %s characters typed', GREATEST(c.min_typed, cat.min_typed))
+		END
+	) AS ineligible_reason
+FROM competition_user_stats s
+	INNER JOIN competitions c ON c.id = s.competition_id
+	INNER JOIN competition_categories cc ON cc.competition_id = s.competition_id
+	INNER JOIN categories cat ON cat.id = cc.category_id AND cat.deleted_at IS NULL
+	INNER JOIN users u ON u.id = s.user_id AND u.status != 'DISQUALIFIED';
+
+-- competition_results ranks each category and hands out the prizes using the competition's tie policy:
+-- * SHARE: tied racers split the prizes of the places they cover
+-- * FULL: tied racers all receive the prize of their shared place
+-- * BREAK: ties are broken by the competition tie breaker (anyone still tied receive the full prize)
+-- Ineligible racers are listed without a rank or reward.
+CREATE MATERIALIZED VIEW competition_results AS
+SELECT r.competition_id,
+	r.user_id,
+	r.category_id,
+	r.score,
+	r.eligible,
+	r.ineligible_reason,
+	r.rank,
+	r.tied,
+	coalesce((
+		CASE
+			WHEN NOT r.eligible THEN 0
+			WHEN r.tie_policy = 'SHARE' THEN ROUND(
+				(
+					SELECT SUM(_p)
+					FROM unnest(r.rewards[r.rank:(r.rank + r.tied - 1)]) _p
+				) * r.multiplier / r.tied::decimal
+			)
+			ELSE r.rewards[r.rank] * r.multiplier
+		END
+	), 0)::int AS reward
+FROM (
+	SELECT _r.competition_id,
+		_r.user_id,
+		_r.category_id,
+		_r.rewards,
+		_r.multiplier,
+		_r.tie_policy,
+		_r.score,
+		_r.eligible,
+		_r.ineligible_reason,
+		(CASE WHEN _r.eligible THEN _r.rank END) AS rank,
+		(
+			CASE
+				WHEN _r.eligible THEN count(*) OVER (PARTITION BY _r.competition_id, _r.category_id, _r.eligible, _r.rank)::int
+				ELSE 0
+			END
+		) AS tied
+	FROM (
+		SELECT s.*,
+			(s.ineligible_reason IS NULL) AS eligible,
+			(
+				rank() OVER (
+					PARTITION BY s.competition_id, s.category_id, (s.ineligible_reason IS NULL)
+					ORDER BY s.sort_key ASC, s.tie_breaker_key ASC NULLS LAST, s.secondary_key ASC NULLS LAST
+				)
+			)::int AS rank
+		FROM competition_category_scores s
+	) _r
+) r;
+
+CREATE UNIQUE INDEX ON competition_results (competition_id, user_id, category_id);
+
+CREATE INDEX competitions_competition_id_idx ON competition_results (
+	competition_id
+);
+
+CREATE INDEX competitions_user_id_idx ON competition_results (
+	user_id
+);
@@ -0,0 +1,99 @@
+DROP TABLE competition_results;
+DROP VIEW competition_ranked_results;
+DROP INDEX user_records_user_id_idx;
+
+CREATE OR REPLACE VIEW competition_user_stats AS
+SELECT c.id AS competition_id,
+	c.multiplier,
+	ur.user_id,
+	ur.played,
+	ur.typed,
+	ur.errs,
+	ur.secs,
+	ur.accuracy,
+	ur.speed,
+	ROUND(ur.played * ((100.0 + (ur.speed / 2.0)) * (ur.accuracy / 100.0))) AS points,
+	(ur.errs / ur.played::decimal) AS errors_per_race,
+	coalesce(ur.speed - (ur.prev_typed / 5.0 / (NULLIF(ur.prev_secs, 0) / 60.0)), 0) AS speed_improvement
+FROM competitions c
+	INNER JOIN (
+		SELECT _ur.request_id,
+			_ur.user_id,
+			_ur.played,
+			_ur.typed,
+			_ur.errs,
+			_ur.secs,
+			((1.0 - (_ur.errs / _ur.typed::decimal)) * 100.0) AS accuracy,
+			(_ur.typed / 5.0 / (_ur.secs / 60.0)) AS speed,
+			SUM(_ur.typed) OVER prev AS prev_typed,
+			SUM(_ur.secs) OVER prev AS prev_secs
+		FROM user_records _ur
+		WHERE _ur.deleted_at IS NULL
+		WINDOW prev AS (PARTITION BY _ur.user_id ORDER BY _ur.to_at ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING)
+	) ur ON ur.request_id = c.request_id;
+
+-- competition_results ranks each category and hands out the prizes using the competition's tie policy:
+-- * SHARE: tied racers split the prizes of the places they cover
+-- * FULL: tied racers all receive the prize of their shared place
+-- * BREAK: ties are broken by the competition tie breaker (anyone still tied receive the full prize)
+-- Ineligible racers are listed without a rank or reward.
+CREATE MATERIALIZED VIEW competition_results AS
+SELECT r.competition_id,
+	r.user_id,
+	r.category_id,
+	r.score,
+	r.eligible,
+	r.ineligible_reason,
+	r.rank,
+	r.tied,
+	coalesce((
+		CASE
+			WHEN NOT r.eligible THEN 0
+			WHEN r.tie_policy = 'SHARE' THEN ROUND(
+				(
+					SELECT SUM(_p)
+					FROM unnest(r.rewards[r.rank:(r.rank + r.tied - 1)]) _p
+				) * r.multiplier / r.tied::decimal
+			)
+			ELSE r.rewards[r.rank] * r.multiplier
+		END
+	), 0)::int AS reward
+FROM (
+	SELECT _r.competition_id,
+		_r.user_id,
+		_r.category_id,
+		_r.rewards,
+		_r.multiplier,
+		_r.tie_policy,
+		_r.score,
+		_r.eligible,
+		_r.ineligible_reason,
+		(CASE WHEN _r.eligible THEN _r.rank END) AS rank,
+		(
+			CASE
+				WHEN _r.eligible THEN count(*) OVER (PARTITION BY _r.competition_id, _r.category_id, _r.eligible, _r.rank)::int
+				ELSE 0
+			END
+		) AS tied
+	FROM (
+		SELECT s.*,
+			(s.ineligible_reason IS NULL) AS eligible,
+			(
+				rank() OVER (
+					PARTITION BY s.competition_id, s.category_id, (s.ineligible_reason IS NULL)
+					ORDER BY s.sort_key ASC, s.tie_breaker_key ASC NULLS LAST, s.secondary_key ASC NULLS LAST
+				)
+			)::int AS rank
+		FROM competition_category_scores s
+	) _r
+) r;
+
+CREATE UNIQUE INDEX ON competition_results (competition_id, user_id, category_id);
+
+CREATE INDEX competitions_competition_id_idx ON competition_results (
+	competition_id
+);
+
+CREATE INDEX competitions_user_id_idx ON competition_results (
+	user_id
+);
@@ -0,0 +1,128 @@
+DROP MATERIALIZED VIEW competition_results;
+
+CREATE INDEX user_records_user_id_idx ON user_records (
+	user_id,
+	to_at
+);
+
+/************************
+*  Competition Results  *
+************************/
+
+-- competition_user_stats contains every metric a category can rank on.
+-- The previous records are looked up per user so a single competition can be computed without the others.
+CREATE OR REPLACE VIEW competition_user_stats AS
+SELECT c.id AS competition_id,
+	c.multiplier,
+	ur.user_id,
+	ur.played,
+	ur.typed,
+	ur.errs,
+	ur.secs,
+	ur.accuracy,
+	ur.speed,
+	ROUND(ur.played * ((100.0 + (ur.speed / 2.0)) * (ur.accuracy / 100.0))) AS points,
+	(ur.errs / ur.played::decimal) AS errors_per_race,
+	coalesce(ur.speed - (prev.typed / 5.0 / (NULLIF(prev.secs, 0) / 60.0)), 0) AS speed_improvement
+FROM competitions c
+	INNER JOIN (
+		SELECT _ur.request_id,
+			_ur.user_id,
+			_ur.played,
+			_ur.typed,
+			_ur.errs,
+			_ur.secs,
+			_ur.to_at,
+			((1.0 - (_ur.errs / _ur.typed::decimal)) * 100.0) AS accuracy,
+			(_ur.typed / 5.0 / (_ur.secs / 60.0)) AS speed
+		FROM user_records _ur
+		WHERE _ur.deleted_at IS NULL
+	) ur ON ur.request_id = c.request_id
+	LEFT JOIN LATERAL (
+		SELECT SUM(_p.typed) AS typed,
+			SUM(_p.secs) AS secs
+		FROM user_records _p
+		WHERE _p.user_id = ur.user_id
+			AND _p.to_at < ur.to_at
+			AND _p.deleted_at IS NULL
+	) prev ON true;
+
+-- competition_ranked_results ranks each category and hands out the prizes using the competition's tie policy:
+-- * SHARE: tied racers split the prizes of the places they cover
+-- * FULL: tied racers all receive the prize of their shared place
+-- * BREAK: ties are broken by the competition tie breaker (anyone still tied receive the full prize)
+-- Ineligible racers are listed without a rank or reward.
+CREATE VIEW competition_ranked_results AS
+SELECT r.competition_id,
+	r.user_id,
+	r.category_id,
+	r.score,
+	r.eligible,
+	r.ineligible_reason,
+	r.rank,
+	r.tied,
+	coalesce((
+		CASE
+			WHEN NOT r.eligible THEN 0
+			WHEN r.tie_policy = 'SHARE' THEN ROUND(
+				(
+					SELECT SUM(_p)
+					FROM unnest(r.rewards[r.rank:(r.rank + r.tied - 1)]) _p
+				) * r.multiplier / r.tied::decimal
+			)
+			ELSE r.rewards[r.rank] * r.multiplier
+		END
+	), 0)::int AS reward
+FROM (
+	SELECT _r.competition_id,
+		_r.user_id,
+		_r.category_id,
+		_r.rewards,
+		_r.multiplier,
+		_r.tie_policy,
+		_r.score,
+		_r.eligible,
+		_r.ineligible_reason,
+		(CASE WHEN _r.eligible THEN _r.rank END) AS rank,
+		(
+			CASE
+				WHEN _r.eligible THEN count(*) OVER (PARTITION BY _r.competition_id, _r.category_id, _r.eligible, _r.rank)::int
+				ELSE 0
+			END
+		) AS tied
+	FROM (
+		SELECT s.*,
+			(s.ineligible_reason IS NULL) AS eligible,
+			(
+				rank() OVER (
+					PARTITION BY s.competition_id, s.category_id, (s.ineligible_reason IS NULL)
+					ORDER BY s.sort_key ASC, s.tie_breaker_key ASC NULLS LAST, s.secondary_key ASC NULLS LAST
+				)
+			)::int AS rank
+		FROM competition_category_scores s
+	) _r
+) r;
+
+CREATE TABLE competition_results (
+	competition_id UUID NOT NULL REFERENCES competitions (id),
+	user_id UUID NOT NULL REFERENCES users (id),
+	category_id UUID NOT NULL REFERENCES categories (id),
+	score DECIMAL NOT NULL,
+	eligible BOOLEAN NOT NULL,
+	ineligible_reason TEXT,
+	rank INT,
+	tied INT NOT NULL,
+	reward INT NOT NULL,
+
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+	PRIMARY KEY (competition_id, user_id, category_id)
+);
+
+CREATE INDEX competition_results_user_id_idx ON competition_results (
+	user_id
+);
+
+INSERT INTO competition_results (competition_id, user_id, category_id, score, eligible, ineligible_reason, rank, tied, reward)
+SELECT competition_id, user_id, category_id, score, eligible, ineligible_reason, rank, tied, reward
+FROM competition_ranked_results;
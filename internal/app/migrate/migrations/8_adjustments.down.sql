@@ -0,0 +1,2 @@
+DROP VIEW user_points;
+DROP TABLE adjustments;
@@ -0,0 +1,39 @@
+/*****************
+*  Adjustments  *
+*****************/
+
+-- adjustments are manual points given (bonuses) or taken (penalties) by the captains.
+-- They're kept apart from the competition results so recomputing the results doesn't lose them.
+CREATE TABLE adjustments (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+	user_id UUID NOT NULL REFERENCES users (id),
+	competition_id UUID REFERENCES competitions (id),
+	points INT NOT NULL CHECK (points != 0),
+	reason TEXT NOT NULL,
+	author TEXT NOT NULL,
+
+	deleted_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX adjustments_user_id_idx ON adjustments (
+	user_id
+);
+
+CREATE INDEX adjustments_competition_id_idx ON adjustments (
+	competition_id
+);
+
+-- user_points contains every source of points (competition rewards and adjustments).
+CREATE VIEW user_points AS
+SELECT r.user_id,
+	r.competition_id,
+	r.reward AS points
+FROM competition_results r
+UNION ALL
+SELECT a.user_id,
+	a.competition_id,
+	a.points
+FROM adjustments a
+WHERE a.deleted_at IS NULL;
@@ -0,0 +1,5 @@
+DROP VIEW event_payout_balances;
+DROP TABLE payouts;
+
+ALTER TABLE events
+	DROP COLUMN payout_rate;
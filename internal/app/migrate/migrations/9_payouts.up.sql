@@ -0,0 +1,60 @@
+/*************
+*  Payouts  *
+*************/
+
+-- payout_rate is the in-game cash paid for each reward point.
+ALTER TABLE events
+	ADD COLUMN payout_rate DECIMAL NOT NULL CHECK (payout_rate >= 0) DEFAULT 1;
+
+-- payouts are the in-game cash or gifts handed out to the racers (PENDING -> SENT -> CONFIRMED).
+CREATE TABLE payouts (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+	event_id UUID NOT NULL REFERENCES events (id),
+	user_id UUID NOT NULL REFERENCES users (id),
+	amount BIGINT NOT NULL CHECK (amount > 0),
+	status TEXT NOT NULL CHECK (status IN ('PENDING', 'SENT', 'CONFIRMED')) DEFAULT 'PENDING',
+	note TEXT NOT NULL DEFAULT '',
+	sent_at TIMESTAMPTZ,
+	confirmed_at TIMESTAMPTZ,
+
+	deleted_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX payouts_event_id_idx ON payouts (
+	event_id,
+	user_id
+);
+
+-- event_payout_balances compares what each racer has earned in an event against what has been paid out.
+-- Pending payouts are already taken off the outstanding balance so they aren't paid twice.
+CREATE VIEW event_payout_balances AS
+SELECT coalesce(b.event_id, p.event_id) AS event_id,
+	coalesce(b.user_id, p.user_id) AS user_id,
+	coalesce(b.points, 0) AS points,
+	coalesce(b.earned, 0) AS earned,
+	coalesce(p.pending, 0) AS pending,
+	coalesce(p.sent, 0) AS sent,
+	coalesce(p.confirmed, 0) AS confirmed,
+	(coalesce(b.earned, 0) - coalesce(p.pending, 0) - coalesce(p.sent, 0) - coalesce(p.confirmed, 0)) AS outstanding
+FROM (
+	SELECT c.event_id,
+		up.user_id,
+		SUM(up.points)::int AS points,
+		GREATEST(FLOOR(SUM(up.points) * e.payout_rate), 0)::bigint AS earned
+	FROM user_points up
+		INNER JOIN competitions c ON c.id = up.competition_id
+		INNER JOIN events e ON e.id = c.event_id
+	GROUP BY c.event_id, up.user_id, e.payout_rate
+) b
+	FULL JOIN (
+		SELECT _p.event_id,
+			_p.user_id,
+			(SUM(_p.amount) FILTER (WHERE _p.status = 'PENDING'))::bigint AS pending,
+			(SUM(_p.amount) FILTER (WHERE _p.status = 'SENT'))::bigint AS sent,
+			(SUM(_p.amount) FILTER (WHERE _p.status = 'CONFIRMED'))::bigint AS confirmed
+		FROM payouts _p
+		WHERE _p.deleted_at IS NULL
+		GROUP BY _p.event_id, _p.user_id
+	) p ON p.event_id = b.event_id AND p.user_id = b.user_id;
@@ -5,13 +5,21 @@ import (
 	b64 "encoding/base64"
 	"fmt"
 	"net/http"
+	"nt-folly-xmaxx-comp/internal/app/serve/auth"
+	"nt-folly-xmaxx-comp/internal/app/serve/cache"
 	"nt-folly-xmaxx-comp/internal/app/serve/dataloaders"
 	"nt-folly-xmaxx-comp/internal/app/serve/graphql"
+	"nt-folly-xmaxx-comp/internal/app/serve/httpcache"
+	"nt-folly-xmaxx-comp/internal/app/serve/metrics"
+	"nt-folly-xmaxx-comp/internal/app/serve/pubsub"
+	"nt-folly-xmaxx-comp/pkg/nitrotype"
 	"time"
 
 	gqlgraphql "github.com/99designs/gqlgen/graphql"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/go-chi/chi"
@@ -20,12 +28,34 @@ import (
 	"github.com/go-chi/httprate"
 	"github.com/gorilla/websocket"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 	"go.uber.org/zap"
 )
 
+// CacheOptions configures the persisted queries and the HTTP caching of the GQL GET requests.
+type CacheOptions struct {
+	// APQCacheSize is how many automatic persisted queries are kept (the least recently used are dropped).
+	APQCacheSize int
+	// MaxAge is how long GET responses with only finished competitions can be cached (0 to always revalidate them).
+	MaxAge time.Duration
+}
+
+// LimitOptions configures how expensive a GQL operation can be (0 disables a limit).
+type LimitOptions struct {
+	// MaxDepth is how deeply the fields of an operation can be nested.
+	MaxDepth int
+	// MaxComplexity is the highest cost of an operation, list fields cost as much as the items they can return.
+	MaxComplexity int
+}
+
 // NewAPIService sets up the API Service for Raffles
-func NewAPIService(conn *pgxpool.Pool, log *zap.Logger, corsOptions *cors.Options) http.Handler {
+// Requests made with an API key get the full schema, everyone else gets the read only schema (without the admin fields).
+// Subscriptions receive their updates from the broker and the provisional leaderboards are refreshed with the API client.
+// The leaderboards and standings are cached until the broker receives their competition updates.
+// GET requests get ETags derived from the sync version, so they can be cached by a CDN.
+// Operations that are too deep or too complex are rejected before they run.
+func NewAPIService(conn *pgxpool.Pool, broker *pubsub.Broker, resultCache *cache.Cache, syncVersion *httpcache.SyncVersion, apiClient nitrotype.APIClient, log *zap.Logger, corsOptions *cors.Options, cacheOptions *CacheOptions, limitOptions *LimitOptions) http.Handler {
 	corsMiddleware := cors.Handler(*corsOptions)
 
 	r := chi.NewRouter()
@@ -33,19 +63,55 @@ func NewAPIService(conn *pgxpool.Pool, log *zap.Logger, corsOptions *cors.Option
 	r.Use(middleware.RealIP)
 	r.Use(corsMiddleware)
 	r.Use(httprate.LimitByIP(100, 1*time.Minute))
+	r.Use(auth.Middleware(conn, log))
 	r.Use(dataloaders.Middleware(conn))
 
-	gqlServer := handler.NewDefaultServer(
-		graphql.NewExecutableSchema(
-			graphql.Config{
-				Resolvers: &graphql.Resolver{
-					Conn: conn,
-					Log:  log,
-				},
-			},
-		),
-	)
-	// gqlServer.Use(extension.FixedComplexityLimit(181))
+	schema := graphql.NewSchema(&graphql.Resolver{
+		Conn:      conn,
+		Broker:    broker,
+		APIClient: apiClient,
+		Cache:     resultCache,
+		Log:       log,
+	})
+	adminServer := newGQLServer(schema, log, cacheOptions.APQCacheSize, limitOptions)
+	publicSchema := graphql.NewReadOnlySchema(schema)
+	publicServer := newGQLServer(publicSchema, log, cacheOptions.APQCacheSize, limitOptions)
+	publicServer.Use(publicSchema)
+	gqlHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.IsAdmin(r.Context()) {
+			adminServer.ServeHTTP(w, r)
+			return
+		}
+		publicServer.ServeHTTP(w, r)
+	})
+
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/check", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("OK"))
+		})
+		r.With(auth.RequireAdmin).Get("/events/{eventID}/payouts.csv", payoutBalancesCSV(conn, log))
+		r.Route("/export", func(r chi.Router) {
+			r.Get("/competitions/{competitionID}/leaderboard", exportLeaderboard(conn, log))
+			r.Get("/events/{eventID}/standings", exportStandings(conn, log))
+			r.Get("/users/{userID}/history", exportUserHistory(conn, log))
+			r.With(auth.RequireAdmin).Get("/user-records", exportUserRecords(conn, log))
+		})
+		r.Route("/gql", func(r chi.Router) {
+			r.Handle("/", playground.Handler("GraphQL playground", "/api/gql/query"))
+			r.Handle("/query", httpcache.Middleware(syncVersion, cacheOptions.MaxAge)(gqlHandler))
+		})
+	})
+	r.Handle("/metrics", promhttp.Handler())
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Oh Folly"))
+	})
+
+	return r
+}
+
+// newGQLServer sets up a GQL server for the schema, with the error handling, transports, persisted queries, query limits and request logging.
+func newGQLServer(schema gqlgraphql.ExecutableSchema, log *zap.Logger, apqCacheSize int, limitOptions *LimitOptions) *handler.Server {
+	gqlServer := handler.New(schema)
 	gqlServer.SetErrorPresenter(
 		func(ctx context.Context, e error) *gqlerror.Error {
 			if gqlErr, ok := e.(*gqlerror.Error); ok {
@@ -86,6 +152,18 @@ func NewAPIService(conn *pgxpool.Pool, log *zap.Logger, corsOptions *cors.Option
 	gqlServer.AddTransport(transport.GET{})
 	gqlServer.AddTransport(transport.POST{})
 	gqlServer.AddTransport(transport.MultipartForm{})
+	gqlServer.SetQueryCache(lru.New(1000))
+	gqlServer.Use(extension.Introspection{})
+	gqlServer.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New(apqCacheSize),
+	})
+	gqlServer.Use(&graphql.QueryLimits{
+		MaxDepth:      limitOptions.MaxDepth,
+		MaxComplexity: limitOptions.MaxComplexity,
+	})
+	gqlServer.Use(metrics.Tracer{})
+	gqlServer.AroundFields(httpcache.TrackFields)
+	gqlServer.AroundOperations(dataloaders.AroundOperations)
 	gqlServer.AroundOperations(func(ctx context.Context, next gqlgraphql.OperationHandler) gqlgraphql.ResponseHandler {
 		opCtx := gqlgraphql.GetOperationContext(ctx)
 		if opCtx.OperationName != "IntrospectionQuery" && opCtx.Operation != nil {
@@ -97,19 +175,5 @@ func NewAPIService(conn *pgxpool.Pool, log *zap.Logger, corsOptions *cors.Option
 		}
 		return next(ctx)
 	})
-
-	r.Route("/api", func(r chi.Router) {
-		r.Get("/check", func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte("OK"))
-		})
-		r.Route("/gql", func(r chi.Router) {
-			r.Handle("/", playground.Handler("GraphQL playground", "/api/gql/query"))
-			r.Handle("/query", gqlServer)
-		})
-	})
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("Oh Folly"))
-	})
-
-	return r
+	return gqlServer
 }
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"nt-folly-xmaxx-comp/internal/app/serve/globalid"
+	"nt-folly-xmaxx-comp/internal/pkg/export"
+	"nt-folly-xmaxx-comp/internal/pkg/reports"
+	"nt-folly-xmaxx-comp/internal/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+// exportFunc streams an export of the requested format.
+type exportFunc func(r *http.Request, w http.ResponseWriter, format export.Format) error
+
+// exportHandler sets up an export download, the filters are read from the query string (named like the GQL arguments).
+// Like the GQL arguments, the ids can be global ids or database ids.
+// Invalid filters are rejected before anything is streamed, errors found afterwards can only be logged.
+func exportHandler(log *zap.Logger, name string, fn exportFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := export.ParseFormat(r.URL.Query().Get("format"))
+		if err != nil {
+			http.Error(w, "Format must be csv or ndjson", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", name, format))
+		out := &exportResponse{ResponseWriter: w}
+		err = fn(r, out, format)
+		if err != nil && !out.written {
+			w.Header().Del("Content-Disposition")
+		}
+		var badRequest exportBadRequest
+		switch {
+		case err == nil:
+		case out.written:
+			log.Error("unable to finish export "+name,
+				zap.String("reqID", middleware.GetReqID(r.Context())),
+				zap.Error(err),
+			)
+		case errors.As(err, &badRequest):
+			http.Error(w, string(badRequest), http.StatusBadRequest)
+		case errors.Is(err, export.ErrCompetitionNotFound):
+			http.Error(w, "Competition not found", http.StatusNotFound)
+		case errors.Is(err, export.ErrEventNotFound):
+			http.Error(w, "Event not found", http.StatusNotFound)
+		case errors.Is(err, export.ErrUserNotFound):
+			http.Error(w, "User not found", http.StatusNotFound)
+		default:
+			log.Error("unable to export "+name,
+				zap.String("reqID", middleware.GetReqID(r.Context())),
+				zap.Error(err),
+			)
+			http.Error(w, "There was a problem with the server, please try again.", http.StatusInternalServerError)
+		}
+	}
+}
+
+// exportResponse tracks whether the export has started streaming (the status can't be changed afterwards).
+type exportResponse struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *exportResponse) Write(data []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *exportResponse) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// exportBadRequest is an invalid filter, its message is sent back to the client.
+type exportBadRequest string
+
+func (e exportBadRequest) Error() string {
+	return string(e)
+}
+
+// exportLeaderboard downloads a competition leaderboard.
+func exportLeaderboard(conn *pgxpool.Pool, log *zap.Logger) http.HandlerFunc {
+	return exportHandler(log, "leaderboard", func(r *http.Request, w http.ResponseWriter, format export.Format) error {
+		competitionID := globalid.Decode(globalid.Competition, chi.URLParam(r, "competitionID"))
+		if !utils.IsValidID(competitionID) {
+			return export.ErrCompetitionNotFound
+		}
+		query := r.URL.Query()
+		filter := reports.LeaderboardFilter{
+			IncludeIneligible: true,
+		}
+		if category := query.Get("category"); category != "" {
+			filter.Category = &category
+		}
+		if value := query.Get("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 {
+				return exportBadRequest("Limit must be a positive number")
+			}
+			filter.Limit = limit
+		}
+		if value := query.Get("includeIneligible"); value != "" {
+			includeIneligible, err := strconv.ParseBool(value)
+			if err != nil {
+				return exportBadRequest("Include ineligible must be true or false")
+			}
+			filter.IncludeIneligible = includeIneligible
+		}
+		if value := query.Get("userIds"); value != "" {
+			filter.UserIDs = []string{}
+			for _, userID := range strings.Split(value, ",") {
+				if userID = globalid.Decode(globalid.User, strings.TrimSpace(userID)); utils.IsValidID(userID) {
+					filter.UserIDs = append(filter.UserIDs, userID)
+				}
+			}
+		}
+		return export.Leaderboard(r.Context(), conn, w, format, competitionID, filter)
+	})
+}
+
+// exportStandings downloads the overall standings of an event.
+func exportStandings(conn *pgxpool.Pool, log *zap.Logger) http.HandlerFunc {
+	return exportHandler(log, "standings", func(r *http.Request, w http.ResponseWriter, format export.Format) error {
+		eventID := globalid.Decode(globalid.Event, chi.URLParam(r, "eventID"))
+		if !utils.IsValidID(eventID) {
+			return export.ErrEventNotFound
+		}
+		query := r.URL.Query()
+		timeRange, err := parseExportTimeRange(r)
+		if err != nil {
+			return err
+		}
+		filter := reports.StandingsFilter{
+			TimeRange: timeRange,
+		}
+		if category := query.Get("category"); category != "" {
+			filter.Category = &category
+		}
+		switch strings.ToUpper(query.Get("ranking")) {
+		case "", "STANDARD":
+		case "DENSE":
+			filter.Dense = true
+		default:
+			return exportBadRequest("Ranking must be STANDARD or DENSE")
+		}
+		return export.Standings(r.Context(), conn, w, format, eventID, filter)
+	})
+}
+
+// exportUserHistory downloads a racer's competition results.
+func exportUserHistory(conn *pgxpool.Pool, log *zap.Logger) http.HandlerFunc {
+	return exportHandler(log, "history", func(r *http.Request, w http.ResponseWriter, format export.Format) error {
+		userID := globalid.Decode(globalid.User, chi.URLParam(r, "userID"))
+		if !utils.IsValidID(userID) {
+			return export.ErrUserNotFound
+		}
+		timeRange, err := parseExportTimeRange(r)
+		if err != nil {
+			return err
+		}
+		return export.UserHistory(r.Context(), conn, w, format, userID, timeRange)
+	})
+}
+
+// exportUserRecords downloads the raw user records, it requires an API key as it can stream the whole table.
+func exportUserRecords(conn *pgxpool.Pool, log *zap.Logger) http.HandlerFunc {
+	return exportHandler(log, "user-records", func(r *http.Request, w http.ResponseWriter, format export.Format) error {
+		query := r.URL.Query()
+		timeRange, err := parseExportTimeRange(r)
+		if err != nil {
+			return err
+		}
+		filter := export.RecordsFilter{
+			TimeRange: timeRange,
+		}
+		if userID := query.Get("userId"); userID != "" {
+			userID = globalid.Decode(globalid.User, userID)
+			if !utils.IsValidID(userID) {
+				return export.ErrUserNotFound
+			}
+			filter.UserID = &userID
+		}
+		if competitionID := query.Get("competitionId"); competitionID != "" {
+			competitionID = globalid.Decode(globalid.Competition, competitionID)
+			if !utils.IsValidID(competitionID) {
+				return export.ErrCompetitionNotFound
+			}
+			filter.CompetitionID = &competitionID
+		}
+		return export.UserRecords(r.Context(), conn, w, format, filter)
+	})
+}
+
+// parseExportTimeRange reads the optional timeFrom and timeTo filters (RFC3339), rounded the same way as the GQL time ranges.
+func parseExportTimeRange(r *http.Request) (*reports.TimeRange, error) {
+	query := r.URL.Query()
+	timeFromValue, timeToValue := query.Get("timeFrom"), query.Get("timeTo")
+	if timeFromValue == "" && timeToValue == "" {
+		return nil, nil
+	}
+	timeFrom, err := time.Parse(time.RFC3339, timeFromValue)
+	if err != nil {
+		return nil, exportBadRequest("Invalid time range received")
+	}
+	timeTo, err := time.Parse(time.RFC3339, timeToValue)
+	if err != nil {
+		return nil, exportBadRequest("Invalid time range received")
+	}
+	timeFrom, timeTo = utils.TimeRound(timeFrom), utils.TimeRound(timeTo)
+	if !timeFrom.Before(timeTo) {
+		return nil, exportBadRequest("Invalid time range received")
+	}
+	return &reports.TimeRange{TimeFrom: timeFrom, TimeTo: timeTo}, nil
+}
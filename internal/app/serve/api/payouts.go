@@ -0,0 +1,43 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"nt-folly-xmaxx-comp/internal/app/serve/globalid"
+	"nt-folly-xmaxx-comp/internal/pkg/payouts"
+	"nt-folly-xmaxx-comp/internal/pkg/utils"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+// payoutBalancesCSV downloads the outstanding payout balances of an event as CSV.
+func payoutBalancesCSV(conn *pgxpool.Pool, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		eventID := globalid.Decode(globalid.Event, chi.URLParam(r, "eventID"))
+		if !utils.IsValidID(eventID) {
+			http.Error(w, "Event not found", http.StatusNotFound)
+			return
+		}
+		balances, err := payouts.Balances(r.Context(), conn, eventID)
+		if err != nil {
+			log.Error("unable to query payout balances",
+				zap.String("reqID", middleware.GetReqID(r.Context())),
+				zap.Error(err),
+			)
+			http.Error(w, "There was a problem with the server, please try again.", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"payouts-%s.csv\"", eventID))
+		err = payouts.WriteBalancesCSV(w, balances)
+		if err != nil {
+			log.Error("unable to write payout balances",
+				zap.String("reqID", middleware.GetReqID(r.Context())),
+				zap.Error(err),
+			)
+		}
+	}
+}
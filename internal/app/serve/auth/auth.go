@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"nt-folly-xmaxx-comp/internal/pkg/apikeys"
+	"strings"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+type contextKey string
+
+const key = contextKey("apiKey")
+
+// Middleware checks the API key sent as a bearer token (Authorization: Bearer <key>).
+// Requests without a key carry on as the public, requests with an invalid or revoked key are rejected.
+func Middleware(conn *pgxpool.Pool, log *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			token := strings.TrimPrefix(header, "Bearer ")
+			apiKey, err := apikeys.Verify(r.Context(), conn, token)
+			if errors.Is(err, apikeys.ErrInvalidKey) {
+				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				log.Error("unable to verify api key",
+					zap.String("reqID", middleware.GetReqID(r.Context())),
+					zap.Error(err),
+				)
+				http.Error(w, "There was a problem with the server, please try again.", http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), key, apiKey)))
+		})
+	}
+}
+
+// GetAPIKey returns the API key the request was made with (nil for public requests).
+func GetAPIKey(ctx context.Context) *apikeys.Key {
+	apiKey, _ := ctx.Value(key).(*apikeys.Key)
+	return apiKey
+}
+
+// IsAdmin checks whether the request was made by an admin.
+func IsAdmin(ctx context.Context) bool {
+	return GetAPIKey(ctx) != nil
+}
+
+// RequireAdmin rejects the requests made without an API key, it guards the REST routes only admins can use.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsAdmin(r.Context()) {
+			http.Error(w, "API key required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"nt-folly-xmaxx-comp/internal/app/serve/pubsub"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "folly",
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Number of result cache lookups, by kind and outcome (hit or miss).",
+	}, []string{"kind", "outcome"})
+
+	cacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "folly",
+		Subsystem: "cache",
+		Name:      "evictions_total",
+		Help:      "Number of results evicted to keep the cache within its size.",
+	})
+
+	cacheInvalidations = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "folly",
+		Subsystem: "cache",
+		Name:      "invalidations_total",
+		Help:      "Number of results dropped because their competition or event changed.",
+	})
+
+	cacheEntries = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "folly",
+		Subsystem: "cache",
+		Name:      "entries",
+		Help:      "Number of results in the cache.",
+	})
+)
+
+// CompetitionTag tags the results that change along with a competition.
+func CompetitionTag(competitionID string) string {
+	return "competition:" + competitionID
+}
+
+// EventTag tags the results that change along with any competition of an event.
+func EventTag(eventID string) string {
+	return "event:" + eventID
+}
+
+// entry is a cached result.
+type entry struct {
+	key       string
+	value     interface{}
+	tags      []string
+	expiresAt time.Time
+}
+
+// Cache holds resolved results (e.g. leaderboards) until their competition or event changes.
+// It's bounded by the number of entries, the least recently used ones are evicted first.
+// Entries also expire after a while, in case a notification was missed while the broker was reconnecting.
+type Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	order      *list.List
+	entries    map[string]*list.Element
+	tags       map[string]map[string]struct{}
+	// generation is bumped on every invalidation, results loaded before then may already be stale
+	generation uint64
+}
+
+// New creates a cache, it's disabled when maxEntries isn't positive.
+func New(maxEntries int, ttl time.Duration) *Cache {
+	return &Cache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		order:      list.New(),
+		entries:    map[string]*list.Element{},
+		tags:       map[string]map[string]struct{}{},
+	}
+}
+
+// Get finds a result of a kind (e.g. "standings") by its key.
+// The result is shared between requests, so it must not be modified.
+func (c *Cache) Get(kind string, key string) (interface{}, bool) {
+	if c.maxEntries <= 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[kind+"::"+key]
+	if ok && time.Now().After(el.Value.(*entry).expiresAt) {
+		c.remove(el)
+		cacheEntries.Set(float64(c.order.Len()))
+		ok = false
+	}
+	if !ok {
+		cacheRequests.WithLabelValues(kind, "miss").Inc()
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	cacheRequests.WithLabelValues(kind, "hit").Inc()
+	return el.Value.(*entry).value, true
+}
+
+// Generation is taken before loading a result, so Set can tell whether it was invalidated while loading.
+func (c *Cache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// Set stores a result of a kind loaded since the generation, it's dropped once any of its tags is invalidated.
+// Results are skipped if anything was invalidated while they were loading.
+func (c *Cache) Set(kind string, key string, generation uint64, value interface{}, tags ...string) {
+	if c.maxEntries <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	key = kind + "::" + key
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	el := c.order.PushFront(&entry{
+		key:       key,
+		value:     value,
+		tags:      tags,
+		expiresAt: time.Now().Add(c.ttl),
+	})
+	c.entries[key] = el
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = map[string]struct{}{}
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+		cacheEvictions.Inc()
+	}
+	cacheEntries.Set(float64(c.order.Len()))
+}
+
+// Invalidate drops the results with any of the tags.
+func (c *Cache) Invalidate(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if el, ok := c.entries[key]; ok {
+				c.remove(el)
+				cacheInvalidations.Inc()
+			}
+		}
+	}
+	cacheEntries.Set(float64(c.order.Len()))
+}
+
+// remove drops an entry along with its tag references, the lock must be held.
+func (c *Cache) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	c.order.Remove(el)
+	delete(c.entries, e.key)
+	for _, tag := range e.tags {
+		delete(c.tags[tag], e.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+// Listen invalidates the results of the competitions (and their events) updated by the collector or the admin changes,
+// until the context is done.
+func (c *Cache) Listen(ctx context.Context, broker *pubsub.Broker, log *zap.Logger) {
+	payloads := broker.Subscribe(ctx, notify.ChannelCompetitionUpdated)
+	for payload := range payloads {
+		update := notify.CompetitionUpdate{}
+		err := json.Unmarshal([]byte(payload), &update)
+		if err != nil {
+			log.Error("unable to decode competition update", zap.Error(err))
+			continue
+		}
+		c.Invalidate(CompetitionTag(update.CompetitionID), EventTag(update.EventID))
+	}
+}
@@ -12,7 +12,7 @@ import (
 // CompetitionLeaderboardLoaderConfig captures the config to create a new CompetitionLeaderboardLoader
 type CompetitionLeaderboardLoaderConfig struct {
 	// Fetch is a method that provides the data for the loader
-	Fetch func(keys []string) ([][]*gqlmodels.CompetitionUser, []error)
+	Fetch func(keys []LeaderboardKey) ([]*gqlmodels.CompetitionUserConnection, []error)
 
 	// Wait is how long wait before sending a batch
 	Wait time.Duration
@@ -33,7 +33,7 @@ func NewCompetitionLeaderboardLoader(config CompetitionLeaderboardLoaderConfig)
 // CompetitionLeaderboardLoader batches and caches requests
 type CompetitionLeaderboardLoader struct {
 	// this method provides the data for the loader
-	fetch func(keys []string) ([][]*gqlmodels.CompetitionUser, []error)
+	fetch func(keys []LeaderboardKey) ([]*gqlmodels.CompetitionUserConnection, []error)
 
 	// how long to done before sending a batch
 	wait time.Duration
@@ -44,7 +44,7 @@ type CompetitionLeaderboardLoader struct {
 	// INTERNAL
 
 	// lazily created cache
-	cache map[string][]*gqlmodels.CompetitionUser
+	cache map[LeaderboardKey]*gqlmodels.CompetitionUserConnection
 
 	// the current batch. keys will continue to be collected until timeout is hit,
 	// then everything will be sent to the fetch method and out to the listeners
@@ -55,26 +55,26 @@ type CompetitionLeaderboardLoader struct {
 }
 
 type competitionLeaderboardLoaderBatch struct {
-	keys    []string
-	data    [][]*gqlmodels.CompetitionUser
+	keys    []LeaderboardKey
+	data    []*gqlmodels.CompetitionUserConnection
 	error   []error
 	closing bool
 	done    chan struct{}
 }
 
-// Load a CompetitionUser by key, batching and caching will be applied automatically
-func (l *CompetitionLeaderboardLoader) Load(key string) ([]*gqlmodels.CompetitionUser, error) {
+// Load a CompetitionUserConnection by key, batching and caching will be applied automatically
+func (l *CompetitionLeaderboardLoader) Load(key LeaderboardKey) (*gqlmodels.CompetitionUserConnection, error) {
 	return l.LoadThunk(key)()
 }
 
-// LoadThunk returns a function that when called will block waiting for a CompetitionUser.
+// LoadThunk returns a function that when called will block waiting for a CompetitionUserConnection.
 // This method should be used if you want one goroutine to make requests to many
 // different data loaders without blocking until the thunk is called.
-func (l *CompetitionLeaderboardLoader) LoadThunk(key string) func() ([]*gqlmodels.CompetitionUser, error) {
+func (l *CompetitionLeaderboardLoader) LoadThunk(key LeaderboardKey) func() (*gqlmodels.CompetitionUserConnection, error) {
 	l.mu.Lock()
 	if it, ok := l.cache[key]; ok {
 		l.mu.Unlock()
-		return func() ([]*gqlmodels.CompetitionUser, error) {
+		return func() (*gqlmodels.CompetitionUserConnection, error) {
 			return it, nil
 		}
 	}
@@ -85,10 +85,10 @@ func (l *CompetitionLeaderboardLoader) LoadThunk(key string) func() ([]*gqlmodel
 	pos := batch.keyIndex(l, key)
 	l.mu.Unlock()
 
-	return func() ([]*gqlmodels.CompetitionUser, error) {
+	return func() (*gqlmodels.CompetitionUserConnection, error) {
 		<-batch.done
 
-		var data []*gqlmodels.CompetitionUser
+		var data *gqlmodels.CompetitionUserConnection
 		if pos < len(batch.data) {
 			data = batch.data[pos]
 		}
@@ -113,73 +113,72 @@ func (l *CompetitionLeaderboardLoader) LoadThunk(key string) func() ([]*gqlmodel
 
 // LoadAll fetches many keys at once. It will be broken into appropriate sized
 // sub batches depending on how the loader is configured
-func (l *CompetitionLeaderboardLoader) LoadAll(keys []string) ([][]*gqlmodels.CompetitionUser, []error) {
-	results := make([]func() ([]*gqlmodels.CompetitionUser, error), len(keys))
+func (l *CompetitionLeaderboardLoader) LoadAll(keys []LeaderboardKey) ([]*gqlmodels.CompetitionUserConnection, []error) {
+	results := make([]func() (*gqlmodels.CompetitionUserConnection, error), len(keys))
 
 	for i, key := range keys {
 		results[i] = l.LoadThunk(key)
 	}
 
-	competitionUsers := make([][]*gqlmodels.CompetitionUser, len(keys))
+	competitionUserConnections := make([]*gqlmodels.CompetitionUserConnection, len(keys))
 	errors := make([]error, len(keys))
 	for i, thunk := range results {
-		competitionUsers[i], errors[i] = thunk()
+		competitionUserConnections[i], errors[i] = thunk()
 	}
-	return competitionUsers, errors
+	return competitionUserConnections, errors
 }
 
-// LoadAllThunk returns a function that when called will block waiting for a CompetitionUsers.
+// LoadAllThunk returns a function that when called will block waiting for a CompetitionUserConnections.
 // This method should be used if you want one goroutine to make requests to many
 // different data loaders without blocking until the thunk is called.
-func (l *CompetitionLeaderboardLoader) LoadAllThunk(keys []string) func() ([][]*gqlmodels.CompetitionUser, []error) {
-	results := make([]func() ([]*gqlmodels.CompetitionUser, error), len(keys))
+func (l *CompetitionLeaderboardLoader) LoadAllThunk(keys []LeaderboardKey) func() ([]*gqlmodels.CompetitionUserConnection, []error) {
+	results := make([]func() (*gqlmodels.CompetitionUserConnection, error), len(keys))
 	for i, key := range keys {
 		results[i] = l.LoadThunk(key)
 	}
-	return func() ([][]*gqlmodels.CompetitionUser, []error) {
-		competitionUsers := make([][]*gqlmodels.CompetitionUser, len(keys))
+	return func() ([]*gqlmodels.CompetitionUserConnection, []error) {
+		competitionUserConnections := make([]*gqlmodels.CompetitionUserConnection, len(keys))
 		errors := make([]error, len(keys))
 		for i, thunk := range results {
-			competitionUsers[i], errors[i] = thunk()
+			competitionUserConnections[i], errors[i] = thunk()
 		}
-		return competitionUsers, errors
+		return competitionUserConnections, errors
 	}
 }
 
 // Prime the cache with the provided key and value. If the key already exists, no change is made
 // and false is returned.
 // (To forcefully prime the cache, clear the key first with loader.clear(key).prime(key, value).)
-func (l *CompetitionLeaderboardLoader) Prime(key string, value []*gqlmodels.CompetitionUser) bool {
+func (l *CompetitionLeaderboardLoader) Prime(key LeaderboardKey, value *gqlmodels.CompetitionUserConnection) bool {
 	l.mu.Lock()
 	var found bool
 	if _, found = l.cache[key]; !found {
 		// make a copy when writing to the cache, its easy to pass a pointer in from a loop var
 		// and end up with the whole cache pointing to the same value.
-		cpy := make([]*gqlmodels.CompetitionUser, len(value))
-		copy(cpy, value)
-		l.unsafeSet(key, cpy)
+		cpy := *value
+		l.unsafeSet(key, &cpy)
 	}
 	l.mu.Unlock()
 	return !found
 }
 
 // Clear the value at key from the cache, if it exists
-func (l *CompetitionLeaderboardLoader) Clear(key string) {
+func (l *CompetitionLeaderboardLoader) Clear(key LeaderboardKey) {
 	l.mu.Lock()
 	delete(l.cache, key)
 	l.mu.Unlock()
 }
 
-func (l *CompetitionLeaderboardLoader) unsafeSet(key string, value []*gqlmodels.CompetitionUser) {
+func (l *CompetitionLeaderboardLoader) unsafeSet(key LeaderboardKey, value *gqlmodels.CompetitionUserConnection) {
 	if l.cache == nil {
-		l.cache = map[string][]*gqlmodels.CompetitionUser{}
+		l.cache = map[LeaderboardKey]*gqlmodels.CompetitionUserConnection{}
 	}
 	l.cache[key] = value
 }
 
 // keyIndex will return the location of the key in the batch, if its not found
 // it will add the key to the batch
-func (b *competitionLeaderboardLoaderBatch) keyIndex(l *CompetitionLeaderboardLoader, key string) int {
+func (b *competitionLeaderboardLoaderBatch) keyIndex(l *CompetitionLeaderboardLoader, key LeaderboardKey) int {
 	for i, existingKey := range b.keys {
 		if key == existingKey {
 			return i
@@ -0,0 +1,224 @@
+// Code generated by github.com/vektah/dataloaden, DO NOT EDIT.
+
+package dataloaders
+
+import (
+	"sync"
+	"time"
+
+	"nt-folly-xmaxx-comp/internal/app/serve/graphql/gqlmodels"
+)
+
+// CompetitionLoaderConfig captures the config to create a new CompetitionLoader
+type CompetitionLoaderConfig struct {
+	// Fetch is a method that provides the data for the loader
+	Fetch func(keys []string) ([]*gqlmodels.Competition, []error)
+
+	// Wait is how long wait before sending a batch
+	Wait time.Duration
+
+	// MaxBatch will limit the maximum number of keys to send in one batch, 0 = not limit
+	MaxBatch int
+}
+
+// NewCompetitionLoader creates a new CompetitionLoader given a fetch, wait, and maxBatch
+func NewCompetitionLoader(config CompetitionLoaderConfig) *CompetitionLoader {
+	return &CompetitionLoader{
+		fetch:    config.Fetch,
+		wait:     config.Wait,
+		maxBatch: config.MaxBatch,
+	}
+}
+
+// CompetitionLoader batches and caches requests
+type CompetitionLoader struct {
+	// this method provides the data for the loader
+	fetch func(keys []string) ([]*gqlmodels.Competition, []error)
+
+	// how long to done before sending a batch
+	wait time.Duration
+
+	// this will limit the maximum number of keys to send in one batch, 0 = no limit
+	maxBatch int
+
+	// INTERNAL
+
+	// lazily created cache
+	cache map[string]*gqlmodels.Competition
+
+	// the current batch. keys will continue to be collected until timeout is hit,
+	// then everything will be sent to the fetch method and out to the listeners
+	batch *competitionLoaderBatch
+
+	// mutex to prevent races
+	mu sync.Mutex
+}
+
+type competitionLoaderBatch struct {
+	keys    []string
+	data    []*gqlmodels.Competition
+	error   []error
+	closing bool
+	done    chan struct{}
+}
+
+// Load a Competition by key, batching and caching will be applied automatically
+func (l *CompetitionLoader) Load(key string) (*gqlmodels.Competition, error) {
+	return l.LoadThunk(key)()
+}
+
+// LoadThunk returns a function that when called will block waiting for a Competition.
+// This method should be used if you want one goroutine to make requests to many
+// different data loaders without blocking until the thunk is called.
+func (l *CompetitionLoader) LoadThunk(key string) func() (*gqlmodels.Competition, error) {
+	l.mu.Lock()
+	if it, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return func() (*gqlmodels.Competition, error) {
+			return it, nil
+		}
+	}
+	if l.batch == nil {
+		l.batch = &competitionLoaderBatch{done: make(chan struct{})}
+	}
+	batch := l.batch
+	pos := batch.keyIndex(l, key)
+	l.mu.Unlock()
+
+	return func() (*gqlmodels.Competition, error) {
+		<-batch.done
+
+		var data *gqlmodels.Competition
+		if pos < len(batch.data) {
+			data = batch.data[pos]
+		}
+
+		var err error
+		// its convenient to be able to return a single error for everything
+		if len(batch.error) == 1 {
+			err = batch.error[0]
+		} else if batch.error != nil {
+			err = batch.error[pos]
+		}
+
+		if err == nil {
+			l.mu.Lock()
+			l.unsafeSet(key, data)
+			l.mu.Unlock()
+		}
+
+		return data, err
+	}
+}
+
+// LoadAll fetches many keys at once. It will be broken into appropriate sized
+// sub batches depending on how the loader is configured
+func (l *CompetitionLoader) LoadAll(keys []string) ([]*gqlmodels.Competition, []error) {
+	results := make([]func() (*gqlmodels.Competition, error), len(keys))
+
+	for i, key := range keys {
+		results[i] = l.LoadThunk(key)
+	}
+
+	competitions := make([]*gqlmodels.Competition, len(keys))
+	errors := make([]error, len(keys))
+	for i, thunk := range results {
+		competitions[i], errors[i] = thunk()
+	}
+	return competitions, errors
+}
+
+// LoadAllThunk returns a function that when called will block waiting for a Competitions.
+// This method should be used if you want one goroutine to make requests to many
+// different data loaders without blocking until the thunk is called.
+func (l *CompetitionLoader) LoadAllThunk(keys []string) func() ([]*gqlmodels.Competition, []error) {
+	results := make([]func() (*gqlmodels.Competition, error), len(keys))
+	for i, key := range keys {
+		results[i] = l.LoadThunk(key)
+	}
+	return func() ([]*gqlmodels.Competition, []error) {
+		competitions := make([]*gqlmodels.Competition, len(keys))
+		errors := make([]error, len(keys))
+		for i, thunk := range results {
+			competitions[i], errors[i] = thunk()
+		}
+		return competitions, errors
+	}
+}
+
+// Prime the cache with the provided key and value. If the key already exists, no change is made
+// and false is returned.
+// (To forcefully prime the cache, clear the key first with loader.clear(key).prime(key, value).)
+func (l *CompetitionLoader) Prime(key string, value *gqlmodels.Competition) bool {
+	l.mu.Lock()
+	var found bool
+	if _, found = l.cache[key]; !found {
+		// make a copy when writing to the cache, its easy to pass a pointer in from a loop var
+		// and end up with the whole cache pointing to the same value.
+		cpy := *value
+		l.unsafeSet(key, &cpy)
+	}
+	l.mu.Unlock()
+	return !found
+}
+
+// Clear the value at key from the cache, if it exists
+func (l *CompetitionLoader) Clear(key string) {
+	l.mu.Lock()
+	delete(l.cache, key)
+	l.mu.Unlock()
+}
+
+func (l *CompetitionLoader) unsafeSet(key string, value *gqlmodels.Competition) {
+	if l.cache == nil {
+		l.cache = map[string]*gqlmodels.Competition{}
+	}
+	l.cache[key] = value
+}
+
+// keyIndex will return the location of the key in the batch, if its not found
+// it will add the key to the batch
+func (b *competitionLoaderBatch) keyIndex(l *CompetitionLoader, key string) int {
+	for i, existingKey := range b.keys {
+		if key == existingKey {
+			return i
+		}
+	}
+
+	pos := len(b.keys)
+	b.keys = append(b.keys, key)
+	if pos == 0 {
+		go b.startTimer(l)
+	}
+
+	if l.maxBatch != 0 && pos >= l.maxBatch-1 {
+		if !b.closing {
+			b.closing = true
+			l.batch = nil
+			go b.end(l)
+		}
+	}
+
+	return pos
+}
+
+func (b *competitionLoaderBatch) startTimer(l *CompetitionLoader) {
+	time.Sleep(l.wait)
+	l.mu.Lock()
+
+	// we must have hit a batch limit and are already finalizing this batch
+	if b.closing {
+		l.mu.Unlock()
+		return
+	}
+
+	l.batch = nil
+	l.mu.Unlock()
+
+	b.end(l)
+}
+
+func (b *competitionLoaderBatch) end(l *CompetitionLoader) {
+	b.data, b.error = l.fetch(b.keys)
+	close(b.done)
+}
@@ -5,28 +5,55 @@ import (
 	"fmt"
 	"net/http"
 	"nt-folly-xmaxx-comp/internal/app/serve/graphql/gqlmodels"
+	"nt-folly-xmaxx-comp/internal/app/serve/metrics"
+	"nt-folly-xmaxx-comp/internal/app/serve/pagination"
 	"nt-folly-xmaxx-comp/internal/pkg/db"
+	"nt-folly-xmaxx-comp/internal/pkg/reports"
+	"strings"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/doug-martin/goqu/v9"
+	"github.com/gorilla/websocket"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type contextKey string
 
-const key = contextKey("dataloaders")
+const (
+	key     = contextKey("dataloaders")
+	connKey = contextKey("dataloadersConn")
+)
 
 // Loaders hold references to the individual dataloaders.
 type Loaders struct {
+	UserByID                   *UserLoader
+	EventByID                  *EventLoader
+	CompetitionByID            *CompetitionLoader
+	CompetitionUserByID        *CompetitionUserLoader
 	UserTotalPointsByID        *UserTotalPointsLoader
+	UserAdjustmentsByID        *UserAdjustmentsLoader
+	EventLeaderboardByID       *EventLeaderboardLoader
+	EventPayoutBalancesByID    *EventPayoutBalancesLoader
+	CompetitionCategoriesByID  *CompetitionCategoriesLoader
 	CompetitionLeaderboardByID *CompetitionLeaderboardLoader
+	UserResultsByID            *UserResultsLoader
 }
 
 // newLoaderse initializes individual loaders.
 func newLoaders(ctx context.Context, conn *pgxpool.Pool) *Loaders {
 	return &Loaders{
+		UserByID:                   userLoader(conn),
+		EventByID:                  eventLoader(conn),
+		CompetitionByID:            competitionLoader(conn),
+		CompetitionUserByID:        competitionUserLoader(conn),
 		UserTotalPointsByID:        userTotalPointLoader(conn),
+		UserAdjustmentsByID:        userAdjustmentsLoader(conn),
+		EventLeaderboardByID:       eventLeaderboardLoader(conn),
+		EventPayoutBalancesByID:    eventPayoutBalancesLoader(conn),
+		CompetitionCategoriesByID:  competitionCategoriesLoader(conn),
 		CompetitionLeaderboardByID: competitionLeaderboardLoader(conn),
+		UserResultsByID:            userResultsLoader(conn),
 	}
 }
 
@@ -36,10 +63,16 @@ func GetLoadersFromContext(ctx context.Context) *Loaders {
 }
 
 // Middleware stores Loaders as a requested-scored context value.
+// Websocket connections get theirs from AroundOperations instead, one per operation.
 func Middleware(conn *pgxpool.Pool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
+			if websocket.IsWebSocketUpgrade(r) {
+				r = r.WithContext(context.WithValue(ctx, connKey, conn))
+				next.ServeHTTP(w, r)
+				return
+			}
 			loaders := newLoaders(ctx, conn)
 			augmentedCtx := context.WithValue(ctx, key, loaders)
 			r = r.WithContext(augmentedCtx)
@@ -48,28 +81,33 @@ func Middleware(conn *pgxpool.Pool) func(http.Handler) http.Handler {
 	}
 }
 
+// AroundOperations stores Loaders for each operation of a websocket connection.
+// They're renewed before every response, so the cache doesn't go stale between subscription updates.
+func AroundOperations(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	conn, ok := ctx.Value(connKey).(*pgxpool.Pool)
+	if !ok {
+		return next(ctx)
+	}
+	loaders := newLoaders(ctx, conn)
+	responses := next(context.WithValue(ctx, key, loaders))
+	return func(ctx context.Context) *graphql.Response {
+		*loaders = *newLoaders(ctx, conn)
+		return responses(ctx)
+	}
+}
+
 ///////////////////
 //  Dataloaders  //
 ///////////////////
 
-// competitionLeaderboardLoader fetches the leaderboard result for the following resolver:
-// * competition -> leaderboard
-func competitionLeaderboardLoader(conn *pgxpool.Pool) *CompetitionLeaderboardLoader {
-	type competitionLeaderboardResult struct {
-		competitionID  string
+// eventLeaderboardLoader fetches the overall event results (including the competition adjustments) for the following resolver:
+// * event -> leaderboard
+func eventLeaderboardLoader(conn *pgxpool.Pool) *EventLeaderboardLoader {
+	type eventLeaderboardResult struct {
+		eventID        string
 		userID         string
-		grind          int
-		grindRank      int
-		grindReward    int
-		speed          float64
-		speedRank      int
-		speedReward    int
-		point          int
-		pointRank      int
-		pointReward    int
-		accuracy       float64
-		accuracyRank   int
-		accuracyReward int
+		rank           int
+		totalPoints    int
 		username       string
 		displayName    string
 		membershipType string
@@ -77,30 +115,21 @@ func competitionLeaderboardLoader(conn *pgxpool.Pool) *CompetitionLeaderboardLoa
 		createdAt      time.Time
 		updatedAt      time.Time
 	}
-	return NewCompetitionLeaderboardLoader(
-		CompetitionLeaderboardLoaderConfig{
-			Fetch: func(ids []string) ([][]*gqlmodels.CompetitionUser, []error) {
+	return NewEventLeaderboardLoader(
+		EventLeaderboardLoaderConfig{
+			Fetch: func(ids []string) ([][]*gqlmodels.EventUser, []error) {
+				metrics.ObserveBatch("eventLeaderboard", len(ids))
 				if len(ids) == 0 {
-					return [][]*gqlmodels.CompetitionUser{}, nil
+					return [][]*gqlmodels.EventUser{}, nil
 				}
 
 				// Query leaderboard data
 				q, args, err := db.QueryBuilder.
 					Select(
-						goqu.L("r.competition_id"),
+						goqu.L("c.event_id"),
 						goqu.L("r.user_id"),
-						goqu.L("r.grind"),
-						goqu.L("r.grind_rank"),
-						goqu.L("r.grind_reward"),
-						goqu.L("r.accuracy"),
-						goqu.L("r.accuracy_rank"),
-						goqu.L("r.accuracy_reward"),
-						goqu.L("r.speed"),
-						goqu.L("r.speed_rank"),
-						goqu.L("r.speed_reward"),
-						goqu.L("r.point"),
-						goqu.L("r.point_rank"),
-						goqu.L("r.point_reward"),
+						goqu.L("rank() OVER (PARTITION BY c.event_id ORDER BY SUM(r.points) DESC)"),
+						goqu.L("SUM(r.points)"),
 						goqu.L("u.username"),
 						goqu.L("u.display_name"),
 						goqu.L("u.membership_type"),
@@ -108,73 +137,353 @@ func competitionLeaderboardLoader(conn *pgxpool.Pool) *CompetitionLeaderboardLoa
 						goqu.L("u.created_at"),
 						goqu.L("u.updated_at"),
 					).
-					From(goqu.T("competition_results").As("r")).
+					From(goqu.T("user_points").As("r")).
+					InnerJoin(
+						goqu.L("competitions c"),
+						goqu.On(goqu.L("c.id = r.competition_id")),
+					).
 					InnerJoin(
 						goqu.L("users u"),
 						goqu.On(goqu.L("u.id = r.user_id")),
 					).
-					Where(goqu.L("r.competition_id").In(ids)).
+					Where(goqu.L("c.event_id").In(ids)).
+					GroupBy(goqu.L("c.event_id"), goqu.L("r.user_id"), goqu.L("u.id")).
+					Order(goqu.L("c.event_id").Asc(), goqu.L("SUM(r.points)").Desc(), goqu.L("u.username").Asc()).
 					ToSQL()
 				if err != nil {
-					return nil, []error{fmt.Errorf("failed to build competition leaderboard: %w", err)}
+					return nil, []error{fmt.Errorf("failed to build event leaderboard: %w", err)}
 				}
 				rows, err := conn.Query(context.Background(), q, args...)
 				if err != nil {
-					return nil, []error{fmt.Errorf("failed to query competition leaderboard: %w", err)}
+					return nil, []error{fmt.Errorf("failed to query event leaderboard: %w", err)}
 				}
 				defer rows.Close()
-				results := []competitionLeaderboardResult{}
+				results := []eventLeaderboardResult{}
 				for rows.Next() {
-					var row competitionLeaderboardResult
+					var row eventLeaderboardResult
 					err := rows.Scan(
-						&row.competitionID, &row.userID, &row.grind, &row.grindRank, &row.grindReward, &row.accuracy,
-						&row.accuracyRank, &row.accuracyReward, &row.speed, &row.speedRank, &row.speedReward, &row.point,
-						&row.pointRank, &row.pointReward, &row.username, &row.displayName, &row.membershipType, &row.status, &row.createdAt, &row.updatedAt,
+						&row.eventID, &row.userID, &row.rank, &row.totalPoints, &row.username,
+						&row.displayName, &row.membershipType, &row.status, &row.createdAt, &row.updatedAt,
 					)
 					if err != nil {
-						return nil, []error{fmt.Errorf("failed to scan user total points: %w", err)}
+						return nil, []error{fmt.Errorf("failed to scan event leaderboard: %w", err)}
 					}
 					results = append(results, row)
 				}
 				err = rows.Err()
 				if err != nil {
-					return nil, []error{fmt.Errorf("an error occurred while scanning user total points: %w", err)}
+					return nil, []error{fmt.Errorf("an error occurred while scanning event leaderboard: %w", err)}
 				}
 
 				// Return output
-				output := [][]*gqlmodels.CompetitionUser{}
+				output := [][]*gqlmodels.EventUser{}
 				for _, key := range ids {
-					rows := []*gqlmodels.CompetitionUser{}
+					rows := []*gqlmodels.EventUser{}
 					for _, row := range results {
-						if row.competitionID == key {
-							if row.displayName == "" {
-								row.displayName = row.username
+						if row.eventID != key {
+							continue
+						}
+						if row.displayName == "" {
+							row.displayName = row.username
+						}
+						rows = append(rows, &gqlmodels.EventUser{
+							ID:          fmt.Sprintf("%s::%s", row.eventID, row.userID),
+							Rank:        row.rank,
+							TotalPoints: row.totalPoints,
+							User: &gqlmodels.User{
+								ID:             row.userID,
+								Username:       row.username,
+								DisplayName:    row.displayName,
+								MembershipType: gqlmodels.MembershipType(row.membershipType),
+								Status:         gqlmodels.UserStatus(row.status),
+								CreatedAt:      row.createdAt,
+								UpdatedAt:      row.updatedAt,
+							},
+						})
+					}
+					output = append(output, rows)
+				}
+				return output, nil
+			},
+			Wait:     1 * time.Millisecond,
+			MaxBatch: 100,
+		},
+	)
+}
+
+// eventPayoutBalancesLoader fetches the payout balances and payouts for the following resolver:
+// * event -> payoutBalances
+func eventPayoutBalancesLoader(conn *pgxpool.Pool) *EventPayoutBalancesLoader {
+	type eventPayoutBalanceResult struct {
+		eventID        string
+		userID         string
+		points         int
+		earned         int
+		pending        int
+		sent           int
+		confirmed      int
+		outstanding    int
+		username       string
+		displayName    string
+		membershipType string
+		status         string
+		createdAt      time.Time
+		updatedAt      time.Time
+	}
+	return NewEventPayoutBalancesLoader(
+		EventPayoutBalancesLoaderConfig{
+			Fetch: func(ids []string) ([][]*gqlmodels.PayoutBalance, []error) {
+				metrics.ObserveBatch("eventPayoutBalances", len(ids))
+				if len(ids) == 0 {
+					return [][]*gqlmodels.PayoutBalance{}, nil
+				}
+
+				// Query balances
+				q, args, err := db.QueryBuilder.
+					Select(
+						goqu.L("b.event_id"),
+						goqu.L("b.user_id"),
+						goqu.L("b.points"),
+						goqu.L("b.earned"),
+						goqu.L("b.pending"),
+						goqu.L("b.sent"),
+						goqu.L("b.confirmed"),
+						goqu.L("b.outstanding"),
+						goqu.L("u.username"),
+						goqu.L("u.display_name"),
+						goqu.L("u.membership_type"),
+						goqu.L("u.status"),
+						goqu.L("u.created_at"),
+						goqu.L("u.updated_at"),
+					).
+					From(goqu.T("event_payout_balances").As("b")).
+					InnerJoin(
+						goqu.L("users u"),
+						goqu.On(goqu.L("u.id = b.user_id")),
+					).
+					Where(goqu.L("b.event_id").In(ids)).
+					Order(goqu.L("b.outstanding").Desc(), goqu.L("u.username").Asc()).
+					ToSQL()
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to build payout balances: %w", err)}
+				}
+				rows, err := conn.Query(context.Background(), q, args...)
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to query payout balances: %w", err)}
+				}
+				defer rows.Close()
+				results := []eventPayoutBalanceResult{}
+				for rows.Next() {
+					var row eventPayoutBalanceResult
+					err := rows.Scan(
+						&row.eventID, &row.userID, &row.points, &row.earned, &row.pending, &row.sent, &row.confirmed, &row.outstanding,
+						&row.username, &row.displayName, &row.membershipType, &row.status, &row.createdAt, &row.updatedAt,
+					)
+					if err != nil {
+						return nil, []error{fmt.Errorf("failed to scan payout balances: %w", err)}
+					}
+					results = append(results, row)
+				}
+				err = rows.Err()
+				if err != nil {
+					return nil, []error{fmt.Errorf("an error occurred while scanning payout balances: %w", err)}
+				}
+
+				// Query payouts
+				q, args, err = db.QueryBuilder.
+					Select(
+						goqu.L("p.id"),
+						goqu.L("p.event_id"),
+						goqu.L("p.user_id"),
+						goqu.L("p.amount"),
+						goqu.L("p.status"),
+						goqu.L("p.note"),
+						goqu.L("p.sent_at"),
+						goqu.L("p.confirmed_at"),
+						goqu.L("p.created_at"),
+						goqu.L("p.updated_at"),
+					).
+					From(goqu.T("payouts").As("p")).
+					Where(
+						goqu.L("p.event_id").In(ids),
+						goqu.L("p.deleted_at IS NULL"),
+					).
+					Order(goqu.L("p.created_at").Asc()).
+					ToSQL()
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to build payouts: %w", err)}
+				}
+				payoutRows, err := conn.Query(context.Background(), q, args...)
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to query payouts: %w", err)}
+				}
+				defer payoutRows.Close()
+				payouts := []*gqlmodels.Payout{}
+				for payoutRows.Next() {
+					row := &gqlmodels.Payout{}
+					err := payoutRows.Scan(
+						&row.ID, &row.EventID, &row.UserID, &row.Amount, &row.Status, &row.Note,
+						&row.SentAt, &row.ConfirmedAt, &row.CreatedAt, &row.UpdatedAt,
+					)
+					if err != nil {
+						return nil, []error{fmt.Errorf("failed to scan payouts: %w", err)}
+					}
+					payouts = append(payouts, row)
+				}
+				err = payoutRows.Err()
+				if err != nil {
+					return nil, []error{fmt.Errorf("an error occurred while scanning payouts: %w", err)}
+				}
+
+				// Return output
+				output := [][]*gqlmodels.PayoutBalance{}
+				for _, key := range ids {
+					rows := []*gqlmodels.PayoutBalance{}
+					for _, row := range results {
+						if row.eventID != key {
+							continue
+						}
+						if row.displayName == "" {
+							row.displayName = row.username
+						}
+						balance := &gqlmodels.PayoutBalance{
+							ID:          fmt.Sprintf("%s::%s", row.eventID, row.userID),
+							Points:      row.points,
+							Earned:      row.earned,
+							Pending:     row.pending,
+							Sent:        row.sent,
+							Confirmed:   row.confirmed,
+							Outstanding: row.outstanding,
+							Payouts:     []*gqlmodels.Payout{},
+							User: &gqlmodels.User{
+								ID:             row.userID,
+								Username:       row.username,
+								DisplayName:    row.displayName,
+								MembershipType: gqlmodels.MembershipType(row.membershipType),
+								Status:         gqlmodels.UserStatus(row.status),
+								CreatedAt:      row.createdAt,
+								UpdatedAt:      row.updatedAt,
+							},
+						}
+						for _, payout := range payouts {
+							if payout.EventID == row.eventID && payout.UserID == row.userID {
+								balance.Payouts = append(balance.Payouts, payout)
 							}
-							rows = append(rows, &gqlmodels.CompetitionUser{
-								ID:             fmt.Sprintf("%s::%s", row.competitionID, row.userID),
-								GrindScore:     row.grind,
-								GrindRank:      row.grindRank,
-								GrindReward:    row.grindReward,
-								SpeedScore:     row.speed,
-								SpeedRank:      row.speedRank,
-								SpeedReward:    row.speedReward,
-								PointScore:     row.point,
-								PointRank:      row.pointRank,
-								PointReward:    row.pointReward,
-								AccuracyScore:  row.accuracy,
-								AccuracyRank:   row.accuracyRank,
-								AccuracyReward: row.accuracyReward,
-								User: &gqlmodels.User{
-									ID:             row.userID,
-									Username:       row.username,
-									DisplayName:    row.displayName,
-									MembershipType: gqlmodels.MembershipType(row.membershipType),
-									Status:         gqlmodels.UserStatus(row.status),
-									CreatedAt:      row.createdAt,
-									UpdatedAt:      row.updatedAt,
-								},
+						}
+						rows = append(rows, balance)
+					}
+					output = append(output, rows)
+				}
+				return output, nil
+			},
+			Wait:     1 * time.Millisecond,
+			MaxBatch: 100,
+		},
+	)
+}
+
+// competitionCategoriesLoader fetches the categories for the following resolver:
+// * competition -> categories
+func competitionCategoriesLoader(conn *pgxpool.Pool) *CompetitionCategoriesLoader {
+	type competitionCategoryResult struct {
+		competitionID       string
+		categoryID          string
+		name                string
+		metric              string
+		sortDirection       string
+		tieBreaker          *string
+		tieBreakerDirection string
+		minRaces            int
+		minSecs             int
+		minTyped            int
+		rewards             []int
+	}
+	return NewCompetitionCategoriesLoader(
+		CompetitionCategoriesLoaderConfig{
+			Fetch: func(ids []string) ([][]*gqlmodels.CompetitionCategory, []error) {
+				metrics.ObserveBatch("competitionCategories", len(ids))
+				if len(ids) == 0 {
+					return [][]*gqlmodels.CompetitionCategory{}, nil
+				}
+
+				// Query competition categories
+				q, args, err := db.QueryBuilder.
+					Select(
+						goqu.L("cc.competition_id"),
+						goqu.L("cat.id"),
+						goqu.L("cat.name"),
+						goqu.L("cat.metric"),
+						goqu.L("cat.sort_direction"),
+						goqu.L("cat.tie_breaker"),
+						goqu.L("cat.tie_breaker_direction"),
+						goqu.L("cat.min_races"),
+						goqu.L("cat.min_secs"),
+						goqu.L("cat.min_typed"),
+						goqu.L("cc.rewards"),
+					).
+					From(goqu.T("competition_categories").As("cc")).
+					InnerJoin(
+						goqu.L("categories cat"),
+						goqu.On(goqu.L("cat.id = cc.category_id AND cat.deleted_at IS NULL")),
+					).
+					Where(goqu.L("cc.competition_id").In(ids)).
+					Order(goqu.L("cat.position").Asc(), goqu.L("cat.name").Asc()).
+					ToSQL()
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to build competition categories: %w", err)}
+				}
+				rows, err := conn.Query(context.Background(), q, args...)
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to query competition categories: %w", err)}
+				}
+				defer rows.Close()
+				results := []competitionCategoryResult{}
+				for rows.Next() {
+					var row competitionCategoryResult
+					err := rows.Scan(
+						&row.competitionID, &row.categoryID, &row.name, &row.metric, &row.sortDirection,
+						&row.tieBreaker, &row.tieBreakerDirection, &row.minRaces, &row.minSecs, &row.minTyped, &row.rewards,
+					)
+					if err != nil {
+						return nil, []error{fmt.Errorf("failed to scan competition categories: %w", err)}
+					}
+					results = append(results, row)
+				}
+				err = rows.Err()
+				if err != nil {
+					return nil, []error{fmt.Errorf("an error occurred while scanning competition categories: %w", err)}
+				}
+
+				// Return output
+				output := [][]*gqlmodels.CompetitionCategory{}
+				for _, key := range ids {
+					rows := []*gqlmodels.CompetitionCategory{}
+					for _, row := range results {
+						if row.competitionID != key {
+							continue
+						}
+						category := &gqlmodels.CompetitionCategory{
+							ID:                  row.categoryID,
+							Name:                row.name,
+							Metric:              gqlmodels.CategoryMetric(row.metric),
+							SortDirection:       gqlmodels.SortDirection(row.sortDirection),
+							TieBreakerDirection: gqlmodels.SortDirection(row.tieBreakerDirection),
+							MinRaces:            row.minRaces,
+							MinSecs:             row.minSecs,
+							MinTyped:            row.minTyped,
+							Rewards:             []*gqlmodels.CompetitionPrize{},
+						}
+						if row.tieBreaker != nil {
+							tieBreaker := gqlmodels.CategoryMetric(*row.tieBreaker)
+							category.TieBreaker = &tieBreaker
+						}
+						for i, points := range row.rewards {
+							category.Rewards = append(category.Rewards, &gqlmodels.CompetitionPrize{
+								Rank:   i + 1,
+								Points: points,
 							})
 						}
+						rows = append(rows, category)
 					}
 					output = append(output, rows)
 				}
@@ -186,6 +495,610 @@ func competitionLeaderboardLoader(conn *pgxpool.Pool) *CompetitionLeaderboardLoa
 	)
 }
 
+// CompetitionLeaderboardKeyset is the order of the competition leaderboards (most points first).
+var CompetitionLeaderboardKeyset = pagination.Keyset{
+	Columns: []pagination.Column{
+		{Expr: "l.points", Name: "p.points", Type: "int"},
+		{Expr: "l.user_id", Name: "p.user_id", Type: "uuid"},
+	},
+	Descending: true,
+}
+
+// CompetitionCategoryLeaderboardKeyset is the order of the competition leaderboards of a category (best rank first, ineligible racers last).
+var CompetitionCategoryLeaderboardKeyset = pagination.Keyset{
+	Columns: []pagination.Column{
+		{Expr: "l.rank", Name: "p.rank", Type: "int"},
+		{Expr: "l.user_id", Name: "p.user_id", Type: "uuid"},
+	},
+}
+
+// getLeaderboardKeyset returns the order of a leaderboard, it matches the racer positions of reports.LeaderboardQuery.
+func getLeaderboardKeyset(key LeaderboardKey) pagination.Keyset {
+	if key.Category != "" {
+		return CompetitionCategoryLeaderboardKeyset
+	}
+	return CompetitionLeaderboardKeyset
+}
+
+// competitionLeaderboardLoader fetches a page of the leaderboard for the following resolver:
+// * competition -> leaderboard
+// Keys with the same page and filters are fetched together. Started competitions use their provisional results.
+func competitionLeaderboardLoader(conn *pgxpool.Pool) *CompetitionLeaderboardLoader {
+	return NewCompetitionLeaderboardLoader(
+		CompetitionLeaderboardLoaderConfig{
+			Fetch: func(keys []LeaderboardKey) ([]*gqlmodels.CompetitionUserConnection, []error) {
+				metrics.ObserveBatch("competitionLeaderboard", len(keys))
+				if len(keys) == 0 {
+					return []*gqlmodels.CompetitionUserConnection{}, nil
+				}
+				output := make([]*gqlmodels.CompetitionUserConnection, len(keys))
+				errs := make([]error, len(keys))
+
+				// Group the competitions requesting the same page and filters
+				filterKeys := map[LeaderboardKey][]int{}
+				for i, key := range keys {
+					filterKeys[key.Filter()] = append(filterKeys[key.Filter()], i)
+				}
+				for filter, indexes := range filterKeys {
+					competitionIDs := []string{}
+					for _, i := range indexes {
+						competitionIDs = append(competitionIDs, keys[i].ID)
+					}
+					pageUsers, err := fetchCompetitionLeaderboardPage(conn, filter, competitionIDs)
+					if err != nil {
+						for _, i := range indexes {
+							errs[i] = err
+						}
+						continue
+					}
+					for _, i := range indexes {
+						output[i] = pageUsers[keys[i].ID]
+					}
+				}
+				return output, errs
+			},
+			Wait:     1 * time.Millisecond,
+			MaxBatch: 100,
+		},
+	)
+}
+
+// fetchCompetitionLeaderboardPage fetches the same leaderboard page of each competition.
+// Without a category, racers are ordered by their points (including adjustments), otherwise by their category rank.
+// The limit only keeps the top racers, the page is then taken out of them.
+func fetchCompetitionLeaderboardPage(conn *pgxpool.Pool, filter LeaderboardKey, competitionIDs []string) (map[string]*gqlmodels.CompetitionUserConnection, error) {
+	type competitionLeaderboardUser struct {
+		competitionID  string
+		userID         string
+		points         int
+		rank           int
+		totalPoints    int
+		username       string
+		displayName    string
+		membershipType string
+		status         string
+		createdAt      time.Time
+		updatedAt      time.Time
+	}
+	type competitionLeaderboardCategory struct {
+		competitionID    string
+		userID           string
+		category         string
+		score            float64
+		eligible         bool
+		ineligibleReason *string
+		rank             *int
+		tied             int
+		reward           int
+	}
+
+	// Filter the racers
+	racerFilter := reports.LeaderboardFilter{
+		Limit:             filter.Limit,
+		IncludeIneligible: filter.IncludeIneligible,
+	}
+	if filter.Category != "" {
+		racerFilter.Category = &filter.Category
+	}
+	if filter.FilterUsers {
+		racerFilter.UserIDs = filter.UserIDList()
+	}
+	racers, racerArgs := reports.LeaderboardQuery(competitionIDs, racerFilter)
+	keyset := getLeaderboardKeyset(filter)
+	args := append([]interface{}{}, racerArgs...)
+
+	// Query the page of users
+	page, err := keyset.Page(filter.Page, len(args))
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, page.Values...)
+	args = append(args, page.Limit)
+	conditions := "true"
+	if len(page.Conditions) > 0 {
+		conditions = strings.Join(page.Conditions, " AND ")
+	}
+	q := `
+		SELECT p.competition_id, p.user_id, p.points, p.rank, p.total_points,
+			u.username, u.display_name, u.membership_type, u.status, u.created_at, u.updated_at
+		FROM (
+			SELECT l.*,
+				row_number() OVER (PARTITION BY l.competition_id ORDER BY ` + page.FetchOrder + `) AS row_number
+			FROM (` + racers + `) l
+			WHERE ` + conditions + `
+		) p
+			INNER JOIN users u ON u.id = p.user_id
+		WHERE p.row_number <= $` + fmt.Sprint(len(args)) + `
+		ORDER BY p.competition_id, ` + page.Order
+	rows, err := conn.Query(context.Background(), q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query competition leaderboard: %w", err)
+	}
+	defer rows.Close()
+	users := []competitionLeaderboardUser{}
+	userIDs := []string{}
+	for rows.Next() {
+		var row competitionLeaderboardUser
+		err := rows.Scan(
+			&row.competitionID, &row.userID, &row.points, &row.rank, &row.totalPoints,
+			&row.username, &row.displayName, &row.membershipType, &row.status, &row.createdAt, &row.updatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan competition leaderboard: %w", err)
+		}
+		users = append(users, row)
+		userIDs = append(userIDs, row.userID)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while scanning competition leaderboard: %w", err)
+	}
+
+	// Query the total count of each leaderboard
+	q = `
+		SELECT l.competition_id, COUNT(*)::int
+		FROM (` + racers + `) l
+		GROUP BY l.competition_id`
+	rows, err = conn.Query(context.Background(), q, racerArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query competition leaderboard counts: %w", err)
+	}
+	defer rows.Close()
+	totalCounts := map[string]int{}
+	for rows.Next() {
+		competitionID, totalCount := "", 0
+		err := rows.Scan(&competitionID, &totalCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan competition leaderboard counts: %w", err)
+		}
+		totalCounts[competitionID] = totalCount
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while scanning competition leaderboard counts: %w", err)
+	}
+
+	// Query the category results of the page
+	q, args, err = db.QueryBuilder.
+		Select(
+			goqu.L("r.competition_id"),
+			goqu.L("r.user_id"),
+			goqu.L("cat.name"),
+			goqu.L("r.score"),
+			goqu.L("r.eligible"),
+			goqu.L("r.ineligible_reason"),
+			goqu.L("r.rank"),
+			goqu.L("r.tied"),
+			goqu.L("r.reward"),
+		).
+		From(goqu.T("leaderboard_results").As("r")).
+		InnerJoin(
+			goqu.L("categories cat"),
+			goqu.On(goqu.L("cat.id = r.category_id")),
+		).
+		Where(
+			goqu.L("r.competition_id").In(competitionIDs),
+			goqu.L("r.user_id").In(append(userIDs, "00000000-0000-0000-0000-000000000000")),
+		).
+		Order(goqu.L("cat.position").Asc(), goqu.L("cat.name").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build competition leaderboard categories: %w", err)
+	}
+	rows, err = conn.Query(context.Background(), q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query competition leaderboard categories: %w", err)
+	}
+	defer rows.Close()
+	categories := []competitionLeaderboardCategory{}
+	for rows.Next() {
+		var row competitionLeaderboardCategory
+		err := rows.Scan(&row.competitionID, &row.userID, &row.category, &row.score, &row.eligible, &row.ineligibleReason, &row.rank, &row.tied, &row.reward)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan competition leaderboard categories: %w", err)
+		}
+		categories = append(categories, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while scanning competition leaderboard categories: %w", err)
+	}
+
+	// Query the adjustments of the page
+	q, args, err = db.QueryBuilder.
+		Select(
+			goqu.L("a.id"),
+			goqu.L("a.user_id"),
+			goqu.L("a.competition_id"),
+			goqu.L("a.points"),
+			goqu.L("a.reason"),
+			goqu.L("a.author"),
+			goqu.L("a.created_at"),
+		).
+		From(goqu.T("adjustments").As("a")).
+		Where(
+			goqu.L("a.competition_id").In(competitionIDs),
+			goqu.L("a.user_id").In(append(userIDs, "00000000-0000-0000-0000-000000000000")),
+			goqu.L("a.deleted_at IS NULL"),
+		).
+		Order(goqu.L("a.created_at").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build competition adjustments: %w", err)
+	}
+	rows, err = conn.Query(context.Background(), q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query competition adjustments: %w", err)
+	}
+	defer rows.Close()
+	adjustments := []*gqlmodels.Adjustment{}
+	for rows.Next() {
+		row := &gqlmodels.Adjustment{}
+		err := rows.Scan(&row.ID, &row.UserID, &row.CompetitionID, &row.Points, &row.Reason, &row.Author, &row.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan competition adjustments: %w", err)
+		}
+		adjustments = append(adjustments, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while scanning competition adjustments: %w", err)
+	}
+
+	// Return output (users are eligible if they qualify for at least one category)
+	output := map[string]*gqlmodels.CompetitionUserConnection{}
+	for _, competitionID := range competitionIDs {
+		edges := []*gqlmodels.CompetitionUserEdge{}
+		for _, row := range users {
+			if row.competitionID != competitionID {
+				continue
+			}
+			if row.displayName == "" {
+				row.displayName = row.username
+			}
+			userRow := &gqlmodels.CompetitionUser{
+				ID:          CompetitionUserID(row.competitionID, row.userID),
+				TotalPoints: row.totalPoints,
+				Categories:  []*gqlmodels.CompetitionUserCategory{},
+				Adjustments: []*gqlmodels.Adjustment{},
+				User: &gqlmodels.User{
+					ID:             row.userID,
+					Username:       row.username,
+					DisplayName:    row.displayName,
+					MembershipType: gqlmodels.MembershipType(row.membershipType),
+					Status:         gqlmodels.UserStatus(row.status),
+					CreatedAt:      row.createdAt,
+					UpdatedAt:      row.updatedAt,
+				},
+			}
+			for _, c := range categories {
+				if c.competitionID != row.competitionID || c.userID != row.userID {
+					continue
+				}
+				if c.eligible {
+					userRow.Eligible = true
+					userRow.IneligibleReason = nil
+				} else if !userRow.Eligible && userRow.IneligibleReason == nil {
+					userRow.IneligibleReason = c.ineligibleReason
+				}
+				userRow.Categories = append(userRow.Categories, &gqlmodels.CompetitionUserCategory{
+					Name:             c.category,
+					Eligible:         c.eligible,
+					IneligibleReason: c.ineligibleReason,
+					Rank:             c.rank,
+					Tied:             c.tied,
+					Score:            c.score,
+					Reward:           c.reward,
+				})
+			}
+			for _, a := range adjustments {
+				if *a.CompetitionID == row.competitionID && a.UserID == row.userID {
+					userRow.Adjustments = append(userRow.Adjustments, a)
+				}
+			}
+			sortValue := row.points
+			if filter.Category != "" {
+				sortValue = row.rank
+			}
+			edges = append(edges, &gqlmodels.CompetitionUserEdge{
+				Cursor: keyset.Cursor(fmt.Sprint(sortValue), row.userID),
+				Node:   userRow,
+			})
+		}
+		start, end, hasPreviousPage, hasNextPage := page.Window(len(edges))
+		connection := &gqlmodels.CompetitionUserConnection{
+			Edges:      edges[start:end],
+			TotalCount: totalCounts[competitionID],
+			PageInfo: &gqlmodels.PageInfo{
+				HasPreviousPage: hasPreviousPage,
+				HasNextPage:     hasNextPage,
+			},
+		}
+		if len(connection.Edges) > 0 {
+			connection.PageInfo.StartCursor = &connection.Edges[0].Cursor
+			connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+		}
+		output[competitionID] = connection
+	}
+	return output, nil
+}
+
+// UserResultsKeyset is the order of the user results (most recent first).
+var UserResultsKeyset = pagination.Keyset{
+	Columns: []pagination.Column{
+		{Expr: "c.to_at", Name: "p.to_at", Type: "timestamptz"},
+		{Expr: "c.id", Name: "p.id", Type: "uuid"},
+	},
+	Descending: true,
+}
+
+// userResultsLoader fetches a page of the competitions each user has placed in for the following resolver:
+// * user -> results
+// Keys with the same page and time range are fetched together.
+func userResultsLoader(conn *pgxpool.Pool) *UserResultsLoader {
+	return NewUserResultsLoader(
+		UserResultsLoaderConfig{
+			Fetch: func(keys []UserResultsKey) ([]*gqlmodels.UserResultConnection, []error) {
+				metrics.ObserveBatch("userResults", len(keys))
+				if len(keys) == 0 {
+					return []*gqlmodels.UserResultConnection{}, nil
+				}
+				output := make([]*gqlmodels.UserResultConnection, len(keys))
+				errs := make([]error, len(keys))
+
+				// Group the users requesting the same page and time range
+				filterKeys := map[UserResultsKey][]int{}
+				for i, key := range keys {
+					filterKeys[key.Filter()] = append(filterKeys[key.Filter()], i)
+				}
+				for filter, indexes := range filterKeys {
+					userIDs := []string{}
+					for _, i := range indexes {
+						userIDs = append(userIDs, keys[i].ID)
+					}
+					pageResults, err := fetchUserResultsPage(conn, filter, userIDs)
+					if err != nil {
+						for _, i := range indexes {
+							errs[i] = err
+						}
+						continue
+					}
+					for _, i := range indexes {
+						output[i] = pageResults[keys[i].ID]
+					}
+				}
+				return output, errs
+			},
+			Wait:     1 * time.Millisecond,
+			MaxBatch: 100,
+		},
+	)
+}
+
+// fetchUserResultsPage fetches the same page of results (with the totals over every page) of each user.
+func fetchUserResultsPage(conn *pgxpool.Pool, filter UserResultsKey, userIDs []string) (map[string]*gqlmodels.UserResultConnection, error) {
+	// Filter by user and time range
+	args := []interface{}{userIDs}
+	conditions := []string{
+		"EXISTS (SELECT 1 FROM competition_results _r WHERE _r.competition_id = c.id AND _r.user_id = u.user_id)",
+	}
+	if !filter.TimeFrom.IsZero() {
+		args = append(args, filter.TimeFrom, filter.TimeTo)
+		conditions = append(conditions, fmt.Sprintf("c.from_at >= $%d AND c.to_at <= $%d", len(args)-1, len(args)))
+	}
+	competitions := `
+		FROM unnest($1::uuid[]) AS u (user_id)
+			INNER JOIN competitions c ON ` + strings.Join(conditions, " AND ")
+
+	output := map[string]*gqlmodels.UserResultConnection{}
+	for _, userID := range userIDs {
+		output[userID] = &gqlmodels.UserResultConnection{
+			Edges: []*gqlmodels.UserResultEdge{},
+			PageInfo: &gqlmodels.PageInfo{
+				HasPreviousPage: false,
+				HasNextPage:     false,
+			},
+			Totals: &gqlmodels.UserResultTotals{},
+		}
+	}
+
+	// Query the totals of each user
+	q := `
+		SELECT p.user_id,
+			COUNT(*)::int,
+			coalesce(SUM(p.reward), 0)::int,
+			coalesce(SUM(s.played), 0)::int,
+			coalesce(AVG(s.speed), 0)::float,
+			coalesce(AVG(s.accuracy), 0)::float
+		FROM (
+			SELECT u.user_id,
+				c.id AS competition_id,
+				(SELECT SUM(_r.reward) FROM competition_results _r WHERE _r.competition_id = c.id AND _r.user_id = u.user_id) AS reward
+			` + competitions + `
+		) p
+			LEFT JOIN competition_user_stats s ON s.competition_id = p.competition_id AND s.user_id = p.user_id
+		GROUP BY p.user_id`
+	rows, err := conn.Query(context.Background(), q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user result totals: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		userID, totals := "", &gqlmodels.UserResultTotals{}
+		err := rows.Scan(&userID, &totals.Competitions, &totals.Rewards, &totals.Played, &totals.AverageSpeed, &totals.AverageAccuracy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user result totals: %w", err)
+		}
+		if totals.Competitions > 0 {
+			totals.AverageReward = float64(totals.Rewards) / float64(totals.Competitions)
+		}
+		output[userID].TotalCount = totals.Competitions
+		output[userID].Totals = totals
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while scanning user result totals: %w", err)
+	}
+
+	// Query the page of competitions
+	page, err := UserResultsKeyset.Page(filter.Page, len(args))
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, page.Values...)
+	args = append(args, page.Limit)
+	pageConditions := "true"
+	if len(page.Conditions) > 0 {
+		pageConditions = strings.Join(page.Conditions, " AND ")
+	}
+	q = `
+		SELECT p.user_id, p.id, p.status, p.multiplier, p.tie_policy, p.tie_breaker, p.tie_breaker_direction, p.min_races, p.min_secs, p.min_typed, p.from_at, p.to_at, p.provisional_at, p.updated_at
+		FROM (
+			SELECT u.user_id, c.id, c.status, c.multiplier, c.tie_policy, c.tie_breaker, c.tie_breaker_direction, c.min_races, c.min_secs, c.min_typed, c.from_at, c.to_at, c.provisional_at, c.updated_at,
+				row_number() OVER (PARTITION BY u.user_id ORDER BY ` + page.FetchOrder + `) AS row_number
+			` + competitions + `
+			WHERE ` + pageConditions + `
+		) p
+		WHERE p.row_number <= $` + fmt.Sprint(len(args)) + `
+		ORDER BY p.user_id, ` + page.Order
+	rows, err = conn.Query(context.Background(), q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user results: %w", err)
+	}
+	defer rows.Close()
+	edges := map[string][]*gqlmodels.UserResultEdge{}
+	for rows.Next() {
+		userID, row := "", &gqlmodels.Competition{}
+		err := rows.Scan(&userID, &row.ID, &row.Status, &row.Multiplier, &row.TiePolicy, &row.TieBreaker, &row.TieBreakerDirection, &row.MinRaces, &row.MinSecs, &row.MinTyped, &row.StartAt, &row.FinishAt, &row.ProvisionalAt, &row.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user results: %w", err)
+		}
+		edges[userID] = append(edges[userID], &gqlmodels.UserResultEdge{
+			Cursor: UserResultsKeyset.Cursor(pagination.FormatTime(row.FinishAt), row.ID),
+			Node: &gqlmodels.UserResult{
+				ID:          CompetitionUserID(row.ID, userID),
+				Competition: row,
+				Categories:  []*gqlmodels.CompetitionUserCategory{},
+				Adjustments: []*gqlmodels.Adjustment{},
+			},
+		})
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while scanning user results: %w", err)
+	}
+	results := map[string]*gqlmodels.UserResult{}
+	competitionIDs := []string{}
+	for userID, userEdges := range edges {
+		start, end, hasPreviousPage, hasNextPage := page.Window(len(userEdges))
+		connection := output[userID]
+		connection.Edges = userEdges[start:end]
+		connection.PageInfo.HasPreviousPage = hasPreviousPage
+		connection.PageInfo.HasNextPage = hasNextPage
+		if len(connection.Edges) > 0 {
+			connection.PageInfo.StartCursor = &connection.Edges[0].Cursor
+			connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+		}
+		for _, edge := range connection.Edges {
+			results[edge.Node.ID] = edge.Node
+			competitionIDs = append(competitionIDs, edge.Node.Competition.ID)
+		}
+	}
+	if len(results) == 0 {
+		return output, nil
+	}
+
+	// Query the category results of the page
+	q = `
+		SELECT r.competition_id::text, r.user_id::text, cat.name, r.score::float, r.eligible, r.ineligible_reason, r.rank, r.tied, r.reward
+		FROM competition_results r
+			INNER JOIN categories cat ON cat.id = r.category_id
+		WHERE r.user_id = ANY($1::uuid[])
+			AND r.competition_id = ANY($2::uuid[])
+		ORDER BY cat.position, cat.name`
+	rows, err = conn.Query(context.Background(), q, userIDs, competitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user result categories: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		competitionID, userID := "", ""
+		row := &gqlmodels.CompetitionUserCategory{}
+		err := rows.Scan(&competitionID, &userID, &row.Name, &row.Score, &row.Eligible, &row.IneligibleReason, &row.Rank, &row.Tied, &row.Reward)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user result categories: %w", err)
+		}
+		result, ok := results[CompetitionUserID(competitionID, userID)]
+		if !ok {
+			continue
+		}
+		if row.Eligible {
+			result.Eligible = true
+			result.IneligibleReason = nil
+		} else if !result.Eligible && result.IneligibleReason == nil {
+			result.IneligibleReason = row.IneligibleReason
+		}
+		result.Categories = append(result.Categories, row)
+		result.TotalPoints += row.Reward
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while scanning user result categories: %w", err)
+	}
+
+	// Query the adjustments of the page
+	q = `
+		SELECT a.id, a.user_id, a.competition_id::text, a.points, a.reason, a.author, a.created_at
+		FROM adjustments a
+		WHERE a.user_id = ANY($1::uuid[])
+			AND a.competition_id = ANY($2::uuid[])
+			AND a.deleted_at IS NULL
+		ORDER BY a.created_at`
+	rows, err = conn.Query(context.Background(), q, userIDs, competitionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user result adjustments: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row := &gqlmodels.Adjustment{}
+		err := rows.Scan(&row.ID, &row.UserID, &row.CompetitionID, &row.Points, &row.Reason, &row.Author, &row.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user result adjustments: %w", err)
+		}
+		result, ok := results[CompetitionUserID(*row.CompetitionID, row.UserID)]
+		if !ok {
+			continue
+		}
+		result.Adjustments = append(result.Adjustments, row)
+		result.TotalPoints += row.Points
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while scanning user result adjustments: %w", err)
+	}
+	return output, nil
+}
+
 // userTotalPointLoader fetches the total points for the following resolver:
 // * user -> totalPoints
 func userTotalPointLoader(conn *pgxpool.Pool) *UserTotalPointsLoader {
@@ -196,6 +1109,7 @@ func userTotalPointLoader(conn *pgxpool.Pool) *UserTotalPointsLoader {
 	return NewUserTotalPointsLoader(
 		UserTotalPointsLoaderConfig{
 			Fetch: func(ids []string) ([]int, []error) {
+				metrics.ObserveBatch("userTotalPoints", len(ids))
 				if len(ids) == 0 {
 					return []int{}, nil
 				}
@@ -204,9 +1118,9 @@ func userTotalPointLoader(conn *pgxpool.Pool) *UserTotalPointsLoader {
 				q, args, err := db.QueryBuilder.
 					Select(
 						goqu.C("user_id"),
-						goqu.L("SUM(grind_reward + accuracy_reward + speed_reward + point_reward)"),
+						goqu.L("SUM(points)"),
 					).
-					From("competition_results").
+					From("user_points").
 					Where(
 						goqu.Ex{
 							"user_id": ids,
@@ -255,3 +1169,335 @@ func userTotalPointLoader(conn *pgxpool.Pool) *UserTotalPointsLoader {
 		},
 	)
 }
+
+// userAdjustmentsLoader fetches the adjustments for the following resolver:
+// * user -> adjustments
+func userAdjustmentsLoader(conn *pgxpool.Pool) *UserAdjustmentsLoader {
+	return NewUserAdjustmentsLoader(
+		UserAdjustmentsLoaderConfig{
+			Fetch: func(ids []string) ([][]*gqlmodels.Adjustment, []error) {
+				metrics.ObserveBatch("userAdjustments", len(ids))
+				if len(ids) == 0 {
+					return [][]*gqlmodels.Adjustment{}, nil
+				}
+
+				// Query adjustments
+				q, args, err := db.QueryBuilder.
+					Select(
+						goqu.L("a.id"),
+						goqu.L("a.user_id"),
+						goqu.L("a.competition_id"),
+						goqu.L("a.points"),
+						goqu.L("a.reason"),
+						goqu.L("a.author"),
+						goqu.L("a.created_at"),
+					).
+					From(goqu.T("adjustments").As("a")).
+					Where(
+						goqu.L("a.user_id").In(ids),
+						goqu.L("a.deleted_at IS NULL"),
+					).
+					Order(goqu.L("a.created_at").Asc()).
+					ToSQL()
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to build user adjustments: %w", err)}
+				}
+				rows, err := conn.Query(context.Background(), q, args...)
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to query user adjustments: %w", err)}
+				}
+				defer rows.Close()
+				results := []*gqlmodels.Adjustment{}
+				for rows.Next() {
+					row := &gqlmodels.Adjustment{}
+					err := rows.Scan(&row.ID, &row.UserID, &row.CompetitionID, &row.Points, &row.Reason, &row.Author, &row.CreatedAt)
+					if err != nil {
+						return nil, []error{fmt.Errorf("failed to scan user adjustments: %w", err)}
+					}
+					results = append(results, row)
+				}
+				err = rows.Err()
+				if err != nil {
+					return nil, []error{fmt.Errorf("an error occurred while scanning user adjustments: %w", err)}
+				}
+
+				// Return output
+				output := [][]*gqlmodels.Adjustment{}
+				for _, key := range ids {
+					rows := []*gqlmodels.Adjustment{}
+					for _, row := range results {
+						if row.UserID == key {
+							rows = append(rows, row)
+						}
+					}
+					output = append(output, rows)
+				}
+				return output, nil
+			},
+			Wait:     1 * time.Millisecond,
+			MaxBatch: 100,
+		},
+	)
+}
+
+// userLoader fetches the users (nil if they don't exist) for the following resolvers:
+// * query -> node
+// * query -> nodes
+func userLoader(conn *pgxpool.Pool) *UserLoader {
+	return NewUserLoader(
+		UserLoaderConfig{
+			Fetch: func(ids []string) ([]*gqlmodels.User, []error) {
+				metrics.ObserveBatch("user", len(ids))
+				if len(ids) == 0 {
+					return []*gqlmodels.User{}, nil
+				}
+
+				// Query users
+				q, args, err := db.QueryBuilder.
+					Select(
+						goqu.L("u.id"),
+						goqu.L("u.username"),
+						goqu.L("u.display_name"),
+						goqu.L("u.membership_type"),
+						goqu.L("u.status"),
+						goqu.L("u.created_at"),
+						goqu.L("u.updated_at"),
+					).
+					From(goqu.T("users").As("u")).
+					Where(
+						goqu.L("u.id").In(ids),
+						goqu.L("u.deleted_at IS NULL"),
+					).
+					ToSQL()
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to build users: %w", err)}
+				}
+				rows, err := conn.Query(context.Background(), q, args...)
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to query users: %w", err)}
+				}
+				defer rows.Close()
+				results := map[string]*gqlmodels.User{}
+				for rows.Next() {
+					row := &gqlmodels.User{}
+					err := rows.Scan(&row.ID, &row.Username, &row.DisplayName, &row.MembershipType, &row.Status, &row.CreatedAt, &row.UpdatedAt)
+					if err != nil {
+						return nil, []error{fmt.Errorf("failed to scan users: %w", err)}
+					}
+					if row.DisplayName == "" {
+						row.DisplayName = row.Username
+					}
+					results[row.ID] = row
+				}
+				err = rows.Err()
+				if err != nil {
+					return nil, []error{fmt.Errorf("an error occurred while scanning users: %w", err)}
+				}
+
+				// Return output
+				output := []*gqlmodels.User{}
+				for _, key := range ids {
+					output = append(output, results[key])
+				}
+				return output, nil
+			},
+			Wait:     1 * time.Millisecond,
+			MaxBatch: 100,
+		},
+	)
+}
+
+// eventLoader fetches the events (nil if they don't exist) for the following resolvers:
+// * query -> node
+// * query -> nodes
+func eventLoader(conn *pgxpool.Pool) *EventLoader {
+	return NewEventLoader(
+		EventLoaderConfig{
+			Fetch: func(ids []string) ([]*gqlmodels.Event, []error) {
+				metrics.ObserveBatch("event", len(ids))
+				if len(ids) == 0 {
+					return []*gqlmodels.Event{}, nil
+				}
+
+				// Query events
+				q, args, err := db.QueryBuilder.
+					Select(
+						goqu.L("e.id"),
+						goqu.L("e.name"),
+						goqu.L("e.team_tag"),
+						goqu.L("e.rules"),
+						goqu.L("e.timezone"),
+						goqu.L("e.window_minutes"),
+						goqu.L("e.tie_policy"),
+						goqu.L("e.payout_rate"),
+						goqu.L("e.from_at"),
+						goqu.L("e.to_at"),
+						goqu.L("e.created_at"),
+						goqu.L("e.updated_at"),
+					).
+					From(goqu.T("events").As("e")).
+					Where(
+						goqu.L("e.id").In(ids),
+						goqu.L("e.deleted_at IS NULL"),
+					).
+					ToSQL()
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to build events: %w", err)}
+				}
+				rows, err := conn.Query(context.Background(), q, args...)
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to query events: %w", err)}
+				}
+				defer rows.Close()
+				results := map[string]*gqlmodels.Event{}
+				for rows.Next() {
+					row := &gqlmodels.Event{}
+					err := rows.Scan(&row.ID, &row.Name, &row.TeamTag, &row.Rules, &row.Timezone, &row.WindowMinutes, &row.TiePolicy, &row.PayoutRate, &row.StartAt, &row.FinishAt, &row.CreatedAt, &row.UpdatedAt)
+					if err != nil {
+						return nil, []error{fmt.Errorf("failed to scan events: %w", err)}
+					}
+					results[row.ID] = row
+				}
+				err = rows.Err()
+				if err != nil {
+					return nil, []error{fmt.Errorf("an error occurred while scanning events: %w", err)}
+				}
+
+				// Return output
+				output := []*gqlmodels.Event{}
+				for _, key := range ids {
+					output = append(output, results[key])
+				}
+				return output, nil
+			},
+			Wait:     1 * time.Millisecond,
+			MaxBatch: 100,
+		},
+	)
+}
+
+// competitionLoader fetches the competitions (nil if they don't exist) for the following resolvers:
+// * query -> node
+// * query -> nodes
+func competitionLoader(conn *pgxpool.Pool) *CompetitionLoader {
+	return NewCompetitionLoader(
+		CompetitionLoaderConfig{
+			Fetch: func(ids []string) ([]*gqlmodels.Competition, []error) {
+				metrics.ObserveBatch("competition", len(ids))
+				if len(ids) == 0 {
+					return []*gqlmodels.Competition{}, nil
+				}
+
+				// Query competitions
+				q, args, err := db.QueryBuilder.
+					Select(
+						goqu.L("c.id"),
+						goqu.L("c.status"),
+						goqu.L("c.multiplier"),
+						goqu.L("c.tie_policy"),
+						goqu.L("c.tie_breaker"),
+						goqu.L("c.tie_breaker_direction"),
+						goqu.L("c.min_races"),
+						goqu.L("c.min_secs"),
+						goqu.L("c.min_typed"),
+						goqu.L("c.from_at"),
+						goqu.L("c.to_at"),
+						goqu.L("c.provisional_at"),
+						goqu.L("c.updated_at"),
+					).
+					From(goqu.T("competitions").As("c")).
+					Where(
+						goqu.L("c.id").In(ids),
+						goqu.L("c.deleted_at IS NULL"),
+					).
+					ToSQL()
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to build competitions: %w", err)}
+				}
+				rows, err := conn.Query(context.Background(), q, args...)
+				if err != nil {
+					return nil, []error{fmt.Errorf("failed to query competitions: %w", err)}
+				}
+				defer rows.Close()
+				results := map[string]*gqlmodels.Competition{}
+				for rows.Next() {
+					row := &gqlmodels.Competition{}
+					err := rows.Scan(&row.ID, &row.Status, &row.Multiplier, &row.TiePolicy, &row.TieBreaker, &row.TieBreakerDirection, &row.MinRaces, &row.MinSecs, &row.MinTyped, &row.StartAt, &row.FinishAt, &row.ProvisionalAt, &row.UpdatedAt)
+					if err != nil {
+						return nil, []error{fmt.Errorf("failed to scan competitions: %w", err)}
+					}
+					results[row.ID] = row
+				}
+				err = rows.Err()
+				if err != nil {
+					return nil, []error{fmt.Errorf("an error occurred while scanning competitions: %w", err)}
+				}
+
+				// Return output
+				output := []*gqlmodels.Competition{}
+				for _, key := range ids {
+					output = append(output, results[key])
+				}
+				return output, nil
+			},
+			Wait:     1 * time.Millisecond,
+			MaxBatch: 100,
+		},
+	)
+}
+
+// CompetitionUserID is the id of a racer in a competition leaderboard.
+func CompetitionUserID(competitionID string, userID string) string {
+	return competitionID + "::" + userID
+}
+
+// competitionUserLoader fetches the racers of the competition leaderboards (nil if they haven't raced) for the following resolvers:
+// * query -> node
+// * query -> nodes
+// The ids are made with CompetitionUserID, the racers of each competition are fetched together.
+func competitionUserLoader(conn *pgxpool.Pool) *CompetitionUserLoader {
+	return NewCompetitionUserLoader(
+		CompetitionUserLoaderConfig{
+			Fetch: func(ids []string) ([]*gqlmodels.CompetitionUser, []error) {
+				metrics.ObserveBatch("competitionUser", len(ids))
+				if len(ids) == 0 {
+					return []*gqlmodels.CompetitionUser{}, nil
+				}
+				output := make([]*gqlmodels.CompetitionUser, len(ids))
+				errs := make([]error, len(ids))
+
+				// Group the racers by competition
+				competitionUsers := map[string][]string{}
+				competitionIndexes := map[string][]int{}
+				for i, id := range ids {
+					parts := strings.SplitN(id, "::", 2)
+					if len(parts) != 2 {
+						continue
+					}
+					competitionUsers[parts[0]] = append(competitionUsers[parts[0]], parts[1])
+					competitionIndexes[parts[0]] = append(competitionIndexes[parts[0]], i)
+				}
+				for competitionID, userIDs := range competitionUsers {
+					filter := NewLeaderboardKey("", pagination.Args{Size: len(userIDs)}, nil, nil, true, userIDs)
+					leaderboards, err := fetchCompetitionLeaderboardPage(conn, filter, []string{competitionID})
+					if err != nil {
+						for _, i := range competitionIndexes[competitionID] {
+							errs[i] = err
+						}
+						continue
+					}
+					racers := map[string]*gqlmodels.CompetitionUser{}
+					for _, edge := range leaderboards[competitionID].Edges {
+						racers[edge.Node.ID] = edge.Node
+					}
+					for _, i := range competitionIndexes[competitionID] {
+						output[i] = racers[ids[i]]
+					}
+				}
+				return output, errs
+			},
+			Wait:     1 * time.Millisecond,
+			MaxBatch: 100,
+		},
+	)
+}
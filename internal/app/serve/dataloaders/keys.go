@@ -2,6 +2,9 @@ package dataloaders
 
 import (
 	"fmt"
+	"nt-folly-xmaxx-comp/internal/app/serve/pagination"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -42,7 +45,7 @@ func (k IDTimeRangeKey) String() string {
 }
 
 // Raw is an identity method. Used to implement Key Raw.
-func (k IDTimeRangeKey) Row() interface{} {
+func (k IDTimeRangeKey) Raw() interface{} {
 	return k
 }
 
@@ -67,3 +70,98 @@ func NewKeysFromStrings(strings []string) Keys {
 	}
 	return list
 }
+
+//////////////////////////
+//  Key: LeaderboardKey  //
+//////////////////////////
+
+// LeaderboardKey implements the Key interface for a page of a competition leaderboard with its filters.
+// The fields are comparable so the key can be cached by the dataloader (the user ids are kept as a sorted list).
+type LeaderboardKey struct {
+	ID                string
+	Page              pagination.Args
+	Category          string
+	Limit             int
+	IncludeIneligible bool
+	FilterUsers       bool
+	UserIDs           string
+}
+
+// NewLeaderboardKey builds the key of a leaderboard page. Only the given users are included when userIDs isn't nil.
+func NewLeaderboardKey(id string, page pagination.Args, category *string, limit *int, includeIneligible bool, userIDs []string) LeaderboardKey {
+	output := LeaderboardKey{
+		ID:                id,
+		Page:              page,
+		IncludeIneligible: includeIneligible,
+	}
+	if category != nil {
+		output.Category = strings.ToLower(*category)
+	}
+	if limit != nil {
+		output.Limit = *limit
+	}
+	if userIDs != nil {
+		ids := append([]string{}, userIDs...)
+		sort.Strings(ids)
+		output.FilterUsers = true
+		output.UserIDs = strings.Join(ids, ",")
+	}
+	return output
+}
+
+// String is an identity method. Used to implement String interface.
+func (k LeaderboardKey) String() string {
+	return fmt.Sprintf(
+		"%s::%d-%t-%s-%s::%s-%d-%t-%t-%s",
+		k.ID, k.Page.Size, k.Page.Backward, k.Page.After, k.Page.Before,
+		k.Category, k.Limit, k.IncludeIneligible, k.FilterUsers, k.UserIDs,
+	)
+}
+
+// Raw is an identity method. Used to implement Key Raw.
+func (k LeaderboardKey) Raw() interface{} { return k }
+
+// Filter is the key without the competition id, keys sharing the same filter can be fetched together.
+func (k LeaderboardKey) Filter() LeaderboardKey {
+	k.ID = ""
+	return k
+}
+
+// UserIDList returns the users the leaderboard is filtered by.
+func (k LeaderboardKey) UserIDList() []string {
+	if k.UserIDs == "" {
+		return []string{}
+	}
+	return strings.Split(k.UserIDs, ",")
+}
+
+///////////////////////////
+//  Key: UserResultsKey  //
+///////////////////////////
+
+// UserResultsKey implements the Key interface for a page of a user's competition results with its time range.
+// The time range is left zero when the results aren't filtered by time.
+type UserResultsKey struct {
+	ID       string
+	Page     pagination.Args
+	TimeFrom time.Time
+	TimeTo   time.Time
+}
+
+// String is an identity method. Used to implement String interface.
+func (k UserResultsKey) String() string {
+	return fmt.Sprintf(
+		"%s::%d-%t-%s-%s::%d-%d",
+		k.ID, k.Page.Size, k.Page.Backward, k.Page.After, k.Page.Before,
+		k.TimeFrom.Unix(), k.TimeTo.Unix(),
+	)
+}
+
+// Raw is an identity method. Used to implement Key Raw.
+func (k UserResultsKey) Raw() interface{} { return k }
+
+// Filter is the key without the user id, keys sharing the same filter can be fetched together.
+func (k UserResultsKey) Filter() UserResultsKey {
+	k.ID = ""
+	return k
+}
@@ -0,0 +1,224 @@
+// Code generated by github.com/vektah/dataloaden, DO NOT EDIT.
+
+package dataloaders
+
+import (
+	"sync"
+	"time"
+
+	"nt-folly-xmaxx-comp/internal/app/serve/graphql/gqlmodels"
+)
+
+// UserResultsLoaderConfig captures the config to create a new UserResultsLoader
+type UserResultsLoaderConfig struct {
+	// Fetch is a method that provides the data for the loader
+	Fetch func(keys []UserResultsKey) ([]*gqlmodels.UserResultConnection, []error)
+
+	// Wait is how long wait before sending a batch
+	Wait time.Duration
+
+	// MaxBatch will limit the maximum number of keys to send in one batch, 0 = not limit
+	MaxBatch int
+}
+
+// NewUserResultsLoader creates a new UserResultsLoader given a fetch, wait, and maxBatch
+func NewUserResultsLoader(config UserResultsLoaderConfig) *UserResultsLoader {
+	return &UserResultsLoader{
+		fetch:    config.Fetch,
+		wait:     config.Wait,
+		maxBatch: config.MaxBatch,
+	}
+}
+
+// UserResultsLoader batches and caches requests
+type UserResultsLoader struct {
+	// this method provides the data for the loader
+	fetch func(keys []UserResultsKey) ([]*gqlmodels.UserResultConnection, []error)
+
+	// how long to done before sending a batch
+	wait time.Duration
+
+	// this will limit the maximum number of keys to send in one batch, 0 = no limit
+	maxBatch int
+
+	// INTERNAL
+
+	// lazily created cache
+	cache map[UserResultsKey]*gqlmodels.UserResultConnection
+
+	// the current batch. keys will continue to be collected until timeout is hit,
+	// then everything will be sent to the fetch method and out to the listeners
+	batch *userResultsLoaderBatch
+
+	// mutex to prevent races
+	mu sync.Mutex
+}
+
+type userResultsLoaderBatch struct {
+	keys    []UserResultsKey
+	data    []*gqlmodels.UserResultConnection
+	error   []error
+	closing bool
+	done    chan struct{}
+}
+
+// Load a UserResultConnection by key, batching and caching will be applied automatically
+func (l *UserResultsLoader) Load(key UserResultsKey) (*gqlmodels.UserResultConnection, error) {
+	return l.LoadThunk(key)()
+}
+
+// LoadThunk returns a function that when called will block waiting for a UserResultConnection.
+// This method should be used if you want one goroutine to make requests to many
+// different data loaders without blocking until the thunk is called.
+func (l *UserResultsLoader) LoadThunk(key UserResultsKey) func() (*gqlmodels.UserResultConnection, error) {
+	l.mu.Lock()
+	if it, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return func() (*gqlmodels.UserResultConnection, error) {
+			return it, nil
+		}
+	}
+	if l.batch == nil {
+		l.batch = &userResultsLoaderBatch{done: make(chan struct{})}
+	}
+	batch := l.batch
+	pos := batch.keyIndex(l, key)
+	l.mu.Unlock()
+
+	return func() (*gqlmodels.UserResultConnection, error) {
+		<-batch.done
+
+		var data *gqlmodels.UserResultConnection
+		if pos < len(batch.data) {
+			data = batch.data[pos]
+		}
+
+		var err error
+		// its convenient to be able to return a single error for everything
+		if len(batch.error) == 1 {
+			err = batch.error[0]
+		} else if batch.error != nil {
+			err = batch.error[pos]
+		}
+
+		if err == nil {
+			l.mu.Lock()
+			l.unsafeSet(key, data)
+			l.mu.Unlock()
+		}
+
+		return data, err
+	}
+}
+
+// LoadAll fetches many keys at once. It will be broken into appropriate sized
+// sub batches depending on how the loader is configured
+func (l *UserResultsLoader) LoadAll(keys []UserResultsKey) ([]*gqlmodels.UserResultConnection, []error) {
+	results := make([]func() (*gqlmodels.UserResultConnection, error), len(keys))
+
+	for i, key := range keys {
+		results[i] = l.LoadThunk(key)
+	}
+
+	userResultConnections := make([]*gqlmodels.UserResultConnection, len(keys))
+	errors := make([]error, len(keys))
+	for i, thunk := range results {
+		userResultConnections[i], errors[i] = thunk()
+	}
+	return userResultConnections, errors
+}
+
+// LoadAllThunk returns a function that when called will block waiting for a UserResultConnections.
+// This method should be used if you want one goroutine to make requests to many
+// different data loaders without blocking until the thunk is called.
+func (l *UserResultsLoader) LoadAllThunk(keys []UserResultsKey) func() ([]*gqlmodels.UserResultConnection, []error) {
+	results := make([]func() (*gqlmodels.UserResultConnection, error), len(keys))
+	for i, key := range keys {
+		results[i] = l.LoadThunk(key)
+	}
+	return func() ([]*gqlmodels.UserResultConnection, []error) {
+		userResultConnections := make([]*gqlmodels.UserResultConnection, len(keys))
+		errors := make([]error, len(keys))
+		for i, thunk := range results {
+			userResultConnections[i], errors[i] = thunk()
+		}
+		return userResultConnections, errors
+	}
+}
+
+// Prime the cache with the provided key and value. If the key already exists, no change is made
+// and false is returned.
+// (To forcefully prime the cache, clear the key first with loader.clear(key).prime(key, value).)
+func (l *UserResultsLoader) Prime(key UserResultsKey, value *gqlmodels.UserResultConnection) bool {
+	l.mu.Lock()
+	var found bool
+	if _, found = l.cache[key]; !found {
+		// make a copy when writing to the cache, its easy to pass a pointer in from a loop var
+		// and end up with the whole cache pointing to the same value.
+		cpy := *value
+		l.unsafeSet(key, &cpy)
+	}
+	l.mu.Unlock()
+	return !found
+}
+
+// Clear the value at key from the cache, if it exists
+func (l *UserResultsLoader) Clear(key UserResultsKey) {
+	l.mu.Lock()
+	delete(l.cache, key)
+	l.mu.Unlock()
+}
+
+func (l *UserResultsLoader) unsafeSet(key UserResultsKey, value *gqlmodels.UserResultConnection) {
+	if l.cache == nil {
+		l.cache = map[UserResultsKey]*gqlmodels.UserResultConnection{}
+	}
+	l.cache[key] = value
+}
+
+// keyIndex will return the location of the key in the batch, if its not found
+// it will add the key to the batch
+func (b *userResultsLoaderBatch) keyIndex(l *UserResultsLoader, key UserResultsKey) int {
+	for i, existingKey := range b.keys {
+		if key == existingKey {
+			return i
+		}
+	}
+
+	pos := len(b.keys)
+	b.keys = append(b.keys, key)
+	if pos == 0 {
+		go b.startTimer(l)
+	}
+
+	if l.maxBatch != 0 && pos >= l.maxBatch-1 {
+		if !b.closing {
+			b.closing = true
+			l.batch = nil
+			go b.end(l)
+		}
+	}
+
+	return pos
+}
+
+func (b *userResultsLoaderBatch) startTimer(l *UserResultsLoader) {
+	time.Sleep(l.wait)
+	l.mu.Lock()
+
+	// we must have hit a batch limit and are already finalizing this batch
+	if b.closing {
+		l.mu.Unlock()
+		return
+	}
+
+	l.batch = nil
+	l.mu.Unlock()
+
+	b.end(l)
+}
+
+func (b *userResultsLoaderBatch) end(l *UserResultsLoader) {
+	b.data, b.error = l.fetch(b.keys)
+	close(b.done)
+}
@@ -0,0 +1,62 @@
+package globalid
+
+import (
+	b64 "encoding/base64"
+	"strings"
+)
+
+// Node types, they prefix the global ids.
+const (
+	User            = "User"
+	Event           = "Event"
+	Competition     = "Competition"
+	CompetitionUser = "CompetitionUser"
+)
+
+// Encode makes the opaque global id of a node, its type with the database id.
+func Encode(nodeType string, id string) string {
+	return b64.RawURLEncoding.EncodeToString([]byte(nodeType + ":" + id))
+}
+
+// Parse splits a global id into its node type and database id.
+func Parse(globalID string) (nodeType string, id string, ok bool) {
+	data, err := b64.RawURLEncoding.DecodeString(globalID)
+	if err != nil {
+		return "", "", false
+	}
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// Decode gets the database id out of a global id of the node type.
+// Database ids (and ids of other types) are returned as is, so the GQL arguments and REST routes accept both.
+func Decode(nodeType string, globalID string) string {
+	if t, id, ok := Parse(globalID); ok && t == nodeType {
+		return id
+	}
+	return globalID
+}
+
+// DecodeList gets the database ids out of a list of global ids of the node type.
+func DecodeList(nodeType string, globalIDs []string) []string {
+	if globalIDs == nil {
+		return nil
+	}
+	output := make([]string, len(globalIDs))
+	for i, globalID := range globalIDs {
+		output[i] = Decode(nodeType, globalID)
+	}
+	return output
+}
+
+// DecodeOptional gets the database id out of an optional global id of the node type.
+func DecodeOptional(nodeType string, globalID *string) *string {
+	if globalID == nil {
+		return nil
+	}
+	id := Decode(nodeType, *globalID)
+	return &id
+}
@@ -0,0 +1,261 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/app/serve/graphql/gqlmodels"
+	"nt-folly-xmaxx-comp/internal/app/serve/pagination"
+	"strings"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Complexity costs, every field costs 1 plus the cost of its selection.
+const (
+	// fetchCost is the extra cost of the fields loaded by a resolver (a query or dataloader call).
+	fetchCost = 5
+	// teamSize is the most members a Nitro Type team can have, it bounds the racer lists that aren't paginated.
+	teamSize = 50
+	// categoryCount is the expected number of categories of a competition.
+	categoryCount = 10
+	// itemCount is the expected size of the other small lists (events, adjustments, payouts and prizes).
+	itemCount = 10
+	// dayCount is the expected number of days of an event.
+	dayCount = 31
+)
+
+// pageSize is the most items a paginated field can return.
+// Invalid sizes are rejected by the resolvers, they're costed as the largest page in the meantime.
+func pageSize(first *int, last *int) int {
+	size := pagination.DefaultPageSize
+	if first != nil {
+		size = *first
+	} else if last != nil {
+		size = *last
+	}
+	if size < 0 || size > pagination.MaxPageSize {
+		return pagination.MaxPageSize
+	}
+	return size
+}
+
+// listLimit is the most items a list with a limit can return.
+// Invalid limits are rejected by the resolvers, they're costed as the largest list in the meantime.
+func listLimit(limit *int) int {
+	if limit == nil || *limit < 1 || *limit > maxListLimit {
+		return maxListLimit
+	}
+	return *limit
+}
+
+// listCost is the cost of a list field, each item costs as much as its selection.
+func listCost(size int) func(childComplexity int) int {
+	return func(childComplexity int) int {
+		return 1 + size*childComplexity
+	}
+}
+
+// fetchListCost is the cost of a list field loaded by a resolver.
+func fetchListCost(size int) func(childComplexity int) int {
+	return func(childComplexity int) int {
+		return fetchCost + size*childComplexity
+	}
+}
+
+// fetchFieldCost is the cost of a single value loaded by a resolver.
+func fetchFieldCost(childComplexity int) int {
+	return fetchCost + childComplexity
+}
+
+// newComplexityRoot sets up the cost of the list fields (multiplied by the number of items they can return)
+// and of the fields loaded by a resolver.
+func newComplexityRoot() ComplexityRoot {
+	c := ComplexityRoot{}
+
+	c.Query.Node = func(childComplexity int, id string) int {
+		return fetchFieldCost(childComplexity)
+	}
+	c.Query.Nodes = func(childComplexity int, ids []string) int {
+		return fetchCost + len(ids)*childComplexity
+	}
+	c.Query.Users = func(childComplexity int, first *int, after *string, last *int, before *string) int {
+		return fetchCost + pageSize(first, last)*childComplexity
+	}
+	c.Query.User = func(childComplexity int, id *string, username *string) int {
+		return fetchFieldCost(childComplexity)
+	}
+	c.Query.SearchUsers = func(childComplexity int, query string, limit *int, status []gqlmodels.UserStatus, membershipType *gqlmodels.MembershipType) int {
+		size := 10
+		if limit != nil {
+			size = *limit
+		}
+		if size < 1 || size > maxSearchLimit {
+			size = maxSearchLimit
+		}
+		return fetchCost + size*childComplexity
+	}
+	c.Query.Events = fetchListCost(itemCount)
+	c.Query.Event = func(childComplexity int, id string) int {
+		return fetchFieldCost(childComplexity)
+	}
+	c.Query.Competitions = func(childComplexity int, eventID *string, timeRange *gqlmodels.TimeRangeInput, first *int, after *string, last *int, before *string) int {
+		return fetchCost + pageSize(first, last)*childComplexity
+	}
+	c.Query.Standings = func(childComplexity int, eventID string, timeRange *gqlmodels.TimeRangeInput, category *string, ranking *gqlmodels.RankingStyle, limit *int) int {
+		return fetchCost + listLimit(limit)*childComplexity
+	}
+	c.Query.Compare = func(childComplexity int, userA string, userB string, timeRange *gqlmodels.TimeRangeInput) int {
+		return fetchFieldCost(childComplexity)
+	}
+	c.Query.Exclusions = func(childComplexity int, eventID *string) int {
+		return fetchCost + teamSize*childComplexity
+	}
+
+	c.User.TotalPoints = fetchFieldCost
+	c.User.Adjustments = fetchListCost(itemCount)
+	c.User.Results = func(childComplexity int, timeRange *gqlmodels.TimeRangeInput, first *int, after *string, last *int, before *string) int {
+		return fetchCost + pageSize(first, last)*childComplexity
+	}
+	c.UserResult.Categories = listCost(categoryCount)
+	c.UserResult.Adjustments = listCost(itemCount)
+
+	c.Event.Competitions = func(childComplexity int, timeRange *gqlmodels.TimeRangeInput, first *int, after *string, last *int, before *string) int {
+		return fetchCost + pageSize(first, last)*childComplexity
+	}
+	c.Event.Leaderboard = fetchListCost(teamSize)
+	c.Event.PayoutBalances = fetchListCost(teamSize)
+	c.Event.Days = func(childComplexity int, tz *string) int {
+		return 1 + dayCount*childComplexity
+	}
+	c.PayoutBalance.Payouts = listCost(itemCount)
+	c.Standing.Categories = listCost(categoryCount)
+
+	c.Comparison.Competitions = func(childComplexity int, limit *int) int {
+		return 1 + listLimit(limit)*childComplexity
+	}
+	c.Comparison.Categories = listCost(categoryCount)
+
+	c.Competition.Categories = fetchListCost(categoryCount)
+	c.Competition.Leaderboard = func(childComplexity int, category *string, limit *int, includeIneligible *bool, userIds []string, first *int, after *string, last *int, before *string) int {
+		size := pageSize(first, last)
+		if limit != nil && *limit > 0 && *limit < size {
+			size = *limit
+		}
+		if userIds != nil && len(userIds) < size {
+			size = len(userIds)
+		}
+		return fetchCost + size*childComplexity
+	}
+	c.CompetitionCategory.Rewards = listCost(itemCount)
+	c.CompetitionUser.Categories = listCost(categoryCount)
+	c.CompetitionUser.Adjustments = listCost(itemCount)
+
+	return c
+}
+
+const (
+	limitsExtension    = "QueryLimits"
+	errDepthLimit      = "DEPTH_LIMIT_EXCEEDED"
+	errComplexityLimit = "COMPLEXITY_LIMIT_EXCEEDED"
+	costExtension      = "cost"
+)
+
+// QueryCost is the cost of an operation, it's reported in the response extensions.
+type QueryCost struct {
+	Complexity    int `json:"complexity"`
+	MaxComplexity int `json:"maxComplexity"`
+	Depth         int `json:"depth"`
+	MaxDepth      int `json:"maxDepth"`
+}
+
+// QueryLimits is a GQL server extension that rejects the operations that are too deep or too complex (0 disables a limit).
+// The cost of each operation is added to the response extensions.
+type QueryLimits struct {
+	MaxDepth      int
+	MaxComplexity int
+
+	es graphql.ExecutableSchema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+	graphql.ResponseInterceptor
+} = &QueryLimits{}
+
+// ExtensionName is the name of the query limits extension.
+func (l *QueryLimits) ExtensionName() string {
+	return limitsExtension
+}
+
+// Validate keeps the schema the complexity is calculated with.
+func (l *QueryLimits) Validate(schema graphql.ExecutableSchema) error {
+	l.es = schema
+	return nil
+}
+
+// MutateOperationContext works out the cost of the operation before it's executed.
+func (l *QueryLimits) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	op := rc.Doc.Operations.ForName(rc.OperationName)
+	cost := &QueryCost{
+		Complexity:    complexity.Calculate(l.es, op, rc.Variables),
+		MaxComplexity: l.MaxComplexity,
+		Depth:         selectionDepth(op.SelectionSet),
+		MaxDepth:      l.MaxDepth,
+	}
+	rc.Stats.SetExtension(limitsExtension, cost)
+
+	if l.MaxDepth > 0 && cost.Depth > l.MaxDepth {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", cost.Depth, l.MaxDepth)
+		errcode.Set(err, errDepthLimit)
+		return err
+	}
+	if l.MaxComplexity > 0 && cost.Complexity > l.MaxComplexity {
+		err := gqlerror.Errorf("operation has complexity %d, which exceeds the limit of %d", cost.Complexity, l.MaxComplexity)
+		errcode.Set(err, errComplexityLimit)
+		return err
+	}
+	return nil
+}
+
+// InterceptResponse reports the cost of the operation.
+func (l *QueryLimits) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if graphql.HasOperationContext(ctx) {
+		if cost, ok := graphql.GetOperationContext(ctx).Stats.GetExtension(limitsExtension).(*QueryCost); ok {
+			graphql.RegisterExtension(ctx, costExtension, cost)
+		}
+	}
+	return next(ctx)
+}
+
+// selectionDepth is how deeply the fields are nested (fragments don't count as a level).
+// Introspection fields are left out, the introspection query is deeply nested but cheap.
+func selectionDepth(selectionSet ast.SelectionSet) int {
+	depth := 0
+	for _, selection := range selectionSet {
+		d := 0
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			d = 1 + selectionDepth(s.SelectionSet)
+		case *ast.InlineFragment:
+			d = selectionDepth(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				d = selectionDepth(s.Definition.SelectionSet)
+			}
+		default:
+			panic(fmt.Sprintf("unexpected selection %T", selection))
+		}
+		if d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
@@ -9,18 +9,111 @@ import (
 	"time"
 )
 
+type Node interface {
+	IsNode()
+}
+
+type Adjustment struct {
+	ID            string    `json:"id"`
+	UserID        string    `json:"userId"`
+	CompetitionID *string   `json:"competitionId"`
+	Points        int       `json:"points"`
+	Reason        string    `json:"reason"`
+	Author        string    `json:"author"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type AdjustmentInput struct {
+	UserID        string  `json:"userId"`
+	CompetitionID *string `json:"competitionId"`
+	Points        int     `json:"points"`
+	Reason        string  `json:"reason"`
+	Author        string  `json:"author"`
+}
+
+type Comparison struct {
+	UserA            *User                    `json:"userA"`
+	UserB            *User                    `json:"userB"`
+	Competitions     []*ComparisonCompetition `json:"competitions"`
+	Categories       []*ComparisonCategory    `json:"categories"`
+	RewardDifference int                      `json:"rewardDifference"`
+	StatsA           *ComparisonStats         `json:"statsA"`
+	StatsB           *ComparisonStats         `json:"statsB"`
+}
+
+type ComparisonCategory struct {
+	Name  string `json:"name"`
+	WinsA int    `json:"winsA"`
+	WinsB int    `json:"winsB"`
+	Ties  int    `json:"ties"`
+}
+
+type ComparisonCompetition struct {
+	Competition                *Competition `json:"competition"`
+	RewardA                    int          `json:"rewardA"`
+	RewardB                    int          `json:"rewardB"`
+	CumulativeRewardDifference int          `json:"cumulativeRewardDifference"`
+	CategoryWinsA              int          `json:"categoryWinsA"`
+	CategoryWinsB              int          `json:"categoryWinsB"`
+}
+
+type ComparisonStats struct {
+	Competitions    int     `json:"competitions"`
+	Rewards         int     `json:"rewards"`
+	Played          int     `json:"played"`
+	AverageSpeed    float64 `json:"averageSpeed"`
+	AverageAccuracy float64 `json:"averageAccuracy"`
+}
+
 type Competition struct {
-	ID              string              `json:"id"`
-	Status          CompetitionStatus   `json:"status"`
-	Multiplier      int                 `json:"multiplier"`
-	GrindRewards    []*CompetitionPrize `json:"grindRewards"`
-	PointRewards    []*CompetitionPrize `json:"pointRewards"`
-	SpeedRewards    []*CompetitionPrize `json:"speedRewards"`
-	AccuracyRewards []*CompetitionPrize `json:"accuracyRewards"`
-	Leaderboard     []*CompetitionUser  `json:"leaderboard"`
-	StartAt         time.Time           `json:"startAt"`
-	FinishAt        time.Time           `json:"finishAt"`
-	UpdatedAt       time.Time           `json:"updatedAt"`
+	ID                  string                     `json:"id"`
+	Status              CompetitionStatus          `json:"status"`
+	Multiplier          int                        `json:"multiplier"`
+	TiePolicy           TiePolicy                  `json:"tiePolicy"`
+	TieBreaker          *CategoryMetric            `json:"tieBreaker"`
+	TieBreakerDirection SortDirection              `json:"tieBreakerDirection"`
+	MinRaces            int                        `json:"minRaces"`
+	MinSecs             int                        `json:"minSecs"`
+	MinTyped            int                        `json:"minTyped"`
+	Categories          []*CompetitionCategory     `json:"categories"`
+	Leaderboard         *CompetitionUserConnection `json:"leaderboard"`
+	StartAt             time.Time                  `json:"startAt"`
+	FinishAt            time.Time                  `json:"finishAt"`
+	ProvisionalAt       *time.Time                 `json:"provisionalAt"`
+	UpdatedAt           time.Time                  `json:"updatedAt"`
+}
+
+func (Competition) IsNode() {}
+
+type CompetitionCategory struct {
+	ID                  string              `json:"id"`
+	Name                string              `json:"name"`
+	Metric              CategoryMetric      `json:"metric"`
+	SortDirection       SortDirection       `json:"sortDirection"`
+	TieBreaker          *CategoryMetric     `json:"tieBreaker"`
+	TieBreakerDirection SortDirection       `json:"tieBreakerDirection"`
+	MinRaces            int                 `json:"minRaces"`
+	MinSecs             int                 `json:"minSecs"`
+	MinTyped            int                 `json:"minTyped"`
+	Rewards             []*CompetitionPrize `json:"rewards"`
+}
+
+type CompetitionConnection struct {
+	Edges      []*CompetitionEdge `json:"edges"`
+	PageInfo   *PageInfo          `json:"pageInfo"`
+	TotalCount int                `json:"totalCount"`
+}
+
+type CompetitionEdge struct {
+	Cursor string       `json:"cursor"`
+	Node   *Competition `json:"node"`
+}
+
+type CompetitionInput struct {
+	EventID    string    `json:"eventId"`
+	TimeFrom   time.Time `json:"timeFrom"`
+	TimeTo     time.Time `json:"timeTo"`
+	Multiplier *int      `json:"multiplier"`
 }
 
 type CompetitionPrize struct {
@@ -28,21 +121,185 @@ type CompetitionPrize struct {
 	Points int `json:"points"`
 }
 
+type CompetitionUpdateInput struct {
+	TimeFrom *time.Time `json:"timeFrom"`
+	TimeTo   *time.Time `json:"timeTo"`
+	MinRaces *int       `json:"minRaces"`
+	MinSecs  *int       `json:"minSecs"`
+	MinTyped *int       `json:"minTyped"`
+}
+
 type CompetitionUser struct {
-	ID             string  `json:"id"`
-	User           *User   `json:"user"`
-	GrindScore     int     `json:"grindScore"`
-	GrindRank      int     `json:"grindRank"`
-	GrindReward    int     `json:"grindReward"`
-	SpeedScore     float64 `json:"speedScore"`
-	SpeedRank      int     `json:"speedRank"`
-	SpeedReward    int     `json:"speedReward"`
-	AccuracyScore  float64 `json:"accuracyScore"`
-	AccuracyRank   int     `json:"accuracyRank"`
-	AccuracyReward int     `json:"accuracyReward"`
-	PointScore     int     `json:"pointScore"`
-	PointRank      int     `json:"pointRank"`
-	PointReward    int     `json:"pointReward"`
+	ID               string                     `json:"id"`
+	User             *User                      `json:"user"`
+	Eligible         bool                       `json:"eligible"`
+	IneligibleReason *string                    `json:"ineligibleReason"`
+	TotalPoints      int                        `json:"totalPoints"`
+	Categories       []*CompetitionUserCategory `json:"categories"`
+	Adjustments      []*Adjustment              `json:"adjustments"`
+}
+
+func (CompetitionUser) IsNode() {}
+
+type CompetitionUserCategory struct {
+	Name             string  `json:"name"`
+	Eligible         bool    `json:"eligible"`
+	IneligibleReason *string `json:"ineligibleReason"`
+	Rank             *int    `json:"rank"`
+	Tied             int     `json:"tied"`
+	Score            float64 `json:"score"`
+	Reward           int     `json:"reward"`
+}
+
+type CompetitionUserConnection struct {
+	Edges       []*CompetitionUserEdge `json:"edges"`
+	PageInfo    *PageInfo              `json:"pageInfo"`
+	TotalCount  int                    `json:"totalCount"`
+	Provisional bool                   `json:"provisional"`
+}
+
+type CompetitionUserEdge struct {
+	Cursor string           `json:"cursor"`
+	Node   *CompetitionUser `json:"node"`
+}
+
+type Event struct {
+	ID             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	TeamTag        string                 `json:"teamTag"`
+	Rules          string                 `json:"rules"`
+	Timezone       string                 `json:"timezone"`
+	WindowMinutes  int                    `json:"windowMinutes"`
+	TiePolicy      TiePolicy              `json:"tiePolicy"`
+	Competitions   *CompetitionConnection `json:"competitions"`
+	Leaderboard    []*EventUser           `json:"leaderboard"`
+	PayoutRate     float64                `json:"payoutRate"`
+	PayoutBalances []*PayoutBalance       `json:"payoutBalances"`
+	Days           []*EventDay            `json:"days"`
+	StartAt        time.Time              `json:"startAt"`
+	FinishAt       time.Time              `json:"finishAt"`
+	CreatedAt      time.Time              `json:"createdAt"`
+	UpdatedAt      time.Time              `json:"updatedAt"`
+}
+
+func (Event) IsNode() {}
+
+type EventDay struct {
+	Date     string    `json:"date"`
+	StartAt  time.Time `json:"startAt"`
+	FinishAt time.Time `json:"finishAt"`
+}
+
+type EventInput struct {
+	Name                string          `json:"name"`
+	TeamTag             string          `json:"teamTag"`
+	TeamID              int             `json:"teamId"`
+	Rules               *string         `json:"rules"`
+	TimeFrom            time.Time       `json:"timeFrom"`
+	TimeTo              time.Time       `json:"timeTo"`
+	Timezone            *string         `json:"timezone"`
+	WindowMinutes       *int            `json:"windowMinutes"`
+	TiePolicy           *TiePolicy      `json:"tiePolicy"`
+	TieBreaker          *CategoryMetric `json:"tieBreaker"`
+	TieBreakerDirection *SortDirection  `json:"tieBreakerDirection"`
+	MinRaces            *int            `json:"minRaces"`
+	MinSecs             *int            `json:"minSecs"`
+	MinTyped            *int            `json:"minTyped"`
+	PayoutRate          *float64        `json:"payoutRate"`
+}
+
+type EventUpdateInput struct {
+	Name       *string  `json:"name"`
+	Rules      *string  `json:"rules"`
+	PayoutRate *float64 `json:"payoutRate"`
+}
+
+type EventUser struct {
+	ID          string `json:"id"`
+	User        *User  `json:"user"`
+	Rank        int    `json:"rank"`
+	TotalPoints int    `json:"totalPoints"`
+}
+
+type Exclusion struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	EventID   *string   `json:"eventId"`
+	Reason    string    `json:"reason"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ExclusionInput struct {
+	UserID  string  `json:"userId"`
+	EventID *string `json:"eventId"`
+	Reason  string  `json:"reason"`
+	Author  string  `json:"author"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+type Payout struct {
+	ID          string       `json:"id"`
+	EventID     string       `json:"eventId"`
+	UserID      string       `json:"userId"`
+	Amount      int          `json:"amount"`
+	Status      PayoutStatus `json:"status"`
+	Note        string       `json:"note"`
+	SentAt      *time.Time   `json:"sentAt"`
+	ConfirmedAt *time.Time   `json:"confirmedAt"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+type PayoutBalance struct {
+	ID          string    `json:"id"`
+	User        *User     `json:"user"`
+	Points      int       `json:"points"`
+	Earned      int       `json:"earned"`
+	Pending     int       `json:"pending"`
+	Sent        int       `json:"sent"`
+	Confirmed   int       `json:"confirmed"`
+	Outstanding int       `json:"outstanding"`
+	Payouts     []*Payout `json:"payouts"`
+}
+
+type PayoutInput struct {
+	EventID string  `json:"eventId"`
+	UserID  string  `json:"userId"`
+	Amount  int     `json:"amount"`
+	Note    *string `json:"note"`
+}
+
+type Standing struct {
+	ID              string              `json:"id"`
+	User            *User               `json:"user"`
+	Rank            int                 `json:"rank"`
+	Tied            int                 `json:"tied"`
+	TotalPoints     int                 `json:"totalPoints"`
+	Rewards         int                 `json:"rewards"`
+	CompetitionsWon int                 `json:"competitionsWon"`
+	GapToNextRank   *int                `json:"gapToNextRank"`
+	Categories      []*StandingCategory `json:"categories"`
+}
+
+type StandingCategory struct {
+	Name         string `json:"name"`
+	Rewards      int    `json:"rewards"`
+	Competitions int    `json:"competitions"`
+	Wins         int    `json:"wins"`
+}
+
+type SyncStatus struct {
+	TeamTag  string    `json:"teamTag"`
+	EventIds []string  `json:"eventIds"`
+	Status   SyncState `json:"status"`
+	SyncedAt time.Time `json:"syncedAt"`
 }
 
 type TimeRangeInput struct {
@@ -51,14 +308,122 @@ type TimeRangeInput struct {
 }
 
 type User struct {
-	ID             string         `json:"id"`
-	Username       string         `json:"username"`
-	DisplayName    string         `json:"displayName"`
-	MembershipType MembershipType `json:"membershipType"`
-	TotalPoints    int            `json:"totalPoints"`
-	Status         UserStatus     `json:"status"`
-	CreatedAt      time.Time      `json:"createdAt"`
-	UpdatedAt      time.Time      `json:"updatedAt"`
+	ID             string                `json:"id"`
+	Username       string                `json:"username"`
+	DisplayName    string                `json:"displayName"`
+	MembershipType MembershipType        `json:"membershipType"`
+	TotalPoints    int                   `json:"totalPoints"`
+	Adjustments    []*Adjustment         `json:"adjustments"`
+	Results        *UserResultConnection `json:"results"`
+	Status         UserStatus            `json:"status"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	UpdatedAt      time.Time             `json:"updatedAt"`
+}
+
+func (User) IsNode() {}
+
+type UserConnection struct {
+	Edges      []*UserEdge `json:"edges"`
+	PageInfo   *PageInfo   `json:"pageInfo"`
+	TotalCount int         `json:"totalCount"`
+}
+
+type UserEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *User  `json:"node"`
+}
+
+type UserResult struct {
+	ID               string                     `json:"id"`
+	Competition      *Competition               `json:"competition"`
+	Eligible         bool                       `json:"eligible"`
+	IneligibleReason *string                    `json:"ineligibleReason"`
+	TotalPoints      int                        `json:"totalPoints"`
+	Categories       []*CompetitionUserCategory `json:"categories"`
+	Adjustments      []*Adjustment              `json:"adjustments"`
+}
+
+type UserResultConnection struct {
+	Edges      []*UserResultEdge `json:"edges"`
+	PageInfo   *PageInfo         `json:"pageInfo"`
+	TotalCount int               `json:"totalCount"`
+	Totals     *UserResultTotals `json:"totals"`
+}
+
+type UserResultEdge struct {
+	Cursor string      `json:"cursor"`
+	Node   *UserResult `json:"node"`
+}
+
+type UserResultTotals struct {
+	Competitions    int     `json:"competitions"`
+	Rewards         int     `json:"rewards"`
+	AverageReward   float64 `json:"averageReward"`
+	Played          int     `json:"played"`
+	AverageSpeed    float64 `json:"averageSpeed"`
+	AverageAccuracy float64 `json:"averageAccuracy"`
+}
+
+type UserSearchResult struct {
+	User          *User    `json:"user"`
+	Similarity    float64  `json:"similarity"`
+	MatchedName   string   `json:"matchedName"`
+	PastUsernames []string `json:"pastUsernames"`
+}
+
+type CategoryMetric string
+
+const (
+	CategoryMetricPlayed           CategoryMetric = "PLAYED"
+	CategoryMetricTyped            CategoryMetric = "TYPED"
+	CategoryMetricErrs             CategoryMetric = "ERRS"
+	CategoryMetricSecs             CategoryMetric = "SECS"
+	CategoryMetricAccuracy         CategoryMetric = "ACCURACY"
+	CategoryMetricSpeed            CategoryMetric = "SPEED"
+	CategoryMetricPoints           CategoryMetric = "POINTS"
+	CategoryMetricErrorsPerRace    CategoryMetric = "ERRORS_PER_RACE"
+	CategoryMetricSpeedImprovement CategoryMetric = "SPEED_IMPROVEMENT"
+)
+
+var AllCategoryMetric = []CategoryMetric{
+	CategoryMetricPlayed,
+	CategoryMetricTyped,
+	CategoryMetricErrs,
+	CategoryMetricSecs,
+	CategoryMetricAccuracy,
+	CategoryMetricSpeed,
+	CategoryMetricPoints,
+	CategoryMetricErrorsPerRace,
+	CategoryMetricSpeedImprovement,
+}
+
+func (e CategoryMetric) IsValid() bool {
+	switch e {
+	case CategoryMetricPlayed, CategoryMetricTyped, CategoryMetricErrs, CategoryMetricSecs, CategoryMetricAccuracy, CategoryMetricSpeed, CategoryMetricPoints, CategoryMetricErrorsPerRace, CategoryMetricSpeedImprovement:
+		return true
+	}
+	return false
+}
+
+func (e CategoryMetric) String() string {
+	return string(e)
+}
+
+func (e *CategoryMetric) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CategoryMetric(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CategoryMetric", str)
+	}
+	return nil
+}
+
+func (e CategoryMetric) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
 type CompetitionStatus string
@@ -147,6 +512,215 @@ func (e MembershipType) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type PayoutStatus string
+
+const (
+	PayoutStatusPending   PayoutStatus = "PENDING"
+	PayoutStatusSent      PayoutStatus = "SENT"
+	PayoutStatusConfirmed PayoutStatus = "CONFIRMED"
+)
+
+var AllPayoutStatus = []PayoutStatus{
+	PayoutStatusPending,
+	PayoutStatusSent,
+	PayoutStatusConfirmed,
+}
+
+func (e PayoutStatus) IsValid() bool {
+	switch e {
+	case PayoutStatusPending, PayoutStatusSent, PayoutStatusConfirmed:
+		return true
+	}
+	return false
+}
+
+func (e PayoutStatus) String() string {
+	return string(e)
+}
+
+func (e *PayoutStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PayoutStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PayoutStatus", str)
+	}
+	return nil
+}
+
+func (e PayoutStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type RankingStyle string
+
+const (
+	RankingStyleStandard RankingStyle = "STANDARD"
+	RankingStyleDense    RankingStyle = "DENSE"
+)
+
+var AllRankingStyle = []RankingStyle{
+	RankingStyleStandard,
+	RankingStyleDense,
+}
+
+func (e RankingStyle) IsValid() bool {
+	switch e {
+	case RankingStyleStandard, RankingStyleDense:
+		return true
+	}
+	return false
+}
+
+func (e RankingStyle) String() string {
+	return string(e)
+}
+
+func (e *RankingStyle) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RankingStyle(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RankingStyle", str)
+	}
+	return nil
+}
+
+func (e RankingStyle) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SortDirection string
+
+const (
+	SortDirectionAsc  SortDirection = "ASC"
+	SortDirectionDesc SortDirection = "DESC"
+)
+
+var AllSortDirection = []SortDirection{
+	SortDirectionAsc,
+	SortDirectionDesc,
+}
+
+func (e SortDirection) IsValid() bool {
+	switch e {
+	case SortDirectionAsc, SortDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e SortDirection) String() string {
+	return string(e)
+}
+
+func (e *SortDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SortDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SortDirection", str)
+	}
+	return nil
+}
+
+func (e SortDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SyncState string
+
+const (
+	SyncStateSuccess SyncState = "SUCCESS"
+	SyncStateFailed  SyncState = "FAILED"
+)
+
+var AllSyncState = []SyncState{
+	SyncStateSuccess,
+	SyncStateFailed,
+}
+
+func (e SyncState) IsValid() bool {
+	switch e {
+	case SyncStateSuccess, SyncStateFailed:
+		return true
+	}
+	return false
+}
+
+func (e SyncState) String() string {
+	return string(e)
+}
+
+func (e *SyncState) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SyncState(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SyncState", str)
+	}
+	return nil
+}
+
+func (e SyncState) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TiePolicy string
+
+const (
+	TiePolicyShare TiePolicy = "SHARE"
+	TiePolicyFull  TiePolicy = "FULL"
+	TiePolicyBreak TiePolicy = "BREAK"
+)
+
+var AllTiePolicy = []TiePolicy{
+	TiePolicyShare,
+	TiePolicyFull,
+	TiePolicyBreak,
+}
+
+func (e TiePolicy) IsValid() bool {
+	switch e {
+	case TiePolicyShare, TiePolicyFull, TiePolicyBreak:
+		return true
+	}
+	return false
+}
+
+func (e TiePolicy) String() string {
+	return string(e)
+}
+
+func (e *TiePolicy) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TiePolicy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TiePolicy", str)
+	}
+	return nil
+}
+
+func (e TiePolicy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type UserStatus string
 
 const (
@@ -0,0 +1,75 @@
+package graphql
+
+import (
+	"context"
+	"nt-folly-xmaxx-comp/internal/app/serve/dataloaders"
+	"nt-folly-xmaxx-comp/internal/app/serve/globalid"
+	"nt-folly-xmaxx-comp/internal/app/serve/graphql/gqlmodels"
+	"nt-folly-xmaxx-comp/internal/pkg/utils"
+	"strings"
+)
+
+// loadNode queues the node of a global id in its dataloader, the returned thunk waits for it.
+// Unknown ids load nothing, so the nodes of a list can be fetched together.
+func loadNode(ctx context.Context, globalID string) func() (gqlmodels.Node, error) {
+	notFound := func() (gqlmodels.Node, error) {
+		return nil, nil
+	}
+	nodeType, id, ok := globalid.Parse(globalID)
+	if !ok {
+		return notFound
+	}
+	loaders := dataloaders.GetLoadersFromContext(ctx)
+	switch nodeType {
+	case globalid.User:
+		if !utils.IsValidID(id) {
+			return notFound
+		}
+		thunk := loaders.UserByID.LoadThunk(id)
+		return func() (gqlmodels.Node, error) {
+			user, err := thunk()
+			if err != nil || user == nil {
+				return nil, err
+			}
+			return user, nil
+		}
+	case globalid.Event:
+		if !utils.IsValidID(id) {
+			return notFound
+		}
+		thunk := loaders.EventByID.LoadThunk(id)
+		return func() (gqlmodels.Node, error) {
+			event, err := thunk()
+			if err != nil || event == nil {
+				return nil, err
+			}
+			return event, nil
+		}
+	case globalid.Competition:
+		if !utils.IsValidID(id) {
+			return notFound
+		}
+		thunk := loaders.CompetitionByID.LoadThunk(id)
+		return func() (gqlmodels.Node, error) {
+			competition, err := thunk()
+			if err != nil || competition == nil {
+				return nil, err
+			}
+			return competition, nil
+		}
+	case globalid.CompetitionUser:
+		parts := strings.SplitN(id, "::", 2)
+		if len(parts) != 2 || !utils.IsValidID(parts[0]) || !utils.IsValidID(parts[1]) {
+			return notFound
+		}
+		thunk := loaders.CompetitionUserByID.LoadThunk(id)
+		return func() (gqlmodels.Node, error) {
+			competitionUser, err := thunk()
+			if err != nil || competitionUser == nil {
+				return nil, err
+			}
+			return competitionUser, nil
+		}
+	}
+	return notFound
+}
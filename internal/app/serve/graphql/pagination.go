@@ -0,0 +1,55 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"nt-folly-xmaxx-comp/internal/app/serve/graphql/gqlmodels"
+	"nt-folly-xmaxx-comp/internal/app/serve/pagination"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// getPageArgs validates the Relay pagination arguments.
+func getPageArgs(ctx context.Context, first *int, after *string, last *int, before *string) (pagination.Args, error) {
+	args, err := pagination.NewArgs(first, after, last, before)
+	if err != nil {
+		return args, paginationError(ctx, err)
+	}
+	return args, nil
+}
+
+// paginationError converts the pagination errors into GQL errors (other errors are returned as is).
+func paginationError(ctx context.Context, err error) error {
+	message, code := "", ""
+	switch {
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		message, code = "Invalid cursor received", "INVALID_CURSOR"
+	case errors.Is(err, pagination.ErrInvalidPageSize):
+		message, code = "Invalid page size received", "INVALID_PAGE_SIZE"
+	case errors.Is(err, pagination.ErrInvalidArgs):
+		message, code = "First and last can't be used together", "INVALID_ARGUMENTS"
+	default:
+		return err
+	}
+	return &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: message,
+		Extensions: map[string]interface{}{
+			"code": code,
+		},
+	}
+}
+
+// getPageInfo builds the page info out of the window of the page and the cursors of its edges.
+func getPageInfo(hasPreviousPage bool, hasNextPage bool, cursors []string) *gqlmodels.PageInfo {
+	output := &gqlmodels.PageInfo{
+		HasPreviousPage: hasPreviousPage,
+		HasNextPage:     hasNextPage,
+	}
+	if len(cursors) > 0 {
+		output.StartCursor = &cursors[0]
+		output.EndCursor = &cursors[len(cursors)-1]
+	}
+	return output
+}
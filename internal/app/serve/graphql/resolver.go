@@ -2,21 +2,45 @@ package graphql
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"nt-folly-xmaxx-comp/internal/app/serve/auth"
+	"nt-folly-xmaxx-comp/internal/app/serve/cache"
 	"nt-folly-xmaxx-comp/internal/app/serve/dataloaders"
+	"nt-folly-xmaxx-comp/internal/app/serve/globalid"
 	"nt-folly-xmaxx-comp/internal/app/serve/graphql/gqlmodels"
+	"nt-folly-xmaxx-comp/internal/app/serve/pagination"
+	"nt-folly-xmaxx-comp/internal/app/serve/pubsub"
+	"nt-folly-xmaxx-comp/internal/pkg/adjustments"
+	"nt-folly-xmaxx-comp/internal/pkg/exclusions"
+	"nt-folly-xmaxx-comp/internal/pkg/manage"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"nt-folly-xmaxx-comp/internal/pkg/payouts"
+	"nt-folly-xmaxx-comp/internal/pkg/provisional"
+	"nt-folly-xmaxx-comp/internal/pkg/reports"
+	"nt-folly-xmaxx-comp/internal/pkg/seed"
 	"nt-folly-xmaxx-comp/internal/pkg/utils"
+	"nt-folly-xmaxx-comp/pkg/nitrotype"
+	"strings"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 	"go.uber.org/zap"
 )
 
 // Resolver contains the GQL resolvers.
+// The leaderboards and standings are kept in the cache until their competition or event is updated.
 type Resolver struct {
-	Conn *pgxpool.Pool
-	Log  *zap.Logger
+	Conn      *pgxpool.Pool
+	Broker    *pubsub.Broker
+	APIClient nitrotype.APIClient
+	Cache     *cache.Cache
+	Log       *zap.Logger
 }
 
 // getTimeRangeRounded will round of the dates between the nearest X:X1 minute.
@@ -37,6 +61,46 @@ func getTimeRangeRounded(timeRange *gqlmodels.TimeRangeInput) (*gqlmodels.TimeRa
 	return output, nil
 }
 
+// getTimezone loads the timezone requested by the tz argument (nil if no timezone was requested).
+func getTimezone(ctx context.Context, tz *string) (*time.Location, error) {
+	if tz == nil {
+		return nil, nil
+	}
+	loc, err := utils.LoadTimezone(*tz)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Invalid timezone received",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_TIMEZONE",
+			},
+		}
+	}
+	return loc, nil
+}
+
+// localizeTime converts the time into the timezone requested by the tz argument.
+func localizeTime(ctx context.Context, t time.Time, tz *string) (*time.Time, error) {
+	loc, err := getTimezone(ctx, tz)
+	if err != nil {
+		return nil, err
+	}
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return &t, nil
+}
+
+// readsCache checks whether the results can be read from the cache.
+// Mutations and subscriptions skip it, the notification invalidating their results may not have arrived yet.
+func readsCache(ctx context.Context) bool {
+	if !graphql.HasOperationContext(ctx) {
+		return false
+	}
+	op := graphql.GetOperationContext(ctx).Operation
+	return op != nil && op.Operation == ast.Query
+}
+
 ////////////
 //  User  //
 ////////////
@@ -47,79 +111,1210 @@ func (r *Resolver) User() UserResolver {
 	return &userResolver{r}
 }
 
-func (r *userResolver) TotalPoints(ctx context.Context, obj *gqlmodels.User) (int, error) {
-	pointLoader := dataloaders.GetLoadersFromContext(ctx).UserTotalPointsByID
-	output, err := pointLoader.Load(obj.ID)
+func (r *userResolver) ID(ctx context.Context, obj *gqlmodels.User) (string, error) {
+	return globalid.Encode(globalid.User, obj.ID), nil
+}
+
+func (r *userResolver) TotalPoints(ctx context.Context, obj *gqlmodels.User) (int, error) {
+	pointLoader := dataloaders.GetLoadersFromContext(ctx).UserTotalPointsByID
+	output, err := pointLoader.Load(obj.ID)
+	if err != nil {
+		return 0, fmt.Errorf("totalPoints dataloader failed: %w", err)
+	}
+	return output, nil
+}
+
+func (r *userResolver) Adjustments(ctx context.Context, obj *gqlmodels.User) ([]*gqlmodels.Adjustment, error) {
+	adjustmentLoader := dataloaders.GetLoadersFromContext(ctx).UserAdjustmentsByID
+	output, err := adjustmentLoader.Load(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("adjustments dataloader failed: %w", err)
+	}
+	return output, nil
+}
+
+// Results lists the competitions the user has placed in, most recent first.
+func (r *userResolver) Results(ctx context.Context, obj *gqlmodels.User, timeRange *gqlmodels.TimeRangeInput, first *int, after *string, last *int, before *string) (*gqlmodels.UserResultConnection, error) {
+	timeRange, err := getTimeRangeRounded(timeRange)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Invalid time range received",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_TIMERANGE",
+			},
+		}
+	}
+	pageArgs, err := getPageArgs(ctx, first, after, last, before)
+	if err != nil {
+		return nil, err
+	}
+	key := dataloaders.UserResultsKey{ID: obj.ID, Page: pageArgs}
+	if timeRange != nil {
+		key.TimeFrom = timeRange.TimeFrom
+		key.TimeTo = timeRange.TimeTo
+	}
+	resultsLoader := dataloaders.GetLoadersFromContext(ctx).UserResultsByID
+	output, err := resultsLoader.Load(key)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		return nil, paginationError(ctx, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("results dataloader failed: %w", err)
+	}
+	return output, nil
+}
+
+/////////////
+//  Event  //
+/////////////
+
+type eventResolver struct{ *Resolver }
+
+func (r *Resolver) Event() EventResolver {
+	return &eventResolver{r}
+}
+
+func (r *eventResolver) ID(ctx context.Context, obj *gqlmodels.Event) (string, error) {
+	return globalid.Encode(globalid.Event, obj.ID), nil
+}
+
+func (r *eventResolver) Competitions(ctx context.Context, obj *gqlmodels.Event, timeRange *gqlmodels.TimeRangeInput, first *int, after *string, last *int, before *string) (*gqlmodels.CompetitionConnection, error) {
+	return r.getCompetitions(ctx, &obj.ID, timeRange, first, after, last, before)
+}
+
+func (r *eventResolver) Leaderboard(ctx context.Context, obj *gqlmodels.Event) ([]*gqlmodels.EventUser, error) {
+	if readsCache(ctx) {
+		if cached, ok := r.Cache.Get("eventLeaderboard", obj.ID); ok {
+			return cached.([]*gqlmodels.EventUser), nil
+		}
+	}
+	generation := r.Cache.Generation()
+	leaderboardLoader := dataloaders.GetLoadersFromContext(ctx).EventLeaderboardByID
+	output, err := leaderboardLoader.Load(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("leaderboard dataloader failed: %w", err)
+	}
+	r.Cache.Set("eventLeaderboard", obj.ID, generation, output, cache.EventTag(obj.ID))
+	return output, nil
+}
+
+// Days lists the calendar days of the event in the requested timezone (the event timezone by default).
+// The first and last day are cut to the event's start and finish.
+func (r *eventResolver) Days(ctx context.Context, obj *gqlmodels.Event, tz *string) ([]*gqlmodels.EventDay, error) {
+	if tz == nil {
+		tz = &obj.Timezone
+	}
+	loc, err := getTimezone(ctx, tz)
+	if err != nil {
+		return nil, err
+	}
+	output := []*gqlmodels.EventDay{}
+	dayStartAt, dayFinishAt := utils.DayBounds(obj.StartAt, loc)
+	for dayStartAt.Before(obj.FinishAt) {
+		row := &gqlmodels.EventDay{
+			Date:     dayStartAt.Format("2006-01-02"),
+			StartAt:  dayStartAt,
+			FinishAt: dayFinishAt,
+		}
+		if row.StartAt.Before(obj.StartAt) {
+			row.StartAt = obj.StartAt.In(loc)
+		}
+		if row.FinishAt.After(obj.FinishAt) {
+			row.FinishAt = obj.FinishAt.In(loc)
+		}
+		output = append(output, row)
+		dayStartAt, dayFinishAt = dayFinishAt, dayFinishAt.AddDate(0, 0, 1)
+	}
+	return output, nil
+}
+
+func (r *eventResolver) StartAt(ctx context.Context, obj *gqlmodels.Event, tz *string) (*time.Time, error) {
+	return localizeTime(ctx, obj.StartAt, tz)
+}
+
+func (r *eventResolver) FinishAt(ctx context.Context, obj *gqlmodels.Event, tz *string) (*time.Time, error) {
+	return localizeTime(ctx, obj.FinishAt, tz)
+}
+
+func (r *eventResolver) PayoutBalances(ctx context.Context, obj *gqlmodels.Event) ([]*gqlmodels.PayoutBalance, error) {
+	balanceLoader := dataloaders.GetLoadersFromContext(ctx).EventPayoutBalancesByID
+	output, err := balanceLoader.Load(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("payoutBalances dataloader failed: %w", err)
+	}
+	return output, nil
+}
+
+///////////////////
+//  Competition  //
+///////////////////
+
+type competitionResolver struct{ *Resolver }
+
+func (r *Resolver) Competition() CompetitionResolver {
+	return &competitionResolver{r}
+}
+
+func (r *competitionResolver) ID(ctx context.Context, obj *gqlmodels.Competition) (string, error) {
+	return globalid.Encode(globalid.Competition, obj.ID), nil
+}
+
+func (r *competitionResolver) Categories(ctx context.Context, obj *gqlmodels.Competition) ([]*gqlmodels.CompetitionCategory, error) {
+	categoryLoader := dataloaders.GetLoadersFromContext(ctx).CompetitionCategoriesByID
+	output, err := categoryLoader.Load(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("categories dataloader failed: %w", err)
+	}
+	return output, nil
+}
+
+func (r *competitionResolver) StartAt(ctx context.Context, obj *gqlmodels.Competition, tz *string) (*time.Time, error) {
+	return localizeTime(ctx, obj.StartAt, tz)
+}
+
+func (r *competitionResolver) FinishAt(ctx context.Context, obj *gqlmodels.Competition, tz *string) (*time.Time, error) {
+	return localizeTime(ctx, obj.FinishAt, tz)
+}
+
+// Leaderboard lists the racers of the competition, ordered by their points (or their rank when a category is given).
+// The limit only keeps the top racers, which can then be paginated through.
+func (r *competitionResolver) Leaderboard(ctx context.Context, obj *gqlmodels.Competition, category *string, limit *int, includeIneligible *bool, userIds []string, first *int, after *string, last *int, before *string) (*gqlmodels.CompetitionUserConnection, error) {
+	userIds = globalid.DecodeList(globalid.User, userIds)
+	pageArgs, err := getPageArgs(ctx, first, after, last, before)
+	if err != nil {
+		return nil, err
+	}
+	if limit != nil && *limit < 1 {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Limit must be at least 1",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_LIMIT",
+			},
+		}
+	}
+	if userIds != nil {
+		validIDs := []string{}
+		for _, id := range userIds {
+			if utils.IsValidID(id) {
+				validIDs = append(validIDs, id)
+			}
+		}
+		userIds = validIDs
+	}
+	if obj.Status != gqlmodels.CompetitionStatusFinished && obj.Status != gqlmodels.CompetitionStatusStarted {
+		return &gqlmodels.CompetitionUserConnection{
+			Edges:    []*gqlmodels.CompetitionUserEdge{},
+			PageInfo: getPageInfo(false, false, nil),
+		}, nil
+	}
+	key := dataloaders.NewLeaderboardKey(obj.ID, pageArgs, category, limit, includeIneligible == nil || *includeIneligible, userIds)
+	output, err := r.getCompetitionLeaderboard(ctx, key)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		return nil, paginationError(ctx, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Started competitions only have the provisional results (the loader output is shared, so it's copied)
+	if obj.Status == gqlmodels.CompetitionStatusStarted {
+		provisional := *output
+		provisional.Provisional = true
+		return &provisional, nil
+	}
+	return output, nil
+}
+
+// getCompetitionLeaderboard loads a leaderboard page, from the cache if it was loaded since the competition last changed.
+func (r *competitionResolver) getCompetitionLeaderboard(ctx context.Context, key dataloaders.LeaderboardKey) (*gqlmodels.CompetitionUserConnection, error) {
+	if readsCache(ctx) {
+		if cached, ok := r.Cache.Get("competitionLeaderboard", key.String()); ok {
+			return cached.(*gqlmodels.CompetitionUserConnection), nil
+		}
+	}
+	generation := r.Cache.Generation()
+	leaderboardLoader := dataloaders.GetLoadersFromContext(ctx).CompetitionLeaderboardByID
+	output, err := leaderboardLoader.Load(key)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("leaderboard dataloader failed: %w", err)
+	}
+	r.Cache.Set("competitionLeaderboard", key.String(), generation, output, cache.CompetitionTag(key.ID))
+	return output, nil
+}
+
+///////////////////////
+//  CompetitionUser  //
+///////////////////////
+
+type competitionUserResolver struct{ *Resolver }
+
+func (r *Resolver) CompetitionUser() CompetitionUserResolver {
+	return &competitionUserResolver{r}
+}
+
+func (r *competitionUserResolver) ID(ctx context.Context, obj *gqlmodels.CompetitionUser) (string, error) {
+	return globalid.Encode(globalid.CompetitionUser, obj.ID), nil
+}
+
+//////////////////
+//  Comparison  //
+//////////////////
+
+type comparisonResolver struct{ *Resolver }
+
+func (r *Resolver) Comparison() ComparisonResolver {
+	return &comparisonResolver{r}
+}
+
+// Competitions is a field resolver that returns the most recent competitions of a comparison (oldest first).
+// The totals and the cumulative reward differences still cover every competition.
+func (r *comparisonResolver) Competitions(ctx context.Context, obj *gqlmodels.Comparison, limit *int) ([]*gqlmodels.ComparisonCompetition, error) {
+	size, err := getListLimit(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(obj.Competitions) > size {
+		return obj.Competitions[len(obj.Competitions)-size:], nil
+	}
+	return obj.Competitions, nil
+}
+
+////////////////
+//  Mutation  //
+////////////////
+
+type mutationResolver struct{ *Resolver }
+
+func (r *Resolver) Mutation() MutationResolver {
+	return &mutationResolver{r}
+}
+
+// requireAdmin is the @admin directive, it rejects the request if it wasn't made with an API key.
+func requireAdmin(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	if auth.IsAdmin(ctx) {
+		return next(ctx)
+	}
+	return nil, &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: "Admin access is required",
+		Extensions: map[string]interface{}{
+			"code": "FORBIDDEN",
+		},
+	}
+}
+
+// notFoundError is the GQL error returned when the requested record doesn't exist.
+func notFoundError(ctx context.Context, message string) error {
+	return &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: message,
+		Extensions: map[string]interface{}{
+			"code": "NOT_FOUND",
+		},
+	}
+}
+
+// CreateEvent is a mutation resolver that sets up an event and its competitions.
+func (r *mutationResolver) CreateEvent(ctx context.Context, input gqlmodels.EventInput) (*gqlmodels.Event, error) {
+	config := seed.CompetitionConfig{
+		EventName:           strings.TrimSpace(input.Name),
+		TeamTag:             strings.TrimSpace(input.TeamTag),
+		TeamID:              input.TeamID,
+		TimeFrom:            input.TimeFrom,
+		TimeTo:              input.TimeTo,
+		WindowMinutes:       10,
+		TiePolicy:           gqlmodels.TiePolicyFull.String(),
+		TieBreakerDirection: gqlmodels.SortDirectionDesc.String(),
+		MinRaces:            1,
+		PayoutRate:          1,
+	}
+	if input.Rules != nil {
+		config.Rules = *input.Rules
+	}
+	if input.Timezone != nil {
+		config.Timezone = *input.Timezone
+	}
+	if input.WindowMinutes != nil {
+		config.WindowMinutes = *input.WindowMinutes
+	}
+	if input.TiePolicy != nil {
+		config.TiePolicy = input.TiePolicy.String()
+	}
+	if input.TieBreaker != nil {
+		tieBreaker := input.TieBreaker.String()
+		config.TieBreaker = &tieBreaker
+	}
+	if input.TieBreakerDirection != nil {
+		config.TieBreakerDirection = input.TieBreakerDirection.String()
+	}
+	if input.MinRaces != nil {
+		config.MinRaces = *input.MinRaces
+	}
+	if input.MinSecs != nil {
+		config.MinSecs = *input.MinSecs
+	}
+	if input.MinTyped != nil {
+		config.MinTyped = *input.MinTyped
+	}
+	if input.PayoutRate != nil {
+		config.PayoutRate = *input.PayoutRate
+	}
+	if config.TiePolicy == gqlmodels.TiePolicyBreak.String() && config.TieBreaker == nil {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "A tie breaker is required when breaking ties",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_EVENT",
+			},
+		}
+	}
+	_, err := seed.PlanCompetition(config)
+	if err != nil || config.EventName == "" || config.TeamTag == "" || config.TeamID <= 0 || config.MinRaces < 0 || config.MinSecs < 0 || config.MinTyped < 0 || config.PayoutRate < 0 {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Invalid event settings received",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_EVENT",
+			},
+		}
+	}
+	eventID, err := seed.SetupCompetition(ctx, r.Conn, config)
+	if errors.Is(err, seed.ErrAlreadySeeded) {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "The team already has an event during this time",
+			Extensions: map[string]interface{}{
+				"code": "EVENT_OVERLAP",
+			},
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create event: %w", err)
+	}
+	return r.Query().Event(ctx, eventID)
+}
+
+// UpdateEvent is a mutation resolver that changes the name, rules or payout rate of an event.
+func (r *mutationResolver) UpdateEvent(ctx context.Context, id string, input gqlmodels.EventUpdateInput) (*gqlmodels.Event, error) {
+	id = globalid.Decode(globalid.Event, id)
+	if !utils.IsValidID(id) {
+		return nil, notFoundError(ctx, "Event not found")
+	}
+	if (input.Name != nil && strings.TrimSpace(*input.Name) == "") || (input.PayoutRate != nil && *input.PayoutRate < 0) {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Invalid event settings received",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_EVENT",
+			},
+		}
+	}
+	err := manage.UpdateEvent(ctx, r.Conn, id, input.Name, input.Rules)
+	if err == nil && input.PayoutRate != nil {
+		err = payouts.SetRate(ctx, r.Conn, id, *input.PayoutRate)
+	}
+	if errors.Is(err, manage.ErrEventNotFound) || errors.Is(err, payouts.ErrEventNotFound) {
+		return nil, notFoundError(ctx, "Event not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to update event: %w", err)
+	}
+	return r.Query().Event(ctx, id)
+}
+
+// CancelEvent is a mutation resolver that removes an event along with its competitions that haven't finished.
+func (r *mutationResolver) CancelEvent(ctx context.Context, id string) (bool, error) {
+	id = globalid.Decode(globalid.Event, id)
+	if !utils.IsValidID(id) {
+		return false, nil
+	}
+	_, err := manage.CancelEvent(ctx, r.Conn, id)
+	if errors.Is(err, manage.ErrEventNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to cancel event: %w", err)
+	}
+	return true, nil
+}
+
+// competitionError converts the competition management errors into GQL errors (other errors are wrapped).
+func competitionError(ctx context.Context, err error, action string) error {
+	message, code := "", ""
+	switch {
+	case errors.Is(err, manage.ErrEventNotFound):
+		message, code = "Event not found", "NOT_FOUND"
+	case errors.Is(err, manage.ErrCompetitionNotFound):
+		message, code = "Competition not found", "NOT_FOUND"
+	case errors.Is(err, manage.ErrInvalidTimeRange):
+		message, code = "Invalid time range received", "INVALID_TIMERANGE"
+	case errors.Is(err, manage.ErrCompetitionOverlap):
+		message, code = "Competition overlaps another competition of the event", "COMPETITION_OVERLAP"
+	case errors.Is(err, manage.ErrCompetitionNotDraft):
+		message, code = "Only draft competitions can be changed", "COMPETITION_NOT_DRAFT"
+	case errors.Is(err, manage.ErrCompetitionClosed):
+		message, code = "Competition has already closed", "COMPETITION_CLOSED"
+	case errors.Is(err, manage.ErrCompetitionNotFinished):
+		message, code = "Competition has not finished", "COMPETITION_NOT_FINISHED"
+	default:
+		return fmt.Errorf("unable to %s: %w", action, err)
+	}
+	return &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: message,
+		Extensions: map[string]interface{}{
+			"code": code,
+		},
+	}
+}
+
+// invalidMultiplierError is the GQL error returned for multipliers below 1.
+func invalidMultiplierError(ctx context.Context) error {
+	return &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: "Multiplier must be at least 1",
+		Extensions: map[string]interface{}{
+			"code": "INVALID_MULTIPLIER",
+		},
+	}
+}
+
+// CreateCompetition is a mutation resolver that adds a competition to an event.
+func (r *mutationResolver) CreateCompetition(ctx context.Context, input gqlmodels.CompetitionInput) (*gqlmodels.Competition, error) {
+	input.EventID = globalid.Decode(globalid.Event, input.EventID)
+	if !utils.IsValidID(input.EventID) {
+		return nil, notFoundError(ctx, "Event not found")
+	}
+	multiplier := 1
+	if input.Multiplier != nil {
+		multiplier = *input.Multiplier
+	}
+	if multiplier < 1 {
+		return nil, invalidMultiplierError(ctx)
+	}
+	competitionID, err := manage.CreateCompetition(ctx, r.Conn, input.EventID, input.TimeFrom, input.TimeTo, multiplier)
+	if err != nil {
+		return nil, competitionError(ctx, err, "create competition")
+	}
+	return r.getCompetition(ctx, competitionID)
+}
+
+// UpdateCompetition is a mutation resolver that changes the time or activity requirements of a draft competition.
+func (r *mutationResolver) UpdateCompetition(ctx context.Context, id string, input gqlmodels.CompetitionUpdateInput) (*gqlmodels.Competition, error) {
+	id = globalid.Decode(globalid.Competition, id)
+	if !utils.IsValidID(id) {
+		return nil, notFoundError(ctx, "Competition not found")
+	}
+	if (input.MinRaces != nil && *input.MinRaces < 1) || (input.MinSecs != nil && *input.MinSecs < 0) || (input.MinTyped != nil && *input.MinTyped < 0) {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Activity requirements must not be negative (and at least 1 race)",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_REQUIREMENTS",
+			},
+		}
+	}
+	err := manage.UpdateCompetition(ctx, r.Conn, id, manage.CompetitionChanges{
+		TimeFrom: input.TimeFrom,
+		TimeTo:   input.TimeTo,
+		MinRaces: input.MinRaces,
+		MinSecs:  input.MinSecs,
+		MinTyped: input.MinTyped,
+	})
+	if err != nil {
+		return nil, competitionError(ctx, err, "update competition")
+	}
+	return r.getCompetition(ctx, id)
+}
+
+// CancelCompetition is a mutation resolver that removes a competition that hasn't finished.
+func (r *mutationResolver) CancelCompetition(ctx context.Context, id string) (bool, error) {
+	id = globalid.Decode(globalid.Competition, id)
+	if !utils.IsValidID(id) {
+		return false, notFoundError(ctx, "Competition not found")
+	}
+	err := manage.CancelCompetition(ctx, r.Conn, id)
+	if err != nil {
+		return false, competitionError(ctx, err, "cancel competition")
+	}
+	return true, nil
+}
+
+// SetMultiplier is a mutation resolver that changes the multiplier of a competition (finished ones are recomputed).
+func (r *mutationResolver) SetMultiplier(ctx context.Context, competitionID string, multiplier int) (*gqlmodels.Competition, error) {
+	competitionID = globalid.Decode(globalid.Competition, competitionID)
+	if !utils.IsValidID(competitionID) {
+		return nil, notFoundError(ctx, "Competition not found")
+	}
+	if multiplier < 1 {
+		return nil, invalidMultiplierError(ctx)
+	}
+	err := manage.SetMultiplier(ctx, r.Conn, competitionID, multiplier)
+	if err != nil {
+		return nil, competitionError(ctx, err, "set multiplier")
+	}
+	return r.getCompetition(ctx, competitionID)
+}
+
+// Recompute is a mutation resolver that rebuilds the records and results of a finished competition (and the ones after it).
+func (r *mutationResolver) Recompute(ctx context.Context, competitionID string) ([]string, error) {
+	competitionID = globalid.Decode(globalid.Competition, competitionID)
+	if !utils.IsValidID(competitionID) {
+		return nil, notFoundError(ctx, "Competition not found")
+	}
+	output, err := manage.RecomputeCompetition(ctx, r.Conn, competitionID)
+	if err != nil {
+		return nil, competitionError(ctx, err, "recompute competition")
+	}
+	return output, nil
+}
+
+// DisqualifyUser is a mutation resolver that disqualifies a racer from every competition.
+func (r *mutationResolver) DisqualifyUser(ctx context.Context, id string) (*gqlmodels.User, error) {
+	return r.setUserStatus(ctx, id, gqlmodels.UserStatusDisqualified)
+}
+
+// ReinstateUser is a mutation resolver that lifts a racer's disqualification.
+func (r *mutationResolver) ReinstateUser(ctx context.Context, id string) (*gqlmodels.User, error) {
+	return r.setUserStatus(ctx, id, gqlmodels.UserStatusActive)
+}
+
+// setUserStatus changes the status of a racer and recomputes the results they were in.
+func (r *mutationResolver) setUserStatus(ctx context.Context, id string, status gqlmodels.UserStatus) (*gqlmodels.User, error) {
+	id = globalid.Decode(globalid.User, id)
+	if !utils.IsValidID(id) {
+		return nil, notFoundError(ctx, "User not found")
+	}
+	_, err := manage.SetUserStatus(ctx, r.Conn, id, status.String())
+	if errors.Is(err, manage.ErrUserNotFound) {
+		return nil, notFoundError(ctx, "User not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to update user status: %w", err)
+	}
+	return r.Query().User(ctx, &id, nil)
+}
+
+// toExclusionModel converts an exclusion into its GQL model.
+func toExclusionModel(exclusion *exclusions.Exclusion) *gqlmodels.Exclusion {
+	return &gqlmodels.Exclusion{
+		ID:        exclusion.ID,
+		UserID:    exclusion.UserID,
+		EventID:   exclusion.EventID,
+		Reason:    exclusion.Reason,
+		Author:    exclusion.Author,
+		CreatedAt: exclusion.CreatedAt,
+	}
+}
+
+// AddExclusion is a mutation resolver that keeps a racer out of the results of an event (or every event).
+func (r *mutationResolver) AddExclusion(ctx context.Context, input gqlmodels.ExclusionInput) (*gqlmodels.Exclusion, error) {
+	input.UserID = globalid.Decode(globalid.User, input.UserID)
+	input.EventID = globalid.DecodeOptional(globalid.Event, input.EventID)
+	if !utils.IsValidID(input.UserID) || (input.EventID != nil && !utils.IsValidID(*input.EventID)) {
+		return nil, notFoundError(ctx, "User or event not found")
+	}
+	if strings.TrimSpace(input.Reason) == "" || strings.TrimSpace(input.Author) == "" {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Reason and author are required",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_EXCLUSION",
+			},
+		}
+	}
+	exclusion, err := exclusions.Add(ctx, r.Conn, input.UserID, input.EventID, input.Reason, input.Author)
+	if errors.Is(err, exclusions.ErrUserNotFound) || errors.Is(err, exclusions.ErrEventNotFound) {
+		return nil, notFoundError(ctx, "User or event not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to add exclusion: %w", err)
+	}
+	return toExclusionModel(exclusion), nil
+}
+
+// RemoveExclusion is a mutation resolver that lets an excluded racer back into the results.
+func (r *mutationResolver) RemoveExclusion(ctx context.Context, id string) (bool, error) {
+	if !utils.IsValidID(id) {
+		return false, nil
+	}
+	err := exclusions.Remove(ctx, r.Conn, id)
+	if errors.Is(err, exclusions.ErrExclusionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to remove exclusion: %w", err)
+	}
+	return true, nil
+}
+
+// AddAdjustment is a mutation resolver that gives a racer bonus points or a penalty.
+func (r *mutationResolver) AddAdjustment(ctx context.Context, input gqlmodels.AdjustmentInput) (*gqlmodels.Adjustment, error) {
+	input.UserID = globalid.Decode(globalid.User, input.UserID)
+	input.CompetitionID = globalid.DecodeOptional(globalid.Competition, input.CompetitionID)
+	if !utils.IsValidID(input.UserID) || (input.CompetitionID != nil && !utils.IsValidID(*input.CompetitionID)) {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "User or competition not found",
+			Extensions: map[string]interface{}{
+				"code": "NOT_FOUND",
+			},
+		}
+	}
+	if input.Points == 0 || strings.TrimSpace(input.Reason) == "" || strings.TrimSpace(input.Author) == "" {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Points, reason and author are required",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_ADJUSTMENT",
+			},
+		}
+	}
+	adjustment, err := adjustments.Add(ctx, r.Conn, input.UserID, input.CompetitionID, input.Points, input.Reason, input.Author)
+	if errors.Is(err, adjustments.ErrUserNotFound) || errors.Is(err, adjustments.ErrCompetitionNotFound) {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "User or competition not found",
+			Extensions: map[string]interface{}{
+				"code": "NOT_FOUND",
+			},
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to add adjustment: %w", err)
+	}
+	output := &gqlmodels.Adjustment{
+		ID:            adjustment.ID,
+		UserID:        adjustment.UserID,
+		CompetitionID: adjustment.CompetitionID,
+		Points:        adjustment.Points,
+		Reason:        adjustment.Reason,
+		Author:        adjustment.Author,
+		CreatedAt:     adjustment.CreatedAt,
+	}
+	return output, nil
+}
+
+// RemoveAdjustment is a mutation resolver that deletes an adjustment.
+func (r *mutationResolver) RemoveAdjustment(ctx context.Context, id string) (bool, error) {
+	if !utils.IsValidID(id) {
+		return false, nil
+	}
+	err := adjustments.Remove(ctx, r.Conn, id)
+	if errors.Is(err, adjustments.ErrAdjustmentNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to remove adjustment: %w", err)
+	}
+	return true, nil
+}
+
+// toPayoutModel converts a payout into its GQL model.
+func toPayoutModel(payout *payouts.Payout) *gqlmodels.Payout {
+	return &gqlmodels.Payout{
+		ID:          payout.ID,
+		EventID:     payout.EventID,
+		UserID:      payout.UserID,
+		Amount:      payout.Amount,
+		Status:      gqlmodels.PayoutStatus(payout.Status),
+		Note:        payout.Note,
+		SentAt:      payout.SentAt,
+		ConfirmedAt: payout.ConfirmedAt,
+		CreatedAt:   payout.CreatedAt,
+		UpdatedAt:   payout.UpdatedAt,
+	}
+}
+
+// CreatePayout is a mutation resolver that records a pending payout.
+func (r *mutationResolver) CreatePayout(ctx context.Context, input gqlmodels.PayoutInput) (*gqlmodels.Payout, error) {
+	input.EventID = globalid.Decode(globalid.Event, input.EventID)
+	input.UserID = globalid.Decode(globalid.User, input.UserID)
+	if !utils.IsValidID(input.EventID) || !utils.IsValidID(input.UserID) {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Event or user not found",
+			Extensions: map[string]interface{}{
+				"code": "NOT_FOUND",
+			},
+		}
+	}
+	if input.Amount <= 0 {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Payout amount must be positive",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_PAYOUT",
+			},
+		}
+	}
+	note := ""
+	if input.Note != nil {
+		note = *input.Note
+	}
+	payout, err := payouts.Create(ctx, r.Conn, input.EventID, input.UserID, input.Amount, note)
+	if errors.Is(err, payouts.ErrEventNotFound) || errors.Is(err, payouts.ErrUserNotFound) {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Event or user not found",
+			Extensions: map[string]interface{}{
+				"code": "NOT_FOUND",
+			},
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create payout: %w", err)
+	}
+	return toPayoutModel(payout), nil
+}
+
+// UpdatePayoutStatus is a mutation resolver that moves a payout to its next status.
+func (r *mutationResolver) UpdatePayoutStatus(ctx context.Context, id string, status gqlmodels.PayoutStatus, note *string) (*gqlmodels.Payout, error) {
+	notFoundErr := &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: "Payout not found",
+		Extensions: map[string]interface{}{
+			"code": "NOT_FOUND",
+		},
+	}
+	if !utils.IsValidID(id) {
+		return nil, notFoundErr
+	}
+	payout, err := payouts.SetStatus(ctx, r.Conn, id, status.String(), note)
+	if errors.Is(err, payouts.ErrPayoutNotFound) {
+		return nil, notFoundErr
+	}
+	if errors.Is(err, payouts.ErrInvalidTransition) {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Payout status can only move forward",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_PAYOUT_STATUS",
+			},
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to update payout status: %w", err)
+	}
+	return toPayoutModel(payout), nil
+}
+
+// CancelPayout is a mutation resolver that removes a pending payout.
+func (r *mutationResolver) CancelPayout(ctx context.Context, id string) (bool, error) {
+	if !utils.IsValidID(id) {
+		return false, nil
+	}
+	err := payouts.Cancel(ctx, r.Conn, id)
+	if errors.Is(err, payouts.ErrPayoutNotFound) || errors.Is(err, payouts.ErrPayoutNotPending) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to cancel payout: %w", err)
+	}
+	return true, nil
+}
+
+// RefreshLeaderboard is a mutation resolver that refreshes the provisional leaderboard of a started competition.
+// Anyone can refresh it, but it's only recomputed once every refresh interval (otherwise the current one is returned).
+func (r *mutationResolver) RefreshLeaderboard(ctx context.Context, competitionID string) (*gqlmodels.Competition, error) {
+	competitionID = globalid.Decode(globalid.Competition, competitionID)
+	notFoundErr := &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: "Competition not found",
+		Extensions: map[string]interface{}{
+			"code": "NOT_FOUND",
+		},
+	}
+	if !utils.IsValidID(competitionID) {
+		return nil, notFoundErr
+	}
+	_, err := provisional.Refresh(ctx, r.Conn, r.APIClient, competitionID)
+	if errors.Is(err, provisional.ErrCompetitionNotFound) {
+		return nil, notFoundErr
+	}
+	if errors.Is(err, provisional.ErrCompetitionNotStarted) {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Only started competitions have a provisional leaderboard",
+			Extensions: map[string]interface{}{
+				"code": "COMPETITION_NOT_STARTED",
+			},
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to refresh provisional leaderboard: %w", err)
+	}
+	output, err := r.getCompetition(ctx, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	if output == nil {
+		return nil, notFoundErr
+	}
+	return output, nil
+}
+
+/////////////
+//  Query  //
+/////////////
+
+type queryResolver struct{ *Resolver }
+
+func (r *Resolver) Query() QueryResolver {
+	return &queryResolver{r}
+}
+
+// Node is a query resolver that fetches any node by its global id.
+func (r *queryResolver) Node(ctx context.Context, id string) (gqlmodels.Node, error) {
+	output, err := loadNode(ctx, id)()
+	if err != nil {
+		return nil, fmt.Errorf("node dataloader failed: %w", err)
+	}
+	return output, nil
+}
+
+// Nodes is a query resolver that fetches nodes by their global ids (null for the ones not found).
+func (r *queryResolver) Nodes(ctx context.Context, ids []string) ([]gqlmodels.Node, error) {
+	thunks := make([]func() (gqlmodels.Node, error), len(ids))
+	for i, id := range ids {
+		thunks[i] = loadNode(ctx, id)
+	}
+	output := make([]gqlmodels.Node, len(ids))
+	for i, thunk := range thunks {
+		node, err := thunk()
+		if err != nil {
+			return nil, fmt.Errorf("node dataloader failed: %w", err)
+		}
+		output[i] = node
+	}
+	return output, nil
+}
+
+// usersKeyset is the order of the users (by username).
+var usersKeyset = pagination.Keyset{
+	Columns: []pagination.Column{
+		{Expr: "lower(u.username)", Name: "lower(p.username)", Type: "text"},
+		{Expr: "u.id", Name: "p.id", Type: "uuid"},
+	},
+}
+
+// Users is a query resolver that fetches a page of the playing users.
+func (r *queryResolver) Users(ctx context.Context, first *int, after *string, last *int, before *string) (*gqlmodels.UserConnection, error) {
+	pageArgs, err := getPageArgs(ctx, first, after, last, before)
+	if err != nil {
+		return nil, err
+	}
+	output := &gqlmodels.UserConnection{
+		Edges: []*gqlmodels.UserEdge{},
+	}
+	q := `
+		SELECT COUNT(*)
+		FROM users u
+		WHERE u.deleted_at IS NULL`
+	err = r.Conn.QueryRow(ctx, q).Scan(&output.TotalCount)
+	if err != nil {
+		return nil, fmt.Errorf("unable to count users: %w", err)
+	}
+
+	page, err := usersKeyset.Page(pageArgs, 0)
+	if err != nil {
+		return nil, paginationError(ctx, err)
+	}
+	conditions := append([]string{"u.deleted_at IS NULL"}, page.Conditions...)
+	args := append(page.Values, page.Limit)
+	q = `
+		SELECT p.*
+		FROM (
+			SELECT u.id, u.username, u.display_name, u.membership_type, u.status, u.created_at, u.updated_at
+			FROM users u
+			WHERE ` + strings.Join(conditions, " AND ") + `
+			ORDER BY ` + page.FetchOrder + `
+			LIMIT $` + fmt.Sprint(len(args)) + `
+		) p
+		ORDER BY ` + page.Order
+	rows, err := r.Conn.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query users: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row := gqlmodels.User{}
+		err := rows.Scan(&row.ID, &row.Username, &row.DisplayName, &row.MembershipType, &row.Status, &row.CreatedAt, &row.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to collect users: %w", err)
+		}
+		if row.DisplayName == "" {
+			row.DisplayName = row.Username
+		}
+		output.Edges = append(output.Edges, &gqlmodels.UserEdge{
+			Cursor: usersKeyset.Cursor(strings.ToLower(row.Username), row.ID),
+			Node:   &row,
+		})
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to collect users: %w", err)
+	}
+	start, end, hasPreviousPage, hasNextPage := page.Window(len(output.Edges))
+	output.Edges = output.Edges[start:end]
+	cursors := []string{}
+	for _, edge := range output.Edges {
+		cursors = append(cursors, edge.Cursor)
+	}
+	output.PageInfo = getPageInfo(hasPreviousPage, hasNextPage, cursors)
+	return output, nil
+}
+
+// User is a query resolver that fetches a user by id or username.
+func (r *queryResolver) User(ctx context.Context, id *string, username *string) (*gqlmodels.User, error) {
+	id = globalid.DecodeOptional(globalid.User, id)
+	if (id == nil) == (username == nil) {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Either id or username is required",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_ARGUMENTS",
+			},
+		}
+	}
+	args := []interface{}{}
+	q := `
+		SELECT u.id, u.username, u.display_name, u.membership_type, u.status, u.created_at, u.updated_at
+		FROM users u
+		WHERE u.deleted_at IS NULL`
+	if id != nil {
+		if !utils.IsValidID(*id) {
+			return nil, nil
+		}
+		args = append(args, *id)
+		q += ` AND u.id = $1`
+	} else {
+		args = append(args, *username)
+		q += ` AND lower(u.username) = lower($1)`
+	}
+	output := &gqlmodels.User{}
+	err := r.Conn.QueryRow(ctx, q, args...).Scan(&output.ID, &output.Username, &output.DisplayName, &output.MembershipType, &output.Status, &output.CreatedAt, &output.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
 	if err != nil {
-		return 0, fmt.Errorf("totalPoints dataloader failed: %w", err)
+		return nil, fmt.Errorf("unable to query user: %w", err)
+	}
+	if output.DisplayName == "" {
+		output.DisplayName = output.Username
 	}
 	return output, nil
 }
 
-///////////////////
-//  Competition  //
-///////////////////
-
-type competitionResolver struct{ *Resolver }
+// maxSearchLimit is the most users a search can return.
+const maxSearchLimit = 50
 
-func (r *Resolver) Competition() CompetitionResolver {
-	return &competitionResolver{r}
-}
+// maxListLimit is the most items the lists with a limit (standings and compared competitions) can return.
+const maxListLimit = 500
 
-func (r *competitionResolver) Leaderboard(ctx context.Context, obj *gqlmodels.Competition) ([]*gqlmodels.CompetitionUser, error) {
-	if obj.Status != gqlmodels.CompetitionStatusFinished {
-		return []*gqlmodels.CompetitionUser{}, nil
+// getListLimit validates the limit of a list (the default is set by the schema).
+func getListLimit(ctx context.Context, limit *int) (int, error) {
+	size := maxListLimit
+	if limit != nil {
+		size = *limit
 	}
-	leaderboardLoader := dataloaders.GetLoadersFromContext(ctx).CompetitionLeaderboardByID
-	output, err := leaderboardLoader.Load(obj.ID)
-	if err != nil {
-		return nil, fmt.Errorf("leaderboard dataloader failed: %w", err)
+	if size < 1 || size > maxListLimit {
+		return 0, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: fmt.Sprintf("Limit must be between 1 and %d", maxListLimit),
+			Extensions: map[string]interface{}{
+				"code": "INVALID_LIMIT",
+			},
+		}
 	}
-	return output, nil
+	return size, nil
 }
 
-/////////////
-//  Query  //
-/////////////
+// likeEscaper escapes the LIKE wildcards of the search query.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
 
-type queryResolver struct{ *Resolver }
+// SearchUsers is a query resolver that finds users by their username, display name or past usernames.
+// Names are matched by trigram similarity (or when they contain the query), the most similar users come first.
+func (r *queryResolver) SearchUsers(ctx context.Context, query string, limit *int, status []gqlmodels.UserStatus, membershipType *gqlmodels.MembershipType) ([]*gqlmodels.UserSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Search query is required",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_QUERY",
+			},
+		}
+	}
+	size := 10
+	if limit != nil {
+		size = *limit
+	}
+	if size < 1 || size > maxSearchLimit {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: fmt.Sprintf("Limit must be between 1 and %d", maxSearchLimit),
+			Extensions: map[string]interface{}{
+				"code": "INVALID_LIMIT",
+			},
+		}
+	}
+	statuses := []string{}
+	for _, s := range status {
+		statuses = append(statuses, string(s))
+	}
+	var membership *string
+	if membershipType != nil {
+		m := string(*membershipType)
+		membership = &m
+	}
 
-func (r *Resolver) Query() QueryResolver {
-	return &queryResolver{r}
+	q := `
+		WITH matches AS (
+			SELECT u.id AS user_id, u.username AS name
+			FROM users u
+			WHERE u.deleted_at IS NULL AND (u.username % $1 OR u.username ILIKE $2)
+			UNION ALL
+			SELECT u.id, u.display_name
+			FROM users u
+			WHERE u.deleted_at IS NULL AND (u.display_name % $1 OR u.display_name ILIKE $2)
+			UNION ALL
+			SELECT h.user_id, h.username
+			FROM user_usernames h
+			WHERE h.deleted_at IS NULL AND (h.username % $1 OR h.username ILIKE $2)
+		),
+		best_matches AS (
+			SELECT DISTINCT ON (m.user_id) m.user_id, m.name, greatest(similarity(m.name, $1), word_similarity($1, m.name))::float AS similarity
+			FROM matches m
+			ORDER BY m.user_id, similarity DESC
+		)
+		SELECT u.id, u.username, u.display_name, u.membership_type, u.status, u.created_at, u.updated_at,
+			b.name,
+			b.similarity,
+			coalesce((
+				SELECT array_agg(h.username ORDER BY h.created_at)
+				FROM user_usernames h
+				WHERE h.user_id = u.id AND h.username != u.username AND h.deleted_at IS NULL
+			), '{}')
+		FROM best_matches b
+			INNER JOIN users u ON u.id = b.user_id
+		WHERE (NOT $3 OR u.status = ANY($4))
+			AND ($5::text IS NULL OR u.membership_type = $5)
+		ORDER BY b.similarity DESC, lower(u.username) ASC
+		LIMIT $6`
+	rows, err := r.Conn.Query(ctx, q, query, "%"+likeEscaper.Replace(query)+"%", status != nil, statuses, membership, size)
+	if err != nil {
+		return nil, fmt.Errorf("unable to search users: %w", err)
+	}
+	defer rows.Close()
+	output := []*gqlmodels.UserSearchResult{}
+	for rows.Next() {
+		row := &gqlmodels.UserSearchResult{
+			User: &gqlmodels.User{},
+		}
+		err := rows.Scan(&row.User.ID, &row.User.Username, &row.User.DisplayName, &row.User.MembershipType, &row.User.Status, &row.User.CreatedAt, &row.User.UpdatedAt, &row.MatchedName, &row.Similarity, &row.PastUsernames)
+		if err != nil {
+			return nil, fmt.Errorf("unable to collect user search: %w", err)
+		}
+		if row.User.DisplayName == "" {
+			row.User.DisplayName = row.User.Username
+		}
+		output = append(output, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to collect user search: %w", err)
+	}
+	return output, nil
 }
 
-// Users is a query resolver that fetches all playing users.
-func (r *queryResolver) Users(ctx context.Context) ([]*gqlmodels.User, error) {
-	output := []*gqlmodels.User{}
+// Events is a query resolver that fetches all events.
+func (r *queryResolver) Events(ctx context.Context) ([]*gqlmodels.Event, error) {
+	output := []*gqlmodels.Event{}
 	q := `
-		SELECT u.id, u.username, u.display_name, u.membership_type, u.status, u.created_at, u.updated_at
-		FROM users u
-		WHERE u.deleted_at IS NULL`
+		SELECT e.id, e.name, e.team_tag, e.rules, e.timezone, e.window_minutes, e.tie_policy, e.payout_rate, e.from_at, e.to_at, e.created_at, e.updated_at
+		FROM events e
+		WHERE e.deleted_at IS NULL
+		ORDER BY e.from_at DESC`
 	rows, err := r.Conn.Query(ctx, q)
 	if err != nil {
-		return nil, fmt.Errorf("unable to query users: %w", err)
+		return nil, fmt.Errorf("unable to query events: %w", err)
 	}
 	defer rows.Close()
 	for rows.Next() {
-		row := gqlmodels.User{}
-		err := rows.Scan(&row.ID, &row.Username, &row.DisplayName, &row.MembershipType, &row.Status, &row.CreatedAt, &row.UpdatedAt)
+		row := gqlmodels.Event{}
+		err := rows.Scan(&row.ID, &row.Name, &row.TeamTag, &row.Rules, &row.Timezone, &row.WindowMinutes, &row.TiePolicy, &row.PayoutRate, &row.StartAt, &row.FinishAt, &row.CreatedAt, &row.UpdatedAt)
 		if err != nil {
-			return nil, fmt.Errorf("unable to collect users: %w", err)
-		}
-		if row.DisplayName == "" {
-			row.DisplayName = row.Username
+			return nil, fmt.Errorf("unable to collect events: %w", err)
 		}
 		output = append(output, &row)
 	}
 	err = rows.Err()
 	if err != nil {
-		return nil, fmt.Errorf("unable to collect users: %w", err)
+		return nil, fmt.Errorf("unable to collect events: %w", err)
+	}
+	return output, nil
+}
+
+// Event is a query resolver that fetches an event.
+func (r *queryResolver) Event(ctx context.Context, id string) (*gqlmodels.Event, error) {
+	id = globalid.Decode(globalid.Event, id)
+	if !utils.IsValidID(id) {
+		return nil, nil
+	}
+	output := &gqlmodels.Event{}
+	q := `
+		SELECT e.id, e.name, e.team_tag, e.rules, e.timezone, e.window_minutes, e.tie_policy, e.payout_rate, e.from_at, e.to_at, e.created_at, e.updated_at
+		FROM events e
+		WHERE e.id = $1 AND e.deleted_at IS NULL`
+	err := r.Conn.QueryRow(ctx, q, id).Scan(&output.ID, &output.Name, &output.TeamTag, &output.Rules, &output.Timezone, &output.WindowMinutes, &output.TiePolicy, &output.PayoutRate, &output.StartAt, &output.FinishAt, &output.CreatedAt, &output.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to query event: %w", err)
+	}
+	return output, nil
+}
+
+// Exclusions is a query resolver that fetches the exclusions, optionally those affecting an event.
+func (r *queryResolver) Exclusions(ctx context.Context, eventID *string) ([]*gqlmodels.Exclusion, error) {
+	eventID = globalid.DecodeOptional(globalid.Event, eventID)
+	if eventID != nil && !utils.IsValidID(*eventID) {
+		return []*gqlmodels.Exclusion{}, nil
+	}
+	items, err := exclusions.List(ctx, r.Conn, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list exclusions: %w", err)
+	}
+	output := []*gqlmodels.Exclusion{}
+	for _, exclusion := range items {
+		output = append(output, toExclusionModel(exclusion))
 	}
 	return output, nil
 }
 
-// Competitions is a query resolver that fetches all available competitions.
-func (r *queryResolver) Competitions(ctx context.Context, timeRange *gqlmodels.TimeRangeInput) ([]*gqlmodels.Competition, error) {
+// Competitions is a query resolver that fetches a page of the available competitions.
+func (r *queryResolver) Competitions(ctx context.Context, eventID *string, timeRange *gqlmodels.TimeRangeInput, first *int, after *string, last *int, before *string) (*gqlmodels.CompetitionConnection, error) {
+	eventID = globalid.DecodeOptional(globalid.Event, eventID)
+	return r.getCompetitions(ctx, eventID, timeRange, first, after, last, before)
+}
+
+// competitionsKeyset is the order of the competitions (earliest first).
+var competitionsKeyset = pagination.Keyset{
+	Columns: []pagination.Column{
+		{Expr: "c.from_at", Name: "p.from_at", Type: "timestamptz"},
+		{Expr: "c.id", Name: "p.id", Type: "uuid"},
+	},
+}
+
+// getCompetitions fetches a page of the competitions, optionally filtered by event and time range.
+func (r *Resolver) getCompetitions(ctx context.Context, eventID *string, timeRange *gqlmodels.TimeRangeInput, first *int, after *string, last *int, before *string) (*gqlmodels.CompetitionConnection, error) {
 	timeRange, err := getTimeRangeRounded(timeRange)
 	if err != nil {
 		return nil, &gqlerror.Error{
@@ -130,69 +1325,481 @@ func (r *queryResolver) Competitions(ctx context.Context, timeRange *gqlmodels.T
 			},
 		}
 	}
-	output := []*gqlmodels.Competition{}
+	pageArgs, err := getPageArgs(ctx, first, after, last, before)
+	if err != nil {
+		return nil, err
+	}
+	output := &gqlmodels.CompetitionConnection{
+		Edges:    []*gqlmodels.CompetitionEdge{},
+		PageInfo: getPageInfo(false, false, nil),
+	}
+
+	// Filter by event and time range
 	args := []interface{}{}
-	q := `
-		SELECT c.id, c.status, c.multiplier, c.grind_rewards, c.point_rewards, c.speed_rewards, c.accuracy_rewards, c.from_at, c.to_at, c.updated_at
-		FROM competitions c`
+	conditions := []string{"c.deleted_at IS NULL"}
+	if eventID != nil {
+		if !utils.IsValidID(*eventID) {
+			return output, nil
+		}
+		args = append(args, *eventID)
+		conditions = append(conditions, fmt.Sprintf("c.event_id = $%d", len(args)))
+	}
 	if timeRange != nil {
-		q += ` WHERE from_at >= $1 AND to_at <= $2`
 		args = append(args, timeRange.TimeFrom, timeRange.TimeTo)
+		conditions = append(conditions, fmt.Sprintf("c.from_at >= $%d AND c.to_at <= $%d", len(args)-1, len(args)))
+	}
+	q := `
+		SELECT COUNT(*)
+		FROM competitions c
+		WHERE ` + strings.Join(conditions, " AND ")
+	err = r.Conn.QueryRow(ctx, q, args...).Scan(&output.TotalCount)
+	if err != nil {
+		return nil, fmt.Errorf("unable to count competitions: %w", err)
+	}
+
+	// Page of competitions
+	page, err := competitionsKeyset.Page(pageArgs, len(args))
+	if err != nil {
+		return nil, paginationError(ctx, err)
 	}
-	q += ` ORDER BY c.from_at ASC NULLS FIRST`
+	conditions = append(conditions, page.Conditions...)
+	args = append(args, page.Values...)
+	args = append(args, page.Limit)
+	q = `
+		SELECT p.*
+		FROM (
+			SELECT c.id, c.status, c.multiplier, c.tie_policy, c.tie_breaker, c.tie_breaker_direction, c.min_races, c.min_secs, c.min_typed, c.from_at, c.to_at, c.provisional_at, c.updated_at
+			FROM competitions c
+			WHERE ` + strings.Join(conditions, " AND ") + `
+			ORDER BY ` + page.FetchOrder + `
+			LIMIT $` + fmt.Sprint(len(args)) + `
+		) p
+		ORDER BY ` + page.Order
 	rows, err := r.Conn.Query(ctx, q, args...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to query competitions: %w", err)
 	}
 	defer rows.Close()
 	for rows.Next() {
-		row := gqlmodels.Competition{
-			GrindRewards:    []*gqlmodels.CompetitionPrize{},
-			PointRewards:    []*gqlmodels.CompetitionPrize{},
-			SpeedRewards:    []*gqlmodels.CompetitionPrize{},
-			AccuracyRewards: []*gqlmodels.CompetitionPrize{},
-		}
-		grindRewards := []int{}
-		pointRewards := []int{}
-		speedRewards := []int{}
-		accuracyRewards := []int{}
-		err := rows.Scan(&row.ID, &row.Status, &row.Multiplier, &grindRewards, &pointRewards, &speedRewards, &accuracyRewards, &row.StartAt, &row.FinishAt, &row.UpdatedAt)
+		row := gqlmodels.Competition{}
+		err := rows.Scan(&row.ID, &row.Status, &row.Multiplier, &row.TiePolicy, &row.TieBreaker, &row.TieBreakerDirection, &row.MinRaces, &row.MinSecs, &row.MinTyped, &row.StartAt, &row.FinishAt, &row.ProvisionalAt, &row.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("unable to collect competitions: %w", err)
 		}
-		for i, r := range grindRewards {
-			prizeData := &gqlmodels.CompetitionPrize{
-				Rank:   i + 1,
-				Points: r,
+		output.Edges = append(output.Edges, &gqlmodels.CompetitionEdge{
+			Cursor: competitionsKeyset.Cursor(pagination.FormatTime(row.StartAt), row.ID),
+			Node:   &row,
+		})
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to collect competitions: %w", err)
+	}
+	start, end, hasPreviousPage, hasNextPage := page.Window(len(output.Edges))
+	output.Edges = output.Edges[start:end]
+	cursors := []string{}
+	for _, edge := range output.Edges {
+		cursors = append(cursors, edge.Cursor)
+	}
+	output.PageInfo = getPageInfo(hasPreviousPage, hasNextPage, cursors)
+	return output, nil
+}
+
+// Standings is a query resolver that ranks the users of an event by their points.
+// The points of a category only count its rewards, otherwise the adjustments are included.
+// Ties share the same rank (STANDARD skips the following ranks while DENSE doesn't), only the top racers are returned.
+func (r *queryResolver) Standings(ctx context.Context, eventID string, timeRange *gqlmodels.TimeRangeInput, category *string, ranking *gqlmodels.RankingStyle, limit *int) ([]*gqlmodels.Standing, error) {
+	eventID = globalid.Decode(globalid.Event, eventID)
+	size, err := getListLimit(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	timeRange, err = getTimeRangeRounded(timeRange)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Invalid time range received",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_TIMERANGE",
+			},
+		}
+	}
+	output := []*gqlmodels.Standing{}
+	if !utils.IsValidID(eventID) {
+		return output, nil
+	}
+	filter := reports.StandingsFilter{
+		Category: category,
+		Dense:    ranking != nil && *ranking == gqlmodels.RankingStyleDense,
+	}
+	if timeRange != nil {
+		filter.TimeRange = &reports.TimeRange{TimeFrom: timeRange.TimeFrom, TimeTo: timeRange.TimeTo}
+	}
+	cacheKey := fmt.Sprintf("%s::%t::", eventID, filter.Dense)
+	if timeRange != nil {
+		cacheKey += fmt.Sprintf("%d-%d", timeRange.TimeFrom.Unix(), timeRange.TimeTo.Unix())
+	}
+	if category != nil {
+		cacheKey += "::" + strings.ToLower(*category)
+	}
+	if readsCache(ctx) {
+		if cached, ok := r.Cache.Get("standings", cacheKey); ok {
+			return topStandings(cached.([]*gqlmodels.Standing), size), nil
+		}
+	}
+	generation := r.Cache.Generation()
+
+	// Standings
+	q, args := reports.StandingsQuery(eventID, filter)
+	q = `
+		SELECT s.user_id, s.rank, s.tied, s.total_points, s.rewards, s.competitions_won, s.gap_to_next_rank,
+			u.username, u.display_name, u.membership_type, u.status, u.created_at, u.updated_at
+		FROM (` + q + `) s
+			INNER JOIN users u ON u.id = s.user_id
+		ORDER BY s.total_points DESC, lower(u.username) ASC`
+	rows, err := r.Conn.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query standings: %w", err)
+	}
+	defer rows.Close()
+	standingsByUserID := map[string]*gqlmodels.Standing{}
+	for rows.Next() {
+		row := &gqlmodels.Standing{
+			User:       &gqlmodels.User{},
+			Categories: []*gqlmodels.StandingCategory{},
+		}
+		err := rows.Scan(
+			&row.User.ID, &row.Rank, &row.Tied, &row.TotalPoints, &row.Rewards, &row.CompetitionsWon, &row.GapToNextRank,
+			&row.User.Username, &row.User.DisplayName, &row.User.MembershipType, &row.User.Status, &row.User.CreatedAt, &row.User.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to collect standings: %w", err)
+		}
+		if row.User.DisplayName == "" {
+			row.User.DisplayName = row.User.Username
+		}
+		row.ID = fmt.Sprintf("%s::%s", eventID, row.User.ID)
+		output = append(output, row)
+		standingsByUserID[row.User.ID] = row
+	}
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to collect standings: %w", err)
+	}
+
+	// Category breakdowns
+	q, args = reports.StandingCategoriesQuery(eventID, filter)
+	rows, err = r.Conn.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query standing categories: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		userID := ""
+		row := &gqlmodels.StandingCategory{}
+		err := rows.Scan(&userID, &row.Name, &row.Rewards, &row.Competitions, &row.Wins)
+		if err != nil {
+			return nil, fmt.Errorf("unable to collect standing categories: %w", err)
+		}
+		if standing, ok := standingsByUserID[userID]; ok {
+			standing.Categories = append(standing.Categories, row)
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to collect standing categories: %w", err)
+	}
+	r.Cache.Set("standings", cacheKey, generation, output, cache.EventTag(eventID))
+	return topStandings(output, size), nil
+}
+
+// topStandings keeps the first standings (the cache holds all of them, whatever the limit).
+func topStandings(standings []*gqlmodels.Standing, limit int) []*gqlmodels.Standing {
+	if len(standings) > limit {
+		return standings[:limit]
+	}
+	return standings
+}
+
+// categoryWinner works out who won a category head to head, the better rank wins and ranked racers beat the ineligible ones.
+// It returns -1 when userA won, 1 when userB won and 0 for a tie (ok is false when neither was ranked).
+func categoryWinner(rankA *int, rankB *int) (winner int, ok bool) {
+	switch {
+	case rankA == nil && rankB == nil:
+		return 0, false
+	case rankB == nil || (rankA != nil && *rankA < *rankB):
+		return -1, true
+	case rankA == nil || *rankB < *rankA:
+		return 1, true
+	}
+	return 0, true
+}
+
+// Compare is a query resolver that puts two users head to head over the competitions they both raced in.
+// The results (from competition_results) and the stats (from user_records) are fetched together, oldest competition first.
+func (r *queryResolver) Compare(ctx context.Context, userA string, userB string, timeRange *gqlmodels.TimeRangeInput) (*gqlmodels.Comparison, error) {
+	userA = globalid.Decode(globalid.User, userA)
+	userB = globalid.Decode(globalid.User, userB)
+	timeRange, err := getTimeRangeRounded(timeRange)
+	if err != nil {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Invalid time range received",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_TIMERANGE",
+			},
+		}
+	}
+	if userA == userB {
+		return nil, &gqlerror.Error{
+			Path:    graphql.GetPath(ctx),
+			Message: "Two different users are required",
+			Extensions: map[string]interface{}{
+				"code": "INVALID_ARGUMENTS",
+			},
+		}
+	}
+	if !utils.IsValidID(userA) || !utils.IsValidID(userB) {
+		return nil, nil
+	}
+	users, errs := dataloaders.GetLoadersFromContext(ctx).UserByID.LoadAll([]string{userA, userB})
+	for _, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("user dataloader failed: %w", err)
+		}
+	}
+	if users[0] == nil || users[1] == nil {
+		return nil, nil
+	}
+
+	// Filter by time range
+	args := []interface{}{userA, userB}
+	conditions := []string{"c.deleted_at IS NULL"}
+	if timeRange != nil {
+		args = append(args, timeRange.TimeFrom, timeRange.TimeTo)
+		conditions = append(conditions, fmt.Sprintf("c.from_at >= $%d AND c.to_at <= $%d", len(args)-1, len(args)))
+	}
+
+	// Category results of the competitions both users raced in
+	q := `
+		SELECT c.id, c.status, c.multiplier, c.tie_policy, c.tie_breaker, c.tie_breaker_direction, c.min_races, c.min_secs, c.min_typed, c.from_at, c.to_at, c.provisional_at, c.updated_at,
+			cat.name,
+			ra.rank,
+			ra.reward,
+			rb.rank,
+			rb.reward,
+			coalesce(sa.played, 0),
+			coalesce(sa.speed, 0)::float,
+			coalesce(sa.accuracy, 0)::float,
+			coalesce(sb.played, 0),
+			coalesce(sb.speed, 0)::float,
+			coalesce(sb.accuracy, 0)::float
+		FROM competitions c
+			INNER JOIN competition_results ra ON ra.competition_id = c.id AND ra.user_id = $1
+			INNER JOIN competition_results rb ON rb.competition_id = c.id AND rb.user_id = $2 AND rb.category_id = ra.category_id
+			INNER JOIN categories cat ON cat.id = ra.category_id
+			LEFT JOIN competition_user_stats sa ON sa.competition_id = c.id AND sa.user_id = $1
+			LEFT JOIN competition_user_stats sb ON sb.competition_id = c.id AND sb.user_id = $2
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY c.to_at, c.id, cat.position, cat.name`
+	rows, err := r.Conn.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query comparison: %w", err)
+	}
+	defer rows.Close()
+	output := &gqlmodels.Comparison{
+		UserA:        users[0],
+		UserB:        users[1],
+		Competitions: []*gqlmodels.ComparisonCompetition{},
+		Categories:   []*gqlmodels.ComparisonCategory{},
+		StatsA:       &gqlmodels.ComparisonStats{},
+		StatsB:       &gqlmodels.ComparisonStats{},
+	}
+	categories := map[string]*gqlmodels.ComparisonCategory{}
+	var current *gqlmodels.ComparisonCompetition
+	for rows.Next() {
+		competition := &gqlmodels.Competition{}
+		category := ""
+		var rankA, rankB *int
+		var rewardA, rewardB, playedA, playedB int
+		var speedA, accuracyA, speedB, accuracyB float64
+		err := rows.Scan(
+			&competition.ID, &competition.Status, &competition.Multiplier, &competition.TiePolicy, &competition.TieBreaker, &competition.TieBreakerDirection,
+			&competition.MinRaces, &competition.MinSecs, &competition.MinTyped, &competition.StartAt, &competition.FinishAt, &competition.ProvisionalAt, &competition.UpdatedAt,
+			&category, &rankA, &rewardA, &rankB, &rewardB,
+			&playedA, &speedA, &accuracyA, &playedB, &speedB, &accuracyB,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to collect comparison: %w", err)
+		}
+
+		// The stats are repeated for each category of the competition
+		if current == nil || current.Competition.ID != competition.ID {
+			current = &gqlmodels.ComparisonCompetition{
+				Competition: competition,
 			}
-			row.GrindRewards = append(row.GrindRewards, prizeData)
+			output.Competitions = append(output.Competitions, current)
+			output.StatsA.Played += playedA
+			output.StatsA.AverageSpeed += speedA
+			output.StatsA.AverageAccuracy += accuracyA
+			output.StatsB.Played += playedB
+			output.StatsB.AverageSpeed += speedB
+			output.StatsB.AverageAccuracy += accuracyB
 		}
-		for i, r := range pointRewards {
-			prizeData := &gqlmodels.CompetitionPrize{
-				Rank:   i + 1,
-				Points: r,
+		current.RewardA += rewardA
+		current.RewardB += rewardB
+
+		headToHead, ok := categories[category]
+		if !ok {
+			headToHead = &gqlmodels.ComparisonCategory{
+				Name: category,
 			}
-			row.PointRewards = append(row.PointRewards, prizeData)
+			categories[category] = headToHead
+			output.Categories = append(output.Categories, headToHead)
+		}
+		winner, ok := categoryWinner(rankA, rankB)
+		if !ok {
+			continue
+		}
+		switch winner {
+		case -1:
+			headToHead.WinsA++
+			current.CategoryWinsA++
+		case 1:
+			headToHead.WinsB++
+			current.CategoryWinsB++
+		default:
+			headToHead.Ties++
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to collect comparison: %w", err)
+	}
+
+	// Running reward difference (userA's rewards minus userB's) and the stats side by side
+	for _, competition := range output.Competitions {
+		output.RewardDifference += competition.RewardA - competition.RewardB
+		competition.CumulativeRewardDifference = output.RewardDifference
+		output.StatsA.Rewards += competition.RewardA
+		output.StatsB.Rewards += competition.RewardB
+	}
+	for _, stats := range []*gqlmodels.ComparisonStats{output.StatsA, output.StatsB} {
+		stats.Competitions = len(output.Competitions)
+		if stats.Competitions > 0 {
+			stats.AverageSpeed /= float64(stats.Competitions)
+			stats.AverageAccuracy /= float64(stats.Competitions)
 		}
-		for i, r := range speedRewards {
-			prizeData := &gqlmodels.CompetitionPrize{
-				Rank:   i + 1,
-				Points: r,
+	}
+	return output, nil
+}
+
+////////////////////
+//  Subscription  //
+////////////////////
+
+type subscriptionResolver struct{ *Resolver }
+
+func (r *Resolver) Subscription() SubscriptionResolver {
+	return &subscriptionResolver{r}
+}
+
+// CompetitionUpdated sends the competitions (optionally of an event) whenever they change status or their results change.
+func (r *subscriptionResolver) CompetitionUpdated(ctx context.Context, eventID *string) (<-chan *gqlmodels.Competition, error) {
+	eventID = globalid.DecodeOptional(globalid.Event, eventID)
+	return r.subscribeCompetitions(ctx, func(update notify.CompetitionUpdate) bool {
+		return eventID == nil || update.EventID == *eventID
+	}), nil
+}
+
+// LeaderboardUpdated sends the competition whenever its leaderboard changes (results computed, provisional results refreshed or adjustments made).
+func (r *subscriptionResolver) LeaderboardUpdated(ctx context.Context, competitionID string) (<-chan *gqlmodels.Competition, error) {
+	competitionID = globalid.Decode(globalid.Competition, competitionID)
+	return r.subscribeCompetitions(ctx, func(update notify.CompetitionUpdate) bool {
+		return update.CompetitionID == competitionID &&
+			(update.Status == string(gqlmodels.CompetitionStatusFinished) || update.Status == string(gqlmodels.CompetitionStatusStarted))
+	}), nil
+}
+
+// SyncStatus sends the outcome of the collector syncs (optionally of a team).
+func (r *subscriptionResolver) SyncStatus(ctx context.Context, teamTag *string) (<-chan *gqlmodels.SyncStatus, error) {
+	output := make(chan *gqlmodels.SyncStatus)
+	payloads := r.Broker.Subscribe(ctx, notify.ChannelSyncStatus)
+	go func() {
+		defer close(output)
+		for payload := range payloads {
+			status := notify.SyncStatus{}
+			err := json.Unmarshal([]byte(payload), &status)
+			if err != nil {
+				r.Log.Error("unable to decode sync status", zap.Error(err))
+				continue
+			}
+			if teamTag != nil && !strings.EqualFold(status.TeamTag, *teamTag) {
+				continue
+			}
+			select {
+			case output <- &gqlmodels.SyncStatus{
+				TeamTag:  status.TeamTag,
+				EventIds: status.EventIDs,
+				Status:   gqlmodels.SyncState(status.Status),
+				SyncedAt: status.SyncedAt,
+			}:
+			case <-ctx.Done():
+				return
 			}
-			row.SpeedRewards = append(row.SpeedRewards, prizeData)
 		}
-		for i, r := range accuracyRewards {
-			prizeData := &gqlmodels.CompetitionPrize{
-				Rank:   i + 1,
-				Points: r,
+	}()
+	return output, nil
+}
+
+// subscribeCompetitions sends the latest state of the updated competitions that match the filter.
+func (r *subscriptionResolver) subscribeCompetitions(ctx context.Context, filter func(update notify.CompetitionUpdate) bool) <-chan *gqlmodels.Competition {
+	output := make(chan *gqlmodels.Competition)
+	payloads := r.Broker.Subscribe(ctx, notify.ChannelCompetitionUpdated)
+	go func() {
+		defer close(output)
+		for payload := range payloads {
+			update := notify.CompetitionUpdate{}
+			err := json.Unmarshal([]byte(payload), &update)
+			if err != nil {
+				r.Log.Error("unable to decode competition update", zap.Error(err))
+				continue
+			}
+			if !filter(update) {
+				continue
+			}
+			competition, err := r.getCompetition(ctx, update.CompetitionID)
+			if err != nil {
+				r.Log.Error("unable to fetch updated competition", zap.Error(err))
+				continue
+			}
+			if competition == nil {
+				continue
+			}
+			select {
+			case output <- competition:
+			case <-ctx.Done():
+				return
 			}
-			row.AccuracyRewards = append(row.AccuracyRewards, prizeData)
 		}
-		output = append(output, &row)
+	}()
+	return output
+}
+
+// getCompetition fetches a competition.
+func (r *Resolver) getCompetition(ctx context.Context, id string) (*gqlmodels.Competition, error) {
+	output := &gqlmodels.Competition{}
+	q := `
+		SELECT c.id, c.status, c.multiplier, c.tie_policy, c.tie_breaker, c.tie_breaker_direction, c.min_races, c.min_secs, c.min_typed, c.from_at, c.to_at, c.provisional_at, c.updated_at
+		FROM competitions c
+		WHERE c.id = $1`
+	err := r.Conn.QueryRow(ctx, q, id).Scan(&output.ID, &output.Status, &output.Multiplier, &output.TiePolicy, &output.TieBreaker, &output.TieBreakerDirection, &output.MinRaces, &output.MinSecs, &output.MinTyped, &output.StartAt, &output.FinishAt, &output.ProvisionalAt, &output.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
 	}
-	err = rows.Err()
 	if err != nil {
-		return nil, fmt.Errorf("unable to collect competitions: %w", err)
+		return nil, fmt.Errorf("unable to query competition: %w", err)
 	}
 	return output, nil
 }
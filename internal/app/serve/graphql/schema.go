@@ -0,0 +1,179 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/introspection"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// NewSchema sets up the full GQL schema, with the admin fields guarded by the @admin directive.
+// Fields are costed by the number of items they can return, so the QueryLimits extension can reject expensive operations.
+func NewSchema(resolver *Resolver) graphql.ExecutableSchema {
+	return NewExecutableSchema(
+		Config{
+			Resolvers: resolver,
+			Directives: DirectiveRoot{
+				Admin: requireAdmin,
+			},
+			Complexity: newComplexityRoot(),
+		},
+	)
+}
+
+// ReadOnlySchema is the schema served to the public, it leaves out the @admin fields and the types only they use.
+// Operations are validated against it, so the admin fields can't be queried (or introspected) without an API key.
+// It must also be added to the server as an extension, so the introspection queries return the pruned schema.
+type ReadOnlySchema struct {
+	graphql.ExecutableSchema
+	schema *ast.Schema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &ReadOnlySchema{}
+
+// NewReadOnlySchema prunes the admin fields out of the schema.
+func NewReadOnlySchema(es graphql.ExecutableSchema) *ReadOnlySchema {
+	return &ReadOnlySchema{
+		ExecutableSchema: es,
+		schema:           pruneSchema(es.Schema()),
+	}
+}
+
+// Schema returns the pruned schema.
+func (s *ReadOnlySchema) Schema() *ast.Schema {
+	return s.schema
+}
+
+// ExtensionName is the name of the read only schema extension.
+func (s *ReadOnlySchema) ExtensionName() string {
+	return "ReadOnlySchema"
+}
+
+// Validate accepts any server schema.
+func (s *ReadOnlySchema) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField answers the introspection fields with the pruned schema (the generated ones use the full schema).
+func (s *ReadOnlySchema) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Object != "Query" {
+		return next(ctx)
+	}
+	switch fc.Field.Name {
+	case "__schema":
+		return introspection.WrapSchema(s.schema), nil
+	case "__type":
+		name, _ := fc.Args["name"].(string)
+		return introspection.WrapTypeFromDef(s.schema, s.schema.Types[name]), nil
+	}
+	return next(ctx)
+}
+
+// pruneSchema copies the schema without the @admin fields, keeping only the types that are still reachable.
+func pruneSchema(schema *ast.Schema) *ast.Schema {
+	types := map[string]*ast.Definition{}
+	for name, def := range schema.Types {
+		types[name] = withoutAdminFields(def)
+	}
+
+	// Walk the types from the operations (and keep the built in ones for introspection)
+	reachable := map[string]bool{}
+	pending := []string{}
+	for name, def := range types {
+		if def.BuiltIn {
+			pending = append(pending, name)
+		}
+	}
+	for _, root := range []*ast.Definition{schema.Query, schema.Mutation, schema.Subscription} {
+		if root != nil && len(types[root.Name].Fields) > 0 {
+			pending = append(pending, root.Name)
+		}
+	}
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		def, ok := types[name]
+		if !ok || reachable[name] {
+			continue
+		}
+		reachable[name] = true
+		for _, field := range def.Fields {
+			pending = append(pending, field.Type.Name())
+			for _, arg := range field.Arguments {
+				pending = append(pending, arg.Type.Name())
+			}
+		}
+		pending = append(pending, def.Interfaces...)
+		pending = append(pending, def.Types...)
+		for _, possible := range schema.PossibleTypes[name] {
+			pending = append(pending, possible.Name)
+		}
+	}
+
+	output := &ast.Schema{
+		Types:         map[string]*ast.Definition{},
+		Directives:    map[string]*ast.DirectiveDefinition{},
+		PossibleTypes: map[string][]*ast.Definition{},
+		Implements:    map[string][]*ast.Definition{},
+	}
+	for name := range reachable {
+		output.Types[name] = types[name]
+	}
+	for name, directive := range schema.Directives {
+		if name != "admin" {
+			output.Directives[name] = directive
+		}
+	}
+	for name, defs := range schema.PossibleTypes {
+		if reachable[name] {
+			output.PossibleTypes[name] = reachableDefinitions(output, defs)
+		}
+	}
+	for name, defs := range schema.Implements {
+		if reachable[name] {
+			output.Implements[name] = reachableDefinitions(output, defs)
+		}
+	}
+	if schema.Query != nil {
+		output.Query = output.Types[schema.Query.Name]
+	}
+	if schema.Mutation != nil {
+		output.Mutation = output.Types[schema.Mutation.Name]
+	}
+	if schema.Subscription != nil {
+		output.Subscription = output.Types[schema.Subscription.Name]
+	}
+	return output
+}
+
+// withoutAdminFields copies the type definition without its @admin fields (the definition is returned as is if it has none).
+func withoutAdminFields(def *ast.Definition) *ast.Definition {
+	fields := ast.FieldList{}
+	for _, field := range def.Fields {
+		if field.Directives.ForName("admin") == nil {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == len(def.Fields) {
+		return def
+	}
+	output := *def
+	output.Fields = fields
+	return &output
+}
+
+// reachableDefinitions swaps the definitions for their pruned copies, leaving out the unreachable ones.
+func reachableDefinitions(schema *ast.Schema, defs []*ast.Definition) []*ast.Definition {
+	output := []*ast.Definition{}
+	for _, def := range defs {
+		if pruned, ok := schema.Types[def.Name]; ok {
+			output = append(output, pruned)
+		}
+	}
+	return output
+}
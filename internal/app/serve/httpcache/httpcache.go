@@ -0,0 +1,192 @@
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"nt-folly-xmaxx-comp/internal/app/serve/auth"
+	"nt-folly-xmaxx-comp/internal/app/serve/graphql/gqlmodels"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/gorilla/websocket"
+)
+
+type contextKey string
+
+const policyKey = contextKey("httpcachePolicy")
+
+// stableFields are the fields that don't change once their competition has finished ("Type" allows all the fields of a type).
+var stableFields = map[string]bool{
+	"Query.competitions":        true,
+	"Query.node":                true,
+	"Query.nodes":               true,
+	"CompetitionConnection":     true,
+	"CompetitionEdge":           true,
+	"PageInfo":                  true,
+	"Competition":               true,
+	"CompetitionCategory":       true,
+	"CompetitionPrize":          true,
+	"CompetitionUserConnection": true,
+	"CompetitionUserEdge":       true,
+	"CompetitionUser":           true,
+	"CompetitionUserCategory":   true,
+	"Adjustment":                true,
+	"User.id":                   true,
+	"User.username":             true,
+	"User.displayName":          true,
+	"User.membershipType":       true,
+	"User.status":               true,
+	"User.createdAt":            true,
+	"User.updatedAt":            true,
+}
+
+// policy tracks whether a response only has finished competitions, so it can be cached for longer.
+type policy struct {
+	mu           sync.Mutex
+	live         bool
+	competitions int
+}
+
+// finished checks whether the response only had stable fields of finished competitions.
+func (p *policy) finished() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.live && p.competitions > 0
+}
+
+// TrackFields is a GQL field middleware that records whether the fields resolved can change.
+// It only runs for the GET requests going through the Middleware.
+func TrackFields(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	p, ok := ctx.Value(policyKey).(*policy)
+	if !ok {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	stable := stableFields[fc.Object] || stableFields[fc.Object+"."+fc.Field.Name]
+	res, err := next(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !stable {
+		p.live = true
+	}
+	if competition, ok := res.(*gqlmodels.Competition); ok && competition != nil {
+		p.competitions++
+		if competition.Status != gqlmodels.CompetitionStatusFinished {
+			p.live = true
+		}
+	}
+	return res, err
+}
+
+// Middleware adds ETags (derived from the sync version and the request) to the GQL GET requests,
+// answering the conditional requests with 304 Not Modified.
+// Responses with only finished competitions can be cached for maxAge, the others have to be revalidated.
+// Error responses (e.g. persisted queries that aren't known yet) and admin requests aren't cached.
+func Middleware(version *SyncVersion, maxAge time.Duration) func(http.Handler) http.Handler {
+	// cacheControls remembers the Cache-Control of each ETag, so a 304 can be sent without running the query
+	cacheControls := lru.New(1000)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || websocket.IsWebSocketUpgrade(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if auth.IsAdmin(r.Context()) {
+				w.Header().Set("Cache-Control", "no-store")
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+			etag := requestETag(version.Current(), r)
+			w.Header().Set("Vary", "Authorization")
+			if cacheControl, ok := cacheControls.Get(ctx, etag); ok && matchesETag(r, etag) {
+				w.Header().Set("ETag", etag)
+				w.Header().Set("Cache-Control", cacheControl.(string))
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			p := &policy{}
+			out := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+			next.ServeHTTP(out, r.WithContext(context.WithValue(ctx, policyKey, p)))
+			for k, v := range out.header {
+				w.Header()[k] = v
+			}
+			if out.status != http.StatusOK || hasErrors(out.body.Bytes()) {
+				w.Header().Set("Cache-Control", "no-store")
+				w.WriteHeader(out.status)
+				w.Write(out.body.Bytes())
+				return
+			}
+
+			cacheControl := "public, no-cache"
+			if p.finished() && maxAge > 0 {
+				cacheControl = "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+			}
+			cacheControls.Add(ctx, etag, cacheControl)
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", cacheControl)
+			if matchesETag(r, etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.WriteHeader(out.status)
+			w.Write(out.body.Bytes())
+		})
+	}
+}
+
+// requestETag hashes the sync version with the query string (query, variables and persisted query hash).
+func requestETag(version int64, r *http.Request) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d\n%s\n%s", version, r.URL.Path, r.URL.RawQuery)))
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// matchesETag checks whether the conditional request already has the ETag.
+func matchesETag(r *http.Request, etag string) bool {
+	for _, value := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == etag || value == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasErrors checks whether a GQL response has errors.
+func hasErrors(body []byte) bool {
+	resp := struct {
+		Errors json.RawMessage `json:"errors"`
+	}{}
+	err := json.Unmarshal(body, &resp)
+	return err != nil || len(resp.Errors) > 0
+}
+
+// bufferedResponse holds the response until its headers are worked out.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponse) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponse) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponse) WriteHeader(status int) {
+	w.status = status
+}
@@ -0,0 +1,94 @@
+package httpcache
+
+import (
+	"context"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/app/serve/pubsub"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+// refreshInterval is how often the sync version is reloaded, in case a notification was missed.
+const refreshInterval = 30 * time.Second
+
+// SyncVersion tracks the sync version of the database, it changes whenever a team is synced or the data is changed (results, admin changes).
+// It's stored in the database, so every API server (and restarts) agree on it.
+type SyncVersion struct {
+	conn    *pgxpool.Pool
+	log     *zap.Logger
+	mu      sync.RWMutex
+	version int64
+}
+
+// NewSyncVersion loads the current sync version.
+func NewSyncVersion(ctx context.Context, conn *pgxpool.Pool, log *zap.Logger) (*SyncVersion, error) {
+	v := &SyncVersion{
+		conn: conn,
+		log:  log.With(zap.String("service", "httpcache")),
+	}
+	err := v.Refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Current returns the last loaded sync version.
+func (v *SyncVersion) Current() int64 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.version
+}
+
+// Refresh reloads the sync version.
+func (v *SyncVersion) Refresh(ctx context.Context) error {
+	var version int64
+	err := v.conn.QueryRow(ctx, `SELECT version FROM sync_versions`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("unable to query sync version: %w", err)
+	}
+	v.set(version)
+	return nil
+}
+
+// set moves to a newer sync version (notifications and refreshes can arrive out of order).
+func (v *SyncVersion) set(version int64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if version > v.version {
+		v.version = version
+	}
+}
+
+// Listen takes the sync version from its notifications (and reloads it every so often) until the context is done.
+func (v *SyncVersion) Listen(ctx context.Context, broker *pubsub.Broker) {
+	versions := broker.Subscribe(ctx, notify.ChannelSyncVersion)
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payload, ok := <-versions:
+			if !ok {
+				return
+			}
+			version, err := strconv.ParseInt(payload, 10, 64)
+			if err == nil {
+				v.set(version)
+				continue
+			}
+			v.log.Error("unable to decode sync version", zap.Error(err))
+		case <-ticker.C:
+		}
+		err := v.Refresh(ctx)
+		if err != nil && ctx.Err() == nil {
+			v.log.Error("unable to refresh sync version", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	gqlOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "folly",
+		Subsystem: "gql",
+		Name:      "operations_total",
+		Help:      "Number of GQL responses sent, by operation (subscriptions count each update).",
+	}, []string{"operation", "type", "status"})
+
+	gqlDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "folly",
+		Subsystem: "gql",
+		Name:      "operation_duration_seconds",
+		Help:      "Time taken to resolve a GQL query or mutation, by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "type"})
+
+	dataloaderBatchSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "folly",
+		Subsystem: "dataloader",
+		Name:      "batch_size",
+		Help:      "Number of keys fetched per dataloader batch.",
+		Buckets:   []float64{1, 2, 5, 10, 25, 50, 100, 250},
+	}, []string{"loader"})
+)
+
+// ObserveBatch records the size of a dataloader batch.
+func ObserveBatch(loader string, size int) {
+	dataloaderBatchSize.WithLabelValues(loader).Observe(float64(size))
+}
+
+// maxOperationNames is how many operation names are used as labels, the names are picked by the clients so
+// the ones seen after that are grouped together.
+const maxOperationNames = 100
+
+var operationNames = struct {
+	sync.Mutex
+	seen map[string]bool
+}{seen: map[string]bool{}}
+
+// operationLabel is the label of an operation name, anonymous operations and the names over the limit are grouped together.
+func operationLabel(name string) string {
+	if name == "" {
+		return "anonymous"
+	}
+	operationNames.Lock()
+	defer operationNames.Unlock()
+	if operationNames.seen[name] {
+		return name
+	}
+	if len(operationNames.seen) >= maxOperationNames {
+		return "other"
+	}
+	operationNames.seen[name] = true
+	return name
+}
+
+// Tracer is a GQL server extension that records the operation counts and latencies.
+type Tracer struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = Tracer{}
+
+func (Tracer) ExtensionName() string {
+	return "Metrics"
+}
+
+func (Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse counts each response by operation, see operationLabel for the grouped operations.
+// Subscription updates aren't timed, most of their time is spent waiting for the next update.
+func (Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	opCtx := graphql.GetOperationContext(ctx)
+	operationName, operationType := opCtx.OperationName, "unknown"
+	if opCtx.Operation != nil {
+		operationName = opCtx.Operation.Name
+		operationType = string(opCtx.Operation.Operation)
+	}
+	operation := operationLabel(operationName)
+
+	start := time.Now()
+	resp := next(ctx)
+	if resp == nil {
+		// Subscriptions return nil once they're closed
+		return resp
+	}
+	status := "success"
+	if len(resp.Errors) > 0 {
+		status = "error"
+	}
+	if operationType != "subscription" {
+		gqlDuration.WithLabelValues(operation, operationType).Observe(time.Since(start).Seconds())
+	}
+	gqlOperations.WithLabelValues(operation, operationType, status).Inc()
+	return resp
+}
@@ -0,0 +1,203 @@
+package pagination
+
+import (
+	b64 "encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	// DefaultPageSize is the page size used when first (or last) isn't given.
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page that can be requested.
+	MaxPageSize = 100
+)
+
+var (
+	ErrInvalidCursor   = fmt.Errorf("invalid cursor")
+	ErrInvalidPageSize = fmt.Errorf("page size must be between 0 and %d", MaxPageSize)
+	ErrInvalidArgs     = fmt.Errorf("first and last can't be used together")
+)
+
+// Args are the Relay pagination arguments (first/after or last/before).
+// The values are kept as is so they can be used as dataloader keys.
+type Args struct {
+	Size     int
+	Backward bool
+	After    string
+	Before   string
+}
+
+// NewArgs validates the Relay pagination arguments.
+func NewArgs(first *int, after *string, last *int, before *string) (Args, error) {
+	output := Args{Size: DefaultPageSize}
+	if first != nil && last != nil {
+		return output, ErrInvalidArgs
+	}
+	if first != nil {
+		output.Size = *first
+	}
+	if last != nil {
+		output.Size = *last
+		output.Backward = true
+	}
+	if output.Size < 0 || output.Size > MaxPageSize {
+		return output, ErrInvalidPageSize
+	}
+	if after != nil {
+		output.After = *after
+	}
+	if before != nil {
+		output.Before = *before
+	}
+	return output, nil
+}
+
+// Column is a column a connection is ordered by.
+type Column struct {
+	// Expr is the SQL expression of the column.
+	Expr string
+	// Name is the column name in the query output.
+	Name string
+	// Type is the SQL type of the column (uuid, timestamptz, int or text).
+	Type string
+}
+
+// Keyset is the ordering a connection is paginated by. The last column must be unique (e.g. id).
+type Keyset struct {
+	Columns    []Column
+	Descending bool
+}
+
+// Page is the SQL needed to fetch a page of a keyset.
+type Page struct {
+	Args
+
+	// Conditions are the SQL conditions to only include the rows after (and before) the cursors.
+	Conditions []string
+	// Values are the query arguments used in the conditions.
+	Values []interface{}
+	// Limit is the number of rows to fetch (one more than the page size to know if there are more).
+	Limit int
+	// FetchOrder is the SQL order the rows need to be fetched in (reversed when paginating backwards).
+	FetchOrder string
+	// Order is the SQL order of the page (using the output column names).
+	Order string
+}
+
+// Page builds the SQL to fetch a page. The query arguments are numbered after argCount.
+func (k Keyset) Page(args Args, argCount int) (*Page, error) {
+	output := &Page{
+		Args:       args,
+		Conditions: []string{},
+		Values:     []interface{}{},
+		Limit:      args.Size + 1,
+	}
+	ascending := !k.Descending
+	cursors := []struct {
+		cursor string
+		after  bool
+	}{
+		{args.After, true},
+		{args.Before, false},
+	}
+	for _, c := range cursors {
+		if c.cursor == "" {
+			continue
+		}
+		values, err := k.decode(c.cursor)
+		if err != nil {
+			return nil, err
+		}
+		columns := []string{}
+		params := []string{}
+		for i, col := range k.Columns {
+			output.Values = append(output.Values, values[i])
+			columns = append(columns, col.Expr)
+			params = append(params, fmt.Sprintf("$%d::%s", argCount+len(output.Values), col.Type))
+		}
+		op := "<"
+		if c.after == ascending {
+			op = ">"
+		}
+		output.Conditions = append(output.Conditions, fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, strings.Join(params, ", ")))
+	}
+
+	fetchOrder := []string{}
+	order := []string{}
+	for _, col := range k.Columns {
+		direction := "ASC"
+		if k.Descending {
+			direction = "DESC"
+		}
+		order = append(order, col.Name+" "+direction)
+		if args.Backward == k.Descending {
+			direction = "ASC"
+		} else {
+			direction = "DESC"
+		}
+		fetchOrder = append(fetchOrder, col.Expr+" "+direction)
+	}
+	output.FetchOrder = strings.Join(fetchOrder, ", ")
+	output.Order = strings.Join(order, ", ")
+	return output, nil
+}
+
+// Cursor builds the cursor of a row out of its keyset values.
+func (k Keyset) Cursor(values ...string) string {
+	return b64.RawURLEncoding.EncodeToString([]byte(strings.Join(values, "|")))
+}
+
+// decode reads back the keyset values of a cursor.
+func (k Keyset) decode(cursor string) ([]string, error) {
+	data, err := b64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	values := strings.Split(string(data), "|")
+	if len(values) != len(k.Columns) {
+		return nil, ErrInvalidCursor
+	}
+	for i, col := range k.Columns {
+		var err error
+		switch col.Type {
+		case "uuid":
+			var uuid pgtype.UUID
+			err = uuid.Set(values[i])
+		case "timestamptz":
+			_, err = time.Parse(time.RFC3339Nano, values[i])
+		case "int":
+			_, err = strconv.Atoi(values[i])
+		}
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return values, nil
+}
+
+// Window works out which of the fetched rows (in page order) are part of the page and whether there are more pages.
+// Rows between start and end are kept.
+func (p *Page) Window(fetched int) (start int, end int, hasPreviousPage bool, hasNextPage bool) {
+	end = fetched
+	hasMore := fetched > p.Size
+	if p.Backward {
+		if hasMore {
+			start = fetched - p.Size
+		}
+		return start, end, hasMore, p.Before != ""
+	}
+	if hasMore {
+		end = p.Size
+	}
+	return start, end, p.After != "", hasMore
+}
+
+// FormatTime formats a timestamp for a cursor.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,231 @@
+package pagination
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testKeyset = Keyset{
+	Columns: []Column{
+		{Expr: "c.to_at", Name: "to_at", Type: "timestamptz"},
+		{Expr: "c.id", Name: "id", Type: "uuid"},
+	},
+}
+
+const (
+	testTime = "2021-12-01T00:11:00Z"
+	testID   = "3f1e2a3c-4b5d-4e6f-8a9b-0c1d2e3f4a5b"
+)
+
+func TestKeysetPage(t *testing.T) {
+	cursor := testKeyset.Cursor(testTime, testID)
+	descending := testKeyset
+	descending.Descending = true
+
+	tests := []struct {
+		name           string
+		keyset         Keyset
+		args           Args
+		wantConditions []string
+		wantFetchOrder string
+		wantOrder      string
+	}{
+		{
+			name:           "first page",
+			keyset:         testKeyset,
+			args:           Args{Size: 10},
+			wantConditions: []string{},
+			wantFetchOrder: "c.to_at ASC, c.id ASC",
+			wantOrder:      "to_at ASC, id ASC",
+		},
+		{
+			name:           "forward after cursor",
+			keyset:         testKeyset,
+			args:           Args{Size: 10, After: cursor},
+			wantConditions: []string{"(c.to_at, c.id) > ($3::timestamptz, $4::uuid)"},
+			wantFetchOrder: "c.to_at ASC, c.id ASC",
+			wantOrder:      "to_at ASC, id ASC",
+		},
+		{
+			name:           "backward before cursor",
+			keyset:         testKeyset,
+			args:           Args{Size: 10, Backward: true, Before: cursor},
+			wantConditions: []string{"(c.to_at, c.id) < ($3::timestamptz, $4::uuid)"},
+			wantFetchOrder: "c.to_at DESC, c.id DESC",
+			wantOrder:      "to_at ASC, id ASC",
+		},
+		{
+			name:   "backward between cursors",
+			keyset: testKeyset,
+			args:   Args{Size: 10, Backward: true, After: cursor, Before: cursor},
+			wantConditions: []string{
+				"(c.to_at, c.id) > ($3::timestamptz, $4::uuid)",
+				"(c.to_at, c.id) < ($5::timestamptz, $6::uuid)",
+			},
+			wantFetchOrder: "c.to_at DESC, c.id DESC",
+			wantOrder:      "to_at ASC, id ASC",
+		},
+		{
+			name:           "descending forward after cursor",
+			keyset:         descending,
+			args:           Args{Size: 10, After: cursor},
+			wantConditions: []string{"(c.to_at, c.id) < ($3::timestamptz, $4::uuid)"},
+			wantFetchOrder: "c.to_at DESC, c.id DESC",
+			wantOrder:      "to_at DESC, id DESC",
+		},
+		{
+			name:           "descending backward before cursor",
+			keyset:         descending,
+			args:           Args{Size: 10, Backward: true, Before: cursor},
+			wantConditions: []string{"(c.to_at, c.id) > ($3::timestamptz, $4::uuid)"},
+			wantFetchOrder: "c.to_at ASC, c.id ASC",
+			wantOrder:      "to_at DESC, id DESC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := tt.keyset.Page(tt.args, 2)
+			if err != nil {
+				t.Fatalf("Page() error = %v", err)
+			}
+			if !reflect.DeepEqual(page.Conditions, tt.wantConditions) {
+				t.Errorf("Page() conditions = %q, want %q", page.Conditions, tt.wantConditions)
+			}
+			if len(page.Values) != 2*len(tt.wantConditions) {
+				t.Errorf("Page() values = %v, want %d values", page.Values, 2*len(tt.wantConditions))
+			}
+			if page.FetchOrder != tt.wantFetchOrder {
+				t.Errorf("Page() fetch order = %q, want %q", page.FetchOrder, tt.wantFetchOrder)
+			}
+			if page.Order != tt.wantOrder {
+				t.Errorf("Page() order = %q, want %q", page.Order, tt.wantOrder)
+			}
+			if page.Limit != tt.args.Size+1 {
+				t.Errorf("Page() limit = %d, want %d", page.Limit, tt.args.Size+1)
+			}
+		})
+	}
+}
+
+func TestKeysetDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursor  string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			cursor: testKeyset.Cursor(testTime, testID),
+			want:   []string{testTime, testID},
+		},
+		{
+			name:    "not base64",
+			cursor:  "not a cursor!",
+			wantErr: true,
+		},
+		{
+			name:    "missing column",
+			cursor:  testKeyset.Cursor(testTime),
+			wantErr: true,
+		},
+		{
+			name:    "extra column",
+			cursor:  testKeyset.Cursor(testTime, testID, testID),
+			wantErr: true,
+		},
+		{
+			name:    "invalid time",
+			cursor:  testKeyset.Cursor("yesterday", testID),
+			wantErr: true,
+		},
+		{
+			name:    "invalid uuid",
+			cursor:  testKeyset.Cursor(testTime, "1234"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testKeyset.decode(tt.cursor)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Fatalf("decode() error = %v, want %v", err, ErrInvalidCursor)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageWindow(t *testing.T) {
+	tests := []struct {
+		name                string
+		args                Args
+		fetched             int
+		wantStart           int
+		wantEnd             int
+		wantHasPreviousPage bool
+		wantHasNextPage     bool
+	}{
+		{
+			name:    "forward first page with more",
+			args:    Args{Size: 3},
+			fetched: 4,
+			wantEnd: 3, wantHasNextPage: true,
+		},
+		{
+			name:    "forward last page",
+			args:    Args{Size: 3, After: "cursor"},
+			fetched: 2,
+			wantEnd: 2, wantHasPreviousPage: true,
+		},
+		{
+			name:    "backward with more",
+			args:    Args{Size: 3, Backward: true, Before: "cursor"},
+			fetched: 4,
+			// The extra row is the oldest one, so it's dropped from the start
+			wantStart: 1, wantEnd: 4, wantHasPreviousPage: true, wantHasNextPage: true,
+		},
+		{
+			name:    "backward from the end",
+			args:    Args{Size: 3, Backward: true},
+			fetched: 2,
+			wantEnd: 2,
+		},
+		{
+			name:    "empty page",
+			args:    Args{Size: 0},
+			fetched: 1,
+			wantEnd: 0, wantHasNextPage: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := &Page{Args: tt.args}
+			start, end, hasPreviousPage, hasNextPage := page.Window(tt.fetched)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("Window() = [%d:%d], want [%d:%d]", start, end, tt.wantStart, tt.wantEnd)
+			}
+			if hasPreviousPage != tt.wantHasPreviousPage || hasNextPage != tt.wantHasNextPage {
+				t.Errorf("Window() pages = (%t, %t), want (%t, %t)", hasPreviousPage, hasNextPage, tt.wantHasPreviousPage, tt.wantHasNextPage)
+			}
+		})
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	loc := time.FixedZone("AEST", 10*60*60)
+	got := FormatTime(time.Date(2021, time.December, 1, 10, 11, 0, 0, loc))
+	if got != testTime {
+		t.Errorf("FormatTime() = %q, want %q", got, testTime)
+	}
+}
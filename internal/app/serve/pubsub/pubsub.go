@@ -0,0 +1,110 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap"
+)
+
+const (
+	// reconnectDelay is how long to wait before listening again after losing the connection.
+	reconnectDelay = 5 * time.Second
+	// subscriberBuffer is how many payloads a subscriber can fall behind before missing some.
+	subscriberBuffer = 16
+)
+
+// Broker listens to the Postgres notifications and fans them out to the subscribers.
+type Broker struct {
+	conn        *pgxpool.Pool
+	log         *zap.Logger
+	mu          sync.Mutex
+	subscribers map[string]map[chan string]struct{}
+}
+
+// NewBroker creates a broker, Start needs to be called for it to receive notifications.
+func NewBroker(conn *pgxpool.Pool, log *zap.Logger) *Broker {
+	return &Broker{
+		conn:        conn,
+		log:         log.With(zap.String("service", "pubsub")),
+		subscribers: map[string]map[chan string]struct{}{},
+	}
+}
+
+// Start listens to the notifications until the context is done, reconnecting when the connection is lost.
+func (b *Broker) Start(ctx context.Context) {
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		b.log.Error("stopped listening to notifications", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// listen uses a dedicated connection (not from the pool) so it can wait for notifications.
+func (b *Broker) listen(ctx context.Context) error {
+	conn, err := pgx.ConnectConfig(ctx, b.conn.Config().ConnConfig)
+	if err != nil {
+		return fmt.Errorf("unable to connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	for _, channel := range notify.Channels {
+		_, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+		if err != nil {
+			return fmt.Errorf("unable to listen to %s: %w", channel, err)
+		}
+	}
+	b.log.Info("listening to notifications")
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to wait for notification: %w", err)
+		}
+		b.publish(n.Channel, n.Payload)
+	}
+}
+
+// publish sends a payload to the subscribers of a channel. Subscribers that are too far behind miss it.
+func (b *Broker) publish(channel string, payload string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[channel] {
+		select {
+		case ch <- payload:
+		default:
+			b.log.Warn("subscriber is too slow, dropping notification", zap.String("channel", channel))
+		}
+	}
+}
+
+// Subscribe receives the payloads of a channel until the context is done.
+func (b *Broker) Subscribe(ctx context.Context, channel string) <-chan string {
+	ch := make(chan string, subscriberBuffer)
+	b.mu.Lock()
+	if b.subscribers[channel] == nil {
+		b.subscribers[channel] = map[chan string]struct{}{}
+	}
+	b.subscribers[channel][ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subscribers[channel], ch)
+		b.mu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
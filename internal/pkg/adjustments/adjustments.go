@@ -0,0 +1,162 @@
+package adjustments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrUserNotFound        = fmt.Errorf("user not found")
+	ErrCompetitionNotFound = fmt.Errorf("competition not found")
+	ErrAdjustmentNotFound  = fmt.Errorf("adjustment not found")
+)
+
+// Adjustment is a manual bonus (positive points) or penalty (negative points) given to a racer.
+type Adjustment struct {
+	ID            string
+	UserID        string
+	CompetitionID *string
+	Points        int
+	Reason        string
+	Author        string
+	CreatedAt     time.Time
+}
+
+// Add records a new adjustment. The competition is optional, adjustments without one only count towards the racer's total.
+func Add(ctx context.Context, conn *pgxpool.Pool, userID string, competitionID *string, points int, reason string, author string) (*Adjustment, error) {
+	reason = strings.TrimSpace(reason)
+	author = strings.TrimSpace(author)
+	if points == 0 {
+		return nil, fmt.Errorf("points must not be zero")
+	}
+	if reason == "" || author == "" {
+		return nil, fmt.Errorf("reason and author are required")
+	}
+
+	exists := false
+	q := `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`
+	err := conn.QueryRow(ctx, q, userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query user: %w", err)
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	if competitionID != nil {
+		q = `SELECT EXISTS (SELECT 1 FROM competitions WHERE id = $1 AND deleted_at IS NULL)`
+		err = conn.QueryRow(ctx, q, *competitionID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("unable to query competition: %w", err)
+		}
+		if !exists {
+			return nil, ErrCompetitionNotFound
+		}
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start adding adjustment: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	output := &Adjustment{}
+	q = `
+		INSERT INTO adjustments (user_id, competition_id, points, reason, author)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id::text, user_id::text, competition_id::text, points, reason, author, created_at`
+	err = tx.QueryRow(ctx, q, userID, competitionID, points, reason, author).Scan(
+		&output.ID, &output.UserID, &output.CompetitionID, &output.Points, &output.Reason, &output.Author, &output.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to insert adjustment: %w", err)
+	}
+	if output.CompetitionID != nil {
+		err = notify.Competitions(ctx, tx, *output.CompetitionID)
+	} else {
+		err = notify.BumpVersion(ctx, tx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to finish adding adjustment: %w", err)
+	}
+	return output, nil
+}
+
+// Remove deletes an adjustment.
+func Remove(ctx context.Context, conn *pgxpool.Pool, id string) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start removing adjustment: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+		UPDATE adjustments
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+			AND deleted_at IS NULL
+		RETURNING competition_id::text`
+	var competitionID *string
+	err = tx.QueryRow(ctx, q, id).Scan(&competitionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrAdjustmentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to remove adjustment: %w", err)
+	}
+	if competitionID != nil {
+		err = notify.Competitions(ctx, tx, *competitionID)
+	} else {
+		err = notify.BumpVersion(ctx, tx)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to finish removing adjustment: %w", err)
+	}
+	return nil
+}
+
+// List fetches the adjustments, optionally filtered by user and competition.
+func List(ctx context.Context, conn *pgxpool.Pool, userID *string, competitionID *string) ([]*Adjustment, error) {
+	q := `
+		SELECT id::text, user_id::text, competition_id::text, points, reason, author, created_at
+		FROM adjustments
+		WHERE deleted_at IS NULL
+			AND ($1::uuid IS NULL OR user_id = $1)
+			AND ($2::uuid IS NULL OR competition_id = $2)
+		ORDER BY created_at`
+	rows, err := conn.Query(ctx, q, userID, competitionID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query adjustments: %w", err)
+	}
+	defer rows.Close()
+	output := []*Adjustment{}
+	for rows.Next() {
+		row := &Adjustment{}
+		err := rows.Scan(&row.ID, &row.UserID, &row.CompetitionID, &row.Points, &row.Reason, &row.Author, &row.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan adjustments: %w", err)
+		}
+		output = append(output, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan adjustments: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,143 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// keyPrefix marks the API keys so they're easy to spot (and revoke) when leaked.
+const keyPrefix = "ntc_"
+
+var (
+	ErrInvalidKey  = fmt.Errorf("invalid api key")
+	ErrKeyNotFound = fmt.Errorf("api key not found")
+)
+
+// Key is an API key giving access to the admin mutations.
+type Key struct {
+	ID         string
+	Name       string
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// Create generates a new API key. Only its hash is stored, so the returned token can't be shown again.
+func Create(ctx context.Context, conn *pgxpool.Pool, name string) (*Key, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("api key name is required")
+	}
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to generate api key: %w", err)
+	}
+	token := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	hash, err := utils.HashData([]byte(token))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to hash api key: %w", err)
+	}
+
+	output := &Key{}
+	q := `
+		INSERT INTO api_keys (name, key_hash)
+		VALUES ($1, $2)
+		RETURNING id::text, name, last_used_at, revoked_at, created_at`
+	err = conn.QueryRow(ctx, q, name, hash).Scan(&output.ID, &output.Name, &output.LastUsedAt, &output.RevokedAt, &output.CreatedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to insert api key: %w", err)
+	}
+	return output, token, nil
+}
+
+// Verify finds the active API key matching the token and records when it was last used.
+func Verify(ctx context.Context, conn *pgxpool.Pool, token string) (*Key, error) {
+	if !strings.HasPrefix(token, keyPrefix) {
+		return nil, ErrInvalidKey
+	}
+	hash, err := utils.HashData([]byte(token))
+	if err != nil {
+		return nil, fmt.Errorf("unable to hash api key: %w", err)
+	}
+
+	output := &Key{}
+	q := `
+		SELECT id::text, name, last_used_at, revoked_at, created_at
+		FROM api_keys
+		WHERE key_hash = $1
+			AND revoked_at IS NULL`
+	err = conn.QueryRow(ctx, q, hash).Scan(&output.ID, &output.Name, &output.LastUsedAt, &output.RevokedAt, &output.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to query api key: %w", err)
+	}
+
+	// Only bump the usage once a minute, so busy keys don't write on every request
+	q = `
+		UPDATE api_keys
+		SET last_used_at = NOW()
+		WHERE id = $1
+			AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+	_, err = conn.Exec(ctx, q, output.ID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to update api key usage: %w", err)
+	}
+	return output, nil
+}
+
+// List fetches the API keys (including the revoked ones).
+func List(ctx context.Context, conn *pgxpool.Pool) ([]*Key, error) {
+	q := `
+		SELECT id::text, name, last_used_at, revoked_at, created_at
+		FROM api_keys
+		ORDER BY created_at`
+	rows, err := conn.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query api keys: %w", err)
+	}
+	defer rows.Close()
+	output := []*Key{}
+	for rows.Next() {
+		row := &Key{}
+		err := rows.Scan(&row.ID, &row.Name, &row.LastUsedAt, &row.RevokedAt, &row.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan api keys: %w", err)
+		}
+		output = append(output, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan api keys: %w", err)
+	}
+	return output, nil
+}
+
+// Revoke disables an API key.
+func Revoke(ctx context.Context, conn *pgxpool.Pool, id string) error {
+	q := `
+		UPDATE api_keys
+		SET revoked_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+			AND revoked_at IS NULL
+		RETURNING id`
+	err := conn.QueryRow(ctx, q, id).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrKeyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to revoke api key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package exclusions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"nt-folly-xmaxx-comp/internal/pkg/results"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrUserNotFound      = fmt.Errorf("user not found")
+	ErrEventNotFound     = fmt.Errorf("event not found")
+	ErrExclusionNotFound = fmt.Errorf("exclusion not found")
+)
+
+// Exclusion keeps a racer out of the results of an event (or every event when there's no event).
+type Exclusion struct {
+	ID        string
+	UserID    string
+	EventID   *string
+	Reason    string
+	Author    string
+	CreatedAt time.Time
+}
+
+// Add records a new exclusion and recomputes the finished competitions the racer was placed in.
+func Add(ctx context.Context, conn *pgxpool.Pool, userID string, eventID *string, reason string, author string) (*Exclusion, error) {
+	reason = strings.TrimSpace(reason)
+	author = strings.TrimSpace(author)
+	if reason == "" || author == "" {
+		return nil, fmt.Errorf("reason and author are required")
+	}
+
+	exists := false
+	q := `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`
+	err := conn.QueryRow(ctx, q, userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query user: %w", err)
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	if eventID != nil {
+		q = `SELECT EXISTS (SELECT 1 FROM events WHERE id = $1 AND deleted_at IS NULL)`
+		err = conn.QueryRow(ctx, q, *eventID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("unable to query event: %w", err)
+		}
+		if !exists {
+			return nil, ErrEventNotFound
+		}
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start adding exclusion: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	output := &Exclusion{}
+	q = `
+		INSERT INTO exclusions (user_id, event_id, reason, author)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id::text, user_id::text, event_id::text, reason, author, created_at`
+	err = tx.QueryRow(ctx, q, userID, eventID, reason, author).Scan(
+		&output.ID, &output.UserID, &output.EventID, &output.Reason, &output.Author, &output.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to insert exclusion: %w", err)
+	}
+	err = notify.BumpVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = results.RecomputeUser(ctx, tx, output.UserID, output.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to finish adding exclusion: %w", err)
+	}
+	return output, nil
+}
+
+// Remove deletes an exclusion and recomputes the finished competitions the racer raced in.
+func Remove(ctx context.Context, conn *pgxpool.Pool, id string) error {
+	var (
+		userID  string
+		eventID *string
+	)
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start removing exclusion: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+		UPDATE exclusions
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+			AND deleted_at IS NULL
+		RETURNING user_id::text, event_id::text`
+	err = tx.QueryRow(ctx, q, id).Scan(&userID, &eventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrExclusionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to remove exclusion: %w", err)
+	}
+	err = notify.BumpVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+	_, err = results.RecomputeUser(ctx, tx, userID, eventID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to finish removing exclusion: %w", err)
+	}
+	return nil
+}
+
+// List fetches the exclusions, optionally filtered by event (exclusions covering every event are always included).
+func List(ctx context.Context, conn *pgxpool.Pool, eventID *string) ([]*Exclusion, error) {
+	q := `
+		SELECT id::text, user_id::text, event_id::text, reason, author, created_at
+		FROM exclusions
+		WHERE deleted_at IS NULL
+			AND ($1::uuid IS NULL OR event_id IS NULL OR event_id = $1)
+		ORDER BY created_at`
+	rows, err := conn.Query(ctx, q, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query exclusions: %w", err)
+	}
+	defer rows.Close()
+	output := []*Exclusion{}
+	for rows.Next() {
+		row := &Exclusion{}
+		err := rows.Scan(&row.ID, &row.UserID, &row.EventID, &row.Reason, &row.Author, &row.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan exclusions: %w", err)
+		}
+		output = append(output, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan exclusions: %w", err)
+	}
+	return output, nil
+}
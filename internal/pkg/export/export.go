@@ -0,0 +1,172 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidFormat = fmt.Errorf("export format must be csv or ndjson")
+)
+
+// Format is the file format of an export.
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat reads the export format (CSV if blank).
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatNDJSON:
+		return FormatNDJSON, nil
+	}
+	return "", ErrInvalidFormat
+}
+
+// ContentType is the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// flushEvery is how many rows are written before they're flushed to the output.
+const flushEvery = 100
+
+// flusher is an output that buffers what's written (e.g. an HTTP response).
+type flusher interface {
+	Flush()
+}
+
+// Writer streams the rows of an export as CSV (with a header row) or NDJSON (one object per row).
+type Writer struct {
+	out     io.Writer
+	format  Format
+	columns []string
+	csv     *csv.Writer
+	rows    int
+}
+
+// NewWriter starts an export with the given columns.
+func NewWriter(out io.Writer, format Format, columns []string) (*Writer, error) {
+	w := &Writer{
+		out:     out,
+		format:  format,
+		columns: columns,
+	}
+	if format == FormatCSV {
+		w.csv = csv.NewWriter(out)
+		err := w.csv.Write(columns)
+		if err != nil {
+			return nil, fmt.Errorf("unable to write csv header: %w", err)
+		}
+	}
+	return w, nil
+}
+
+// Write adds a row, the values must be in the same order as the columns.
+func (w *Writer) Write(values ...interface{}) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(w.columns), len(values))
+	}
+	if w.format == FormatCSV {
+		record := make([]string, len(values))
+		for i, value := range values {
+			record[i] = formatValue(value)
+		}
+		err := w.csv.Write(record)
+		if err != nil {
+			return fmt.Errorf("unable to write csv row: %w", err)
+		}
+	} else {
+		// Build the object by hand so the keys keep the column order
+		buf := &bytes.Buffer{}
+		buf.WriteByte('{')
+		for i, value := range values {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(w.columns[i])
+			if err != nil {
+				return fmt.Errorf("unable to encode ndjson key: %w", err)
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("unable to encode ndjson value: %w", err)
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(data)
+		}
+		buf.WriteString("}\n")
+		_, err := w.out.Write(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("unable to write ndjson row: %w", err)
+		}
+	}
+	w.rows++
+	if w.rows%flushEvery == 0 {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush sends the rows written so far to the output.
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		err := w.csv.Error()
+		if err != nil {
+			return fmt.Errorf("unable to flush csv: %w", err)
+		}
+	}
+	if f, ok := w.out.(flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// formatValue converts a value into its CSV text (nil values are blank).
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case int:
+		return strconv.Itoa(v)
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,168 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"nt-folly-xmaxx-comp/internal/pkg/reports"
+	"strings"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrCompetitionNotFound = fmt.Errorf("competition not found")
+	ErrEventNotFound       = fmt.Errorf("event not found")
+	ErrUserNotFound        = fmt.Errorf("user not found")
+)
+
+// RecordsFilter narrows down the raw user records.
+type RecordsFilter struct {
+	UserID        *string
+	CompetitionID *string
+	TimeRange     *reports.TimeRange
+}
+
+// Leaderboard exports a competition leaderboard, ordered by total points (or rank when a category is given).
+// Started competitions use their provisional results.
+func Leaderboard(ctx context.Context, conn *pgxpool.Pool, out io.Writer, format Format, competitionID string, filter reports.LeaderboardFilter) error {
+	exists := false
+	q := `SELECT EXISTS (SELECT 1 FROM competitions WHERE id = $1 AND deleted_at IS NULL)`
+	err := conn.QueryRow(ctx, q, competitionID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("unable to query competition: %w", err)
+	}
+	if !exists {
+		return ErrCompetitionNotFound
+	}
+
+	q, args := reports.LeaderboardQuery([]string{competitionID}, filter)
+	if filter.Category == nil {
+		q = `
+			SELECT l.position, l.user_id::text, u.username, COALESCE(NULLIF(u.display_name, ''), u.username), l.points
+			FROM (` + q + `) l
+				INNER JOIN users u ON u.id = l.user_id
+			ORDER BY l.position`
+		columns := []string{"position", "user_id", "username", "display_name", "points"}
+		return stream(ctx, conn, out, format, columns, q, args...)
+	}
+
+	q = `
+		SELECT l.position, NULLIF(l.rank, ` + fmt.Sprint(reports.UnrankedRank) + `), l.tied, l.user_id::text, u.username, COALESCE(NULLIF(u.display_name, ''), u.username),
+			l.score, l.points, l.eligible, l.ineligible_reason
+		FROM (` + q + `) l
+			INNER JOIN users u ON u.id = l.user_id
+		ORDER BY l.position`
+	columns := []string{"position", "rank", "tied", "user_id", "username", "display_name", "score", "reward", "eligible", "ineligible_reason"}
+	return stream(ctx, conn, out, format, columns, q, args...)
+}
+
+// Standings exports the overall standings of an event, ranking the racers by their points.
+func Standings(ctx context.Context, conn *pgxpool.Pool, out io.Writer, format Format, eventID string, filter reports.StandingsFilter) error {
+	exists := false
+	q := `SELECT EXISTS (SELECT 1 FROM events WHERE id = $1 AND deleted_at IS NULL)`
+	err := conn.QueryRow(ctx, q, eventID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("unable to query event: %w", err)
+	}
+	if !exists {
+		return ErrEventNotFound
+	}
+
+	q, args := reports.StandingsQuery(eventID, filter)
+	q = `
+		SELECT s.rank, s.tied, s.user_id::text, u.username, COALESCE(NULLIF(u.display_name, ''), u.username),
+			s.total_points, s.rewards, s.competitions_won, s.gap_to_next_rank
+		FROM (` + q + `) s
+			INNER JOIN users u ON u.id = s.user_id
+		ORDER BY s.total_points DESC, lower(u.username) ASC`
+	columns := []string{"rank", "tied", "user_id", "username", "display_name", "total_points", "rewards", "competitions_won", "gap_to_next_rank"}
+	return stream(ctx, conn, out, format, columns, q, args...)
+}
+
+// UserHistory exports a racer's category results in each finished competition, most recent first.
+func UserHistory(ctx context.Context, conn *pgxpool.Pool, out io.Writer, format Format, userID string, timeRange *reports.TimeRange) error {
+	exists := false
+	q := `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`
+	err := conn.QueryRow(ctx, q, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("unable to query user: %w", err)
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	args := []interface{}{userID}
+	conditions := []string{"r.user_id = $1"}
+	if timeRange != nil {
+		args = append(args, timeRange.TimeFrom, timeRange.TimeTo)
+		conditions = append(conditions, "c.from_at >= $2", "c.to_at <= $3")
+	}
+	q = `
+		SELECT c.id::text, c.event_id::text, e.name, c.from_at, c.to_at, c.multiplier,
+			cat.name, r.score::float, r.rank, r.tied, r.reward, r.eligible, r.ineligible_reason
+		FROM competition_results r
+			INNER JOIN competitions c ON c.id = r.competition_id
+			INNER JOIN events e ON e.id = c.event_id
+			INNER JOIN categories cat ON cat.id = r.category_id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY c.to_at DESC, c.id, cat.position, cat.name`
+	columns := []string{"competition_id", "event_id", "event_name", "from_at", "to_at", "multiplier", "category", "score", "rank", "tied", "reward", "eligible", "ineligible_reason"}
+	return stream(ctx, conn, out, format, columns, q, args...)
+}
+
+// UserRecords exports the raw user records collected from the team logs, oldest first.
+func UserRecords(ctx context.Context, conn *pgxpool.Pool, out io.Writer, format Format, filter RecordsFilter) error {
+	args := []interface{}{}
+	conditions := []string{"ur.deleted_at IS NULL"}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("ur.user_id = $%d", len(args)))
+	}
+	if filter.CompetitionID != nil {
+		args = append(args, *filter.CompetitionID)
+		conditions = append(conditions, fmt.Sprintf("ur.request_id = (SELECT _c.request_id FROM competitions _c WHERE _c.id = $%d)", len(args)))
+	}
+	if filter.TimeRange != nil {
+		args = append(args, filter.TimeRange.TimeFrom, filter.TimeRange.TimeTo)
+		conditions = append(conditions, fmt.Sprintf("ur.from_at >= $%d AND ur.to_at <= $%d", len(args)-1, len(args)))
+	}
+	q := `
+		SELECT ur.id::text, ur.request_id::text, ur.user_id::text, u.username,
+			ur.played, ur.typed, ur.errs, ur.secs, ur.from_at, ur.to_at, ur.created_at
+		FROM user_records ur
+			INNER JOIN users u ON u.id = ur.user_id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ur.to_at, lower(u.username)`
+	columns := []string{"id", "request_id", "user_id", "username", "played", "typed", "errs", "secs", "from_at", "to_at", "created_at"}
+	return stream(ctx, conn, out, format, columns, q, args...)
+}
+
+// stream writes the rows of the query as they're read.
+// The query must select the columns in order, as plain values (text, int, float, bool or timestamptz).
+func stream(ctx context.Context, conn *pgxpool.Pool, out io.Writer, format Format, columns []string, q string, args ...interface{}) error {
+	rows, err := conn.Query(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("unable to query export: %w", err)
+	}
+	defer rows.Close()
+	w, err := NewWriter(out, format, columns)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return fmt.Errorf("unable to scan export: %w", err)
+		}
+		err = w.Write(values...)
+		if err != nil {
+			return err
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return fmt.Errorf("unable to scan export: %w", err)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,238 @@
+package manage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// CompetitionAudit explains how the results of a competition were produced.
+type CompetitionAudit struct {
+	ID         string
+	EventName  string
+	Status     string
+	Multiplier int
+	TiePolicy  string
+	FromAt     time.Time
+	ToAt       time.Time
+
+	// PrevLog and NextLog are the team logs compared to get the member stats (missing if the competition was never recorded).
+	PrevLog *AuditTeamLog
+	NextLog *AuditTeamLog
+
+	Members []*AuditMember
+}
+
+// AuditTeamLog is a team log request used by a competition.
+type AuditTeamLog struct {
+	RequestID    string
+	LogID        string
+	Hash         string
+	ResponseType string
+	CreatedAt    time.Time
+}
+
+// AuditCounters are the Nitro Type team member stat counters.
+type AuditCounters struct {
+	Played *int
+	Typed  *int
+	Errs   *int
+	Secs   *int
+}
+
+// AuditMember is a team member's stats and results in the competition.
+type AuditMember struct {
+	ReferenceID int
+	Username    string
+	UserID      *string
+	Status      *string
+
+	Prev  AuditCounters
+	Next  AuditCounters
+	Delta AuditCounters
+	// Recorded is whether the member has a user record for the competition (only members that have raced are recorded).
+	Recorded bool
+
+	Speed    *float64
+	Accuracy *float64
+	Points   *float64
+
+	Categories []*AuditCategory
+}
+
+// AuditCategory is a member's result in a competition category.
+type AuditCategory struct {
+	Name             string
+	Score            float64
+	Eligible         bool
+	IneligibleReason *string
+	Rank             *int
+	Tied             int
+	Reward           int
+}
+
+// AuditCompetition collects the team logs, member counters and results of a competition.
+func AuditCompetition(ctx context.Context, conn *pgxpool.Pool, competitionID string) (*CompetitionAudit, error) {
+	output := &CompetitionAudit{}
+	var (
+		nextRequestID    *string
+		nextLogID        *string
+		nextHash         *string
+		nextResponseType *string
+		nextCreatedAt    *time.Time
+		prevRequestID    *string
+		prevLogID        *string
+		prevHash         *string
+		prevResponseType *string
+		prevCreatedAt    *time.Time
+	)
+	q := `
+		SELECT c.id::text, e.name, c.status, c.multiplier, c.tie_policy, c.from_at, c.to_at,
+			r1.id::text, l1.id::text, encode(l1.hash, 'hex'), r1.response_type, r1.created_at,
+			r2.id::text, l2.id::text, encode(l2.hash, 'hex'), r2.response_type, r2.created_at
+		FROM competitions c
+			INNER JOIN events e ON e.id = c.event_id
+			LEFT JOIN nt_api_team_log_requests r1 ON r1.id = c.request_id
+			LEFT JOIN nt_api_team_logs l1 ON l1.id = r1.api_team_log_id
+			LEFT JOIN nt_api_team_log_requests r2 ON r2.id = r1.prev_id
+			LEFT JOIN nt_api_team_logs l2 ON l2.id = r2.api_team_log_id
+		WHERE c.id = $1
+			AND c.deleted_at IS NULL`
+	err := conn.QueryRow(ctx, q, competitionID).Scan(
+		&output.ID, &output.EventName, &output.Status, &output.Multiplier, &output.TiePolicy, &output.FromAt, &output.ToAt,
+		&nextRequestID, &nextLogID, &nextHash, &nextResponseType, &nextCreatedAt,
+		&prevRequestID, &prevLogID, &prevHash, &prevResponseType, &prevCreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCompetitionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to query competition: %w", err)
+	}
+	if nextRequestID != nil {
+		output.NextLog = &AuditTeamLog{
+			RequestID:    *nextRequestID,
+			LogID:        *nextLogID,
+			Hash:         *nextHash,
+			ResponseType: *nextResponseType,
+			CreatedAt:    *nextCreatedAt,
+		}
+	}
+	if prevRequestID != nil {
+		output.PrevLog = &AuditTeamLog{
+			RequestID:    *prevRequestID,
+			LogID:        *prevLogID,
+			Hash:         *prevHash,
+			ResponseType: *prevResponseType,
+			CreatedAt:    *prevCreatedAt,
+		}
+	}
+	if output.NextLog == nil || output.PrevLog == nil {
+		output.Members = []*AuditMember{}
+		return output, nil
+	}
+
+	// Member counters from both logs
+	q = `
+		SELECT (m1->>'userID')::int AS reference_id,
+			m1->>'username' AS username,
+			u.id::text,
+			u.status,
+			(m2->>'played')::int, (m2->>'typed')::int, (m2->>'errs')::int, (m2->>'secs')::int,
+			(m1->>'played')::int, (m1->>'typed')::int, (m1->>'errs')::int, (m1->>'secs')::int,
+			((m1->>'played')::int - (m2->>'played')::int),
+			((m1->>'typed')::int - (m2->>'typed')::int),
+			((m1->>'errs')::int - (m2->>'errs')::int),
+			((m1->>'secs')::int - (m2->>'secs')::int),
+			(ur.id IS NOT NULL) AS recorded,
+			s.speed::float,
+			s.accuracy::float,
+			s.points::float
+		FROM nt_api_team_log_requests r1
+			INNER JOIN nt_api_team_log_requests r2 ON r2.id = r1.prev_id
+			INNER JOIN nt_api_team_logs l1 ON l1.id = r1.api_team_log_id AND json_typeof(l1.log_data->'data'->'members') = 'array'
+			INNER JOIN nt_api_team_logs l2 ON l2.id = r2.api_team_log_id AND json_typeof(l2.log_data->'data'->'members') = 'array'
+			INNER JOIN json_array_elements(l1.log_data->'data'->'members') AS m1 ON m1->>'userID' IS NOT NULL
+			LEFT JOIN json_array_elements(l2.log_data->'data'->'members') AS m2 ON (m1->>'userID')::int = (m2->>'userID')::int
+			LEFT JOIN users u ON u.reference_id = (m1->>'userID')::int
+			LEFT JOIN user_records ur ON ur.request_id = r1.id
+				AND ur.user_id = u.id
+				AND ur.deleted_at IS NULL
+			LEFT JOIN competition_user_stats s ON s.competition_id = $2
+				AND s.user_id = u.id
+		WHERE r1.id = $1
+		ORDER BY s.points DESC NULLS LAST, m1->>'username'`
+	rows, err := conn.Query(ctx, q, output.NextLog.RequestID, competitionID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query competition members: %w", err)
+	}
+	defer rows.Close()
+
+	output.Members = []*AuditMember{}
+	membersByID := map[string]*AuditMember{}
+	for rows.Next() {
+		row := &AuditMember{
+			Categories: []*AuditCategory{},
+		}
+		err := rows.Scan(
+			&row.ReferenceID, &row.Username, &row.UserID, &row.Status,
+			&row.Prev.Played, &row.Prev.Typed, &row.Prev.Errs, &row.Prev.Secs,
+			&row.Next.Played, &row.Next.Typed, &row.Next.Errs, &row.Next.Secs,
+			&row.Delta.Played, &row.Delta.Typed, &row.Delta.Errs, &row.Delta.Secs,
+			&row.Recorded, &row.Speed, &row.Accuracy, &row.Points,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan competition members: %w", err)
+		}
+		output.Members = append(output.Members, row)
+		if row.UserID != nil {
+			membersByID[*row.UserID] = row
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan competition members: %w", err)
+	}
+
+	// Category results
+	q = `
+		SELECT r.user_id::text,
+			cat.name,
+			r.score::float,
+			r.eligible,
+			r.ineligible_reason,
+			r.rank,
+			r.tied,
+			r.reward
+		FROM competition_results r
+			INNER JOIN categories cat ON cat.id = r.category_id
+		WHERE r.competition_id = $1
+		ORDER BY cat.position, cat.name`
+	rows, err = conn.Query(ctx, q, competitionID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query competition results: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		userID := ""
+		row := &AuditCategory{}
+		err := rows.Scan(&userID, &row.Name, &row.Score, &row.Eligible, &row.IneligibleReason, &row.Rank, &row.Tied, &row.Reward)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan competition results: %w", err)
+		}
+		member, ok := membersByID[userID]
+		if !ok {
+			continue
+		}
+		member.Categories = append(member.Categories, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan competition results: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,278 @@
+package manage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"nt-folly-xmaxx-comp/internal/pkg/utils"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrCompetitionNotDraft = fmt.Errorf("only draft competitions can be changed")
+	ErrCompetitionClosed   = fmt.Errorf("competition has already closed")
+	ErrCompetitionOverlap  = fmt.Errorf("competition overlaps another competition of the event")
+	ErrInvalidTimeRange    = fmt.Errorf("time from must be before time to")
+)
+
+// CompetitionChanges are the settings of a draft competition that can be changed (nil values are left as is).
+type CompetitionChanges struct {
+	TimeFrom *time.Time
+	TimeTo   *time.Time
+	MinRaces *int
+	MinSecs  *int
+	MinTyped *int
+}
+
+// CreateCompetition adds a competition to an event, using the event's settings and category rewards.
+// The times are rounded the same way as the seeded competitions, so they line up with the collector's syncs.
+func CreateCompetition(ctx context.Context, conn *pgxpool.Pool, eventID string, timeFrom time.Time, timeTo time.Time, multiplier int) (string, error) {
+	if multiplier < 1 {
+		return "", fmt.Errorf("multiplier must be at least 1")
+	}
+	timezone := ""
+	q := `SELECT timezone FROM events WHERE id = $1 AND deleted_at IS NULL`
+	err := conn.QueryRow(ctx, q, eventID).Scan(&timezone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrEventNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to query event: %w", err)
+	}
+	loc, err := utils.LoadTimezone(timezone)
+	if err != nil {
+		return "", err
+	}
+	timeFrom = utils.TimeRoundIn(timeFrom, loc)
+	timeTo = utils.TimeRoundIn(timeTo, loc)
+	if !timeFrom.Before(timeTo) {
+		return "", ErrInvalidTimeRange
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to start creating competition: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = checkOverlap(ctx, tx, eventID, nil, timeFrom, timeTo)
+	if err != nil {
+		return "", err
+	}
+	competitionID := ""
+	q = `
+		INSERT INTO competitions (event_id, multiplier, tie_policy, tie_breaker, tie_breaker_direction, min_races, min_secs, min_typed, from_at, to_at)
+		SELECT e.id, $2, e.tie_policy, e.tie_breaker, e.tie_breaker_direction, e.min_races, e.min_secs, e.min_typed, $3, $4
+		FROM events e
+		WHERE e.id = $1
+		RETURNING id::text`
+	err = tx.QueryRow(ctx, q, eventID, multiplier, timeFrom, timeTo).Scan(&competitionID)
+	if err != nil {
+		return "", fmt.Errorf("unable to insert competition: %w", err)
+	}
+	q = `
+		INSERT INTO competition_categories (competition_id, category_id, rewards)
+		SELECT $1, ec.category_id, ec.rewards
+		FROM event_categories ec
+		WHERE ec.event_id = $2`
+	_, err = tx.Exec(ctx, q, competitionID, eventID)
+	if err != nil {
+		return "", fmt.Errorf("unable to insert competition categories: %w", err)
+	}
+	err = notify.Competitions(ctx, tx, competitionID)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to finish creating competition: %w", err)
+	}
+	return competitionID, nil
+}
+
+// UpdateCompetition changes the settings of a competition that hasn't started yet.
+func UpdateCompetition(ctx context.Context, conn *pgxpool.Pool, competitionID string, changes CompetitionChanges) error {
+	if (changes.MinRaces != nil && *changes.MinRaces < 1) ||
+		(changes.MinSecs != nil && *changes.MinSecs < 0) ||
+		(changes.MinTyped != nil && *changes.MinTyped < 0) {
+		return fmt.Errorf("activity requirements must not be negative (and at least 1 race)")
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start updating competition: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		eventID  string
+		status   string
+		timezone string
+		timeFrom time.Time
+		timeTo   time.Time
+	)
+	q := `
+		SELECT c.event_id::text, c.status, e.timezone, c.from_at, c.to_at
+		FROM competitions c
+			INNER JOIN events e ON e.id = c.event_id
+		WHERE c.id = $1
+			AND c.deleted_at IS NULL
+		FOR UPDATE OF c`
+	err = tx.QueryRow(ctx, q, competitionID).Scan(&eventID, &status, &timezone, &timeFrom, &timeTo)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrCompetitionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to query competition: %w", err)
+	}
+	if status != "DRAFT" {
+		return ErrCompetitionNotDraft
+	}
+	if changes.TimeFrom != nil || changes.TimeTo != nil {
+		loc, err := utils.LoadTimezone(timezone)
+		if err != nil {
+			return err
+		}
+		if changes.TimeFrom != nil {
+			timeFrom = utils.TimeRoundIn(*changes.TimeFrom, loc)
+		}
+		if changes.TimeTo != nil {
+			timeTo = utils.TimeRoundIn(*changes.TimeTo, loc)
+		}
+		if !timeFrom.Before(timeTo) {
+			return ErrInvalidTimeRange
+		}
+		err = checkOverlap(ctx, tx, eventID, &competitionID, timeFrom, timeTo)
+		if err != nil {
+			return err
+		}
+	}
+
+	q = `
+		UPDATE competitions
+		SET from_at = $2,
+			to_at = $3,
+			min_races = COALESCE($4, min_races),
+			min_secs = COALESCE($5, min_secs),
+			min_typed = COALESCE($6, min_typed),
+			updated_at = NOW()
+		WHERE id = $1`
+	_, err = tx.Exec(ctx, q, competitionID, timeFrom, timeTo, changes.MinRaces, changes.MinSecs, changes.MinTyped)
+	if err != nil {
+		return fmt.Errorf("unable to update competition: %w", err)
+	}
+	err = notify.Competitions(ctx, tx, competitionID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to finish updating competition: %w", err)
+	}
+	return nil
+}
+
+// CancelCompetition removes a competition that hasn't finished yet.
+func CancelCompetition(ctx context.Context, conn *pgxpool.Pool, competitionID string) error {
+	status := ""
+	q := `SELECT status FROM competitions WHERE id = $1 AND deleted_at IS NULL`
+	err := conn.QueryRow(ctx, q, competitionID).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrCompetitionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to query competition: %w", err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start cancelling competition: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	competitionIDs, err := cancelCompetitions(ctx, tx, nil, &competitionID)
+	if err != nil {
+		return err
+	}
+	if len(competitionIDs) == 0 {
+		return ErrCompetitionClosed
+	}
+	err = notify.Competitions(ctx, tx, competitionIDs...)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to finish cancelling competition: %w", err)
+	}
+	return nil
+}
+
+// cancelCompetitions fails and removes the draft and started competitions of an event (or a single competition).
+// Returns the ids of the cancelled competitions.
+func cancelCompetitions(ctx context.Context, tx pgx.Tx, eventID *string, competitionID *string) ([]string, error) {
+	q := `
+		UPDATE competitions
+		SET status = 'FAILED', deleted_at = NOW(), updated_at = NOW()
+		WHERE deleted_at IS NULL
+			AND status IN ('DRAFT', 'STARTED')
+			AND (event_id = $1::uuid OR id = $2::uuid)
+		RETURNING id::text`
+	rows, err := tx.Query(ctx, q, eventID, competitionID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to cancel competitions: %w", err)
+	}
+	defer rows.Close()
+	output := []string{}
+	for rows.Next() {
+		id := ""
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan cancelled competitions: %w", err)
+		}
+		output = append(output, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to cancel competitions: %w", err)
+	}
+	if len(output) == 0 {
+		return output, nil
+	}
+	q = `DELETE FROM competition_provisional_results WHERE competition_id = ANY($1)`
+	_, err = tx.Exec(ctx, q, output)
+	if err != nil {
+		return nil, fmt.Errorf("unable to clear provisional results: %w", err)
+	}
+	return output, nil
+}
+
+// checkOverlap makes sure the time range doesn't overlap the other competitions of the event.
+func checkOverlap(ctx context.Context, tx pgx.Tx, eventID string, competitionID *string, timeFrom time.Time, timeTo time.Time) error {
+	exists := false
+	q := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM competitions
+			WHERE event_id = $1
+				AND deleted_at IS NULL
+				AND ($2::uuid IS NULL OR id != $2)
+				AND from_at < $4
+				AND to_at > $3
+		)`
+	err := tx.QueryRow(ctx, q, eventID, competitionID, timeFrom, timeTo).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("unable to check overlapping competitions: %w", err)
+	}
+	if exists {
+		return ErrCompetitionOverlap
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package manage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrEventNotFound = fmt.Errorf("event not found")
+)
+
+// UpdateEvent changes the name and rules of an event (nil values are left as is).
+func UpdateEvent(ctx context.Context, conn *pgxpool.Pool, eventID string, name *string, rules *string) error {
+	if name != nil && strings.TrimSpace(*name) == "" {
+		return fmt.Errorf("event name must not be blank")
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start updating event: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+		UPDATE events
+		SET name = COALESCE(btrim($2), name),
+			rules = COALESCE($3, rules),
+			updated_at = NOW()
+		WHERE id = $1
+			AND deleted_at IS NULL
+		RETURNING id`
+	err = tx.QueryRow(ctx, q, eventID, name, rules).Scan(&eventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrEventNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update event: %w", err)
+	}
+	err = notify.BumpVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to finish updating event: %w", err)
+	}
+	return nil
+}
+
+// CancelEvent removes an event along with its competitions that haven't finished yet.
+// The finished competitions are left alone so the racers keep the rewards they've already won.
+// Returns the ids of the cancelled competitions.
+func CancelEvent(ctx context.Context, conn *pgxpool.Pool, eventID string) ([]string, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start cancelling event: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+		UPDATE events
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+			AND deleted_at IS NULL
+		RETURNING id`
+	err = tx.QueryRow(ctx, q, eventID).Scan(&eventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to cancel event: %w", err)
+	}
+	competitionIDs, err := cancelCompetitions(ctx, tx, &eventID, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = notify.Competitions(ctx, tx, competitionIDs...)
+	if err != nil {
+		return nil, err
+	}
+	err = notify.BumpVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to finish cancelling event: %w", err)
+	}
+	return competitionIDs, nil
+}
@@ -0,0 +1,61 @@
+package manage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"nt-folly-xmaxx-comp/internal/pkg/results"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrCompetitionNotFound = fmt.Errorf("competition not found")
+)
+
+// SetMultiplier changes the multiplier of a competition and recomputes the results it affects.
+// The multiplier is only saved along with the recomputed results.
+func SetMultiplier(ctx context.Context, conn *pgxpool.Pool, competitionID string, multiplier int) error {
+	if multiplier < 1 {
+		return fmt.Errorf("multiplier must be at least 1")
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start updating multiplier: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+		UPDATE competitions
+		SET multiplier = $2, updated_at = NOW()
+		WHERE id = $1
+			AND deleted_at IS NULL
+		RETURNING status`
+	status := ""
+	err = tx.QueryRow(ctx, q, competitionID, multiplier).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrCompetitionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update multiplier: %w", err)
+	}
+	if status != "FINISHED" {
+		err = notify.BumpVersion(ctx, tx)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = results.Recompute(ctx, tx, competitionID)
+		if err != nil {
+			return fmt.Errorf("unable to recompute results: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to finish updating multiplier: %w", err)
+	}
+	return nil
+}
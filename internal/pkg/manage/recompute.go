@@ -0,0 +1,96 @@
+package manage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/records"
+	"nt-folly-xmaxx-comp/internal/pkg/results"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrCompetitionNotFinished = fmt.Errorf("competition has not finished")
+)
+
+// RecomputeCompetition rebuilds the user records of a finished competition from its team logs and recomputes the results.
+// Competitions finishing afterwards are recomputed too, as their speed improvement depends on the earlier records.
+// Returns the ids of the recomputed competitions.
+func RecomputeCompetition(ctx context.Context, conn *pgxpool.Pool, competitionID string) ([]string, error) {
+	var (
+		status    string
+		requestID *string
+		toAt      time.Time
+	)
+	q := `
+		SELECT status, request_id::text, to_at
+		FROM competitions
+		WHERE id = $1
+			AND deleted_at IS NULL`
+	err := conn.QueryRow(ctx, q, competitionID).Scan(&status, &requestID, &toAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCompetitionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to query competition: %w", err)
+	}
+	if status != "FINISHED" || requestID == nil {
+		return nil, ErrCompetitionNotFinished
+	}
+
+	// Rebuild the records (shared by every competition closed by the request)
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start rebuilding records: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q = `DELETE FROM user_records WHERE request_id = $1`
+	_, err = tx.Exec(ctx, q, *requestID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to clear user records: %w", err)
+	}
+	_, err = records.Insert(ctx, tx, *requestID)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to finish rebuilding records: %w", err)
+	}
+
+	// Recompute the affected results
+	q = `
+		SELECT id::text
+		FROM competitions
+		WHERE status = 'FINISHED'
+			AND deleted_at IS NULL
+			AND to_at >= $1
+		ORDER BY to_at`
+	rows, err := conn.Query(ctx, q, toAt)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query affected competitions: %w", err)
+	}
+	defer rows.Close()
+	competitionIDs := []string{}
+	for rows.Next() {
+		id := ""
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan affected competitions: %w", err)
+		}
+		competitionIDs = append(competitionIDs, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan affected competitions: %w", err)
+	}
+	err = results.Recompute(ctx, conn, competitionIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to recompute results: %w", err)
+	}
+	return competitionIDs, nil
+}
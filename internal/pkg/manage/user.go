@@ -0,0 +1,75 @@
+package manage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"nt-folly-xmaxx-comp/internal/pkg/results"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrUserNotFound = fmt.Errorf("user not found")
+)
+
+// GetUserID finds the id of a racer by their Nitro Type username.
+func GetUserID(ctx context.Context, conn *pgxpool.Pool, username string) (string, error) {
+	userID := ""
+	q := `
+		SELECT id::text
+		FROM users
+		WHERE lower(username) = lower($1)
+			AND deleted_at IS NULL`
+	err := conn.QueryRow(ctx, q, username).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to query user: %w", err)
+	}
+	return userID, nil
+}
+
+// SetUserStatus disqualifies (DISQUALIFIED) or reinstates (ACTIVE) a racer and recomputes the finished competitions they raced in.
+// Returns the ids of the recomputed competitions.
+func SetUserStatus(ctx context.Context, conn *pgxpool.Pool, userID string, status string) ([]string, error) {
+	if status != "DISQUALIFIED" && status != "ACTIVE" {
+		return nil, fmt.Errorf("user status must be DISQUALIFIED or ACTIVE")
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start updating user status: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+		UPDATE users
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1
+			AND deleted_at IS NULL
+		RETURNING id`
+	err = tx.QueryRow(ctx, q, userID, status).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to update user status: %w", err)
+	}
+	err = notify.BumpVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	competitionIDs, err := results.RecomputeUser(ctx, tx, userID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to recompute results: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to finish updating user status: %w", err)
+	}
+	return competitionIDs, nil
+}
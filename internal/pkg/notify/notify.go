@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+)
+
+const (
+	// ChannelCompetitionUpdated is notified when a competition changes status or its results change.
+	ChannelCompetitionUpdated = "competition_updated"
+	// ChannelSyncStatus is notified after each team sync.
+	ChannelSyncStatus = "sync_status"
+	// ChannelSyncVersion is notified with the new sync version whenever it's bumped.
+	ChannelSyncVersion = "sync_version"
+)
+
+// Channels lists every channel that gets notified.
+var Channels = []string{
+	ChannelCompetitionUpdated,
+	ChannelSyncStatus,
+	ChannelSyncVersion,
+}
+
+// DB is a database connection (pool or transaction) the notifications can be sent with.
+// Notifications sent within a transaction are only delivered once it commits.
+type DB interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+// CompetitionUpdate is the payload of the competition updated notifications.
+type CompetitionUpdate struct {
+	CompetitionID string `json:"competitionId"`
+	EventID       string `json:"eventId"`
+	Status        string `json:"status"`
+}
+
+// SyncStatus is the payload of the sync status notifications.
+type SyncStatus struct {
+	TeamTag  string    `json:"teamTag"`
+	EventIDs []string  `json:"eventIds"`
+	Status   string    `json:"status"`
+	SyncedAt time.Time `json:"syncedAt"`
+}
+
+// Competitions notifies the current status of the given competitions (and bumps the sync version).
+func Competitions(ctx context.Context, conn DB, competitionIDs ...string) error {
+	if len(competitionIDs) == 0 {
+		return nil
+	}
+	q := `
+		SELECT pg_notify($1, json_build_object('competitionId', c.id, 'eventId', c.event_id, 'status', c.status)::text)
+		FROM competitions c
+		WHERE c.id = ANY($2)`
+	_, err := conn.Exec(ctx, q, ChannelCompetitionUpdated, competitionIDs)
+	if err != nil {
+		return fmt.Errorf("unable to notify competition updates: %w", err)
+	}
+	return BumpVersion(ctx, conn)
+}
+
+// Sync notifies the outcome of a team sync (and bumps the sync version).
+func Sync(ctx context.Context, conn DB, status SyncStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("unable to encode sync status: %w", err)
+	}
+	_, err = conn.Exec(ctx, `SELECT pg_notify($1, $2)`, ChannelSyncStatus, string(data))
+	if err != nil {
+		return fmt.Errorf("unable to notify sync status: %w", err)
+	}
+	return BumpVersion(ctx, conn)
+}
+
+// BumpVersion bumps the sync version the API derives its ETags from, and notifies the new version.
+// Within a transaction, the new version is only seen (and notified) once it commits, so it goes along with the changes.
+func BumpVersion(ctx context.Context, conn DB) error {
+	q := `
+		WITH v AS (
+			UPDATE sync_versions
+			SET version = version + 1
+			RETURNING version
+		)
+		SELECT pg_notify($1, v.version::text)
+		FROM v`
+	_, err := conn.Exec(ctx, q, ChannelSyncVersion)
+	if err != nil {
+		return fmt.Errorf("unable to bump sync version: %w", err)
+	}
+	return nil
+}
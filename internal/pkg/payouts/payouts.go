@@ -0,0 +1,308 @@
+package payouts
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrEventNotFound     = fmt.Errorf("event not found")
+	ErrUserNotFound      = fmt.Errorf("user not found")
+	ErrPayoutNotFound    = fmt.Errorf("payout not found")
+	ErrInvalidTransition = fmt.Errorf("payout status can only move forward (PENDING, SENT then CONFIRMED)")
+	ErrPayoutNotPending  = fmt.Errorf("only pending payouts can be cancelled")
+)
+
+// Statuses are the payout states in the order they move through.
+var Statuses = []string{"PENDING", "SENT", "CONFIRMED"}
+
+// Payout is in-game cash or gifts handed out to a racer for an event.
+type Payout struct {
+	ID          string
+	EventID     string
+	UserID      string
+	Amount      int
+	Status      string
+	Note        string
+	SentAt      *time.Time
+	ConfirmedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Balance is what a racer has earned in an event against what has been paid out.
+type Balance struct {
+	EventID     string
+	UserID      string
+	Username    string
+	DisplayName string
+	Points      int
+	Earned      int
+	Pending     int
+	Sent        int
+	Confirmed   int
+	Outstanding int
+}
+
+// statusIndex returns the position of the status in the payout flow (-1 if unknown).
+func statusIndex(status string) int {
+	for i, s := range Statuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetRate changes the in-game cash paid for each reward point of an event.
+func SetRate(ctx context.Context, conn *pgxpool.Pool, eventID string, rate float64) error {
+	if rate < 0 {
+		return fmt.Errorf("payout rate must not be negative")
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start updating payout rate: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+		UPDATE events
+		SET payout_rate = $2, updated_at = NOW()
+		WHERE id = $1
+			AND deleted_at IS NULL
+		RETURNING id`
+	err = tx.QueryRow(ctx, q, eventID, rate).Scan(&eventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrEventNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update payout rate: %w", err)
+	}
+	err = notify.BumpVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to finish updating payout rate: %w", err)
+	}
+	return nil
+}
+
+// Create records a pending payout.
+func Create(ctx context.Context, conn *pgxpool.Pool, eventID string, userID string, amount int, note string) (*Payout, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("payout amount must be positive")
+	}
+	exists := false
+	q := `SELECT EXISTS (SELECT 1 FROM events WHERE id = $1 AND deleted_at IS NULL)`
+	err := conn.QueryRow(ctx, q, eventID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query event: %w", err)
+	}
+	if !exists {
+		return nil, ErrEventNotFound
+	}
+	q = `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`
+	err = conn.QueryRow(ctx, q, userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query user: %w", err)
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start creating payout: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	output := &Payout{}
+	q = `
+		INSERT INTO payouts (event_id, user_id, amount, note)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id::text, event_id::text, user_id::text, amount, status, note, sent_at, confirmed_at, created_at, updated_at`
+	err = tx.QueryRow(ctx, q, eventID, userID, amount, note).Scan(
+		&output.ID, &output.EventID, &output.UserID, &output.Amount, &output.Status, &output.Note,
+		&output.SentAt, &output.ConfirmedAt, &output.CreatedAt, &output.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to insert payout: %w", err)
+	}
+	err = notify.BumpVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to finish creating payout: %w", err)
+	}
+	return output, nil
+}
+
+// SetStatus moves a payout to the next status. The note is replaced if given.
+func SetStatus(ctx context.Context, conn *pgxpool.Pool, id string, status string, note *string) (*Payout, error) {
+	newIndex := statusIndex(status)
+	if newIndex == -1 {
+		return nil, fmt.Errorf("payout status is invalid")
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start updating payout: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	currentStatus := ""
+	q := `SELECT status FROM payouts WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	err = tx.QueryRow(ctx, q, id).Scan(&currentStatus)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPayoutNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to query payout: %w", err)
+	}
+	if newIndex <= statusIndex(currentStatus) {
+		return nil, ErrInvalidTransition
+	}
+
+	output := &Payout{}
+	q = `
+		UPDATE payouts
+		SET status = $2,
+			note = coalesce($3, note),
+			sent_at = coalesce(sent_at, NOW()),
+			confirmed_at = (CASE WHEN $2 = 'CONFIRMED' THEN NOW() END),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id::text, event_id::text, user_id::text, amount, status, note, sent_at, confirmed_at, created_at, updated_at`
+	err = tx.QueryRow(ctx, q, id, status, note).Scan(
+		&output.ID, &output.EventID, &output.UserID, &output.Amount, &output.Status, &output.Note,
+		&output.SentAt, &output.ConfirmedAt, &output.CreatedAt, &output.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to update payout: %w", err)
+	}
+	err = notify.BumpVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to finish updating payout: %w", err)
+	}
+	return output, nil
+}
+
+// Cancel removes a payout that hasn't been sent yet.
+func Cancel(ctx context.Context, conn *pgxpool.Pool, id string) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start cancelling payout: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+		UPDATE payouts
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+			AND status = 'PENDING'
+			AND deleted_at IS NULL`
+	tag, err := tx.Exec(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("unable to cancel payout: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		err = notify.BumpVersion(ctx, tx)
+		if err != nil {
+			return err
+		}
+		err = tx.Commit(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to finish cancelling payout: %w", err)
+		}
+		return nil
+	}
+	exists := false
+	q = `SELECT EXISTS (SELECT 1 FROM payouts WHERE id = $1 AND deleted_at IS NULL)`
+	err = tx.QueryRow(ctx, q, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("unable to query payout: %w", err)
+	}
+	if !exists {
+		return ErrPayoutNotFound
+	}
+	return ErrPayoutNotPending
+}
+
+// Balances fetches the payout balances of an event, largest outstanding balance first.
+func Balances(ctx context.Context, conn *pgxpool.Pool, eventID string) ([]*Balance, error) {
+	q := `
+		SELECT b.event_id::text, b.user_id::text, u.username, u.display_name, b.points, b.earned, b.pending, b.sent, b.confirmed, b.outstanding
+		FROM event_payout_balances b
+			INNER JOIN users u ON u.id = b.user_id
+		WHERE b.event_id = $1
+		ORDER BY b.outstanding DESC, u.username`
+	rows, err := conn.Query(ctx, q, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query payout balances: %w", err)
+	}
+	defer rows.Close()
+	output := []*Balance{}
+	for rows.Next() {
+		row := &Balance{}
+		err := rows.Scan(
+			&row.EventID, &row.UserID, &row.Username, &row.DisplayName,
+			&row.Points, &row.Earned, &row.Pending, &row.Sent, &row.Confirmed, &row.Outstanding,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan payout balances: %w", err)
+		}
+		if row.DisplayName == "" {
+			row.DisplayName = row.Username
+		}
+		output = append(output, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan payout balances: %w", err)
+	}
+	return output, nil
+}
+
+// WriteBalancesCSV writes the payout balances as CSV (with a header row).
+func WriteBalancesCSV(w io.Writer, balances []*Balance) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"username", "display_name", "points", "earned", "pending", "sent", "confirmed", "outstanding"})
+	if err != nil {
+		return fmt.Errorf("unable to write csv header: %w", err)
+	}
+	for _, b := range balances {
+		err := cw.Write([]string{
+			b.Username,
+			b.DisplayName,
+			strconv.Itoa(b.Points),
+			strconv.Itoa(b.Earned),
+			strconv.Itoa(b.Pending),
+			strconv.Itoa(b.Sent),
+			strconv.Itoa(b.Confirmed),
+			strconv.Itoa(b.Outstanding),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to write csv row: %w", err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
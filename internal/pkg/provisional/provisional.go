@@ -0,0 +1,229 @@
+package provisional
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"nt-folly-xmaxx-comp/internal/pkg/records"
+	"nt-folly-xmaxx-comp/internal/pkg/utils"
+	"nt-folly-xmaxx-comp/pkg/nitrotype"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// RefreshInterval is how often the provisional results of a competition can be refreshed.
+const RefreshInterval = 1 * time.Minute
+
+var (
+	ErrCompetitionNotFound   = fmt.Errorf("competition not found")
+	ErrCompetitionNotStarted = fmt.Errorf("competition has not started")
+)
+
+// Refresh collects the latest team log and recomputes the provisional results of a started competition.
+// The results are worked out with the same views as the final results, using a team log request that is rolled back
+// once computed (so the collector keeps comparing against the window's start log).
+// Returns false when the results were refreshed less than RefreshInterval ago.
+func Refresh(ctx context.Context, conn *pgxpool.Pool, apiClient nitrotype.APIClient, competitionID string) (bool, error) {
+	// Claim the refresh (the previous refresh time is put back if it fails)
+	teamTag, teamID := "", 0
+	var prevProvisionalAt *time.Time
+	q := `
+		UPDATE competitions c
+		SET provisional_at = NOW()
+		FROM events e, competitions prev
+		WHERE e.id = c.event_id
+			AND prev.id = c.id
+			AND c.id = $1
+			AND c.status = 'STARTED'
+			AND (c.provisional_at IS NULL OR c.provisional_at <= NOW() - make_interval(secs => $2))
+		RETURNING e.team_tag, e.team_id, prev.provisional_at`
+	err := conn.QueryRow(ctx, q, competitionID, RefreshInterval.Seconds()).Scan(&teamTag, &teamID, &prevProvisionalAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		status := ""
+		q = `SELECT status FROM competitions WHERE id = $1 AND deleted_at IS NULL`
+		err = conn.QueryRow(ctx, q, competitionID).Scan(&status)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrCompetitionNotFound
+		}
+		if err != nil {
+			return false, fmt.Errorf("unable to query competition: %w", err)
+		}
+		if status != "STARTED" {
+			return false, ErrCompetitionNotStarted
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to claim provisional refresh: %w", err)
+	}
+
+	err = refresh(ctx, conn, apiClient, competitionID, teamTag, teamID)
+	if err != nil {
+		q = `UPDATE competitions SET provisional_at = $2 WHERE id = $1`
+		_, releaseErr := conn.Exec(context.Background(), q, competitionID, prevProvisionalAt)
+		if releaseErr != nil {
+			return false, fmt.Errorf("unable to release provisional refresh: %v: %w", releaseErr, err)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// refresh stores the provisional results worked out from the latest team log, along with the time they were refreshed.
+func refresh(ctx context.Context, conn *pgxpool.Pool, apiClient nitrotype.APIClient, competitionID string, teamTag string, teamID int) error {
+	// Grab Latest Stats
+	teamData, err := apiClient.GetTeam(teamTag)
+	if err != nil {
+		return fmt.Errorf("unable to pull team log: %w", err)
+	}
+	if !teamData.Success || teamData.Data.Info == nil {
+		return fmt.Errorf("unable to pull team log: team api request failed")
+	}
+	if teamData.Data.Info.TeamID != teamID {
+		return fmt.Errorf("team has changed")
+	}
+	data, err := json.Marshal(teamData)
+	if err != nil {
+		return fmt.Errorf("unable to marshal team data: %w", err)
+	}
+	hash, err := utils.HashData(data)
+	if err != nil {
+		return fmt.Errorf("unable to calculate team data hash: %w", err)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start refreshing provisional results: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Store the team log
+	logID := ""
+	q := `SELECT id FROM nt_api_team_logs WHERE hash = $1`
+	err = tx.QueryRow(ctx, q, hash).Scan(&logID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		q = `
+			INSERT INTO nt_api_team_logs (hash, log_data)
+			VALUES ($1, $2)
+			RETURNING id`
+		err = tx.QueryRow(ctx, q, hash, data).Scan(&logID)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to store team log: %w", err)
+	}
+
+	rows, err := computeResults(ctx, tx, competitionID, teamTag, logID)
+	if err != nil {
+		return err
+	}
+
+	// Replace the provisional results
+	q = `DELETE FROM competition_provisional_results WHERE competition_id = $1`
+	_, err = tx.Exec(ctx, q, competitionID)
+	if err != nil {
+		return fmt.Errorf("unable to clear provisional results: %w", err)
+	}
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"competition_provisional_results"},
+		[]string{"competition_id", "user_id", "category_id", "score", "eligible", "ineligible_reason", "rank", "tied", "reward"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to insert provisional results: %w", err)
+	}
+	q = `UPDATE competitions SET provisional_log_id = $2, provisional_at = NOW(), updated_at = NOW() WHERE id = $1`
+	_, err = tx.Exec(ctx, q, competitionID, logID)
+	if err != nil {
+		return fmt.Errorf("unable to update competition: %w", err)
+	}
+	err = notify.Competitions(ctx, tx, competitionID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to finish refreshing provisional results: %w", err)
+	}
+	return nil
+}
+
+// computeResults works out the competition results against a team log within a savepoint that is rolled back.
+func computeResults(ctx context.Context, conn pgx.Tx, competitionID string, teamTag string, logID string) ([][]interface{}, error) {
+	sp, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start computing provisional results: %w", err)
+	}
+	defer sp.Rollback(ctx)
+
+	// Record the stats since the last team log request (the start of the window)
+	requestID := ""
+	q := `
+		INSERT INTO nt_api_team_log_requests (prev_id, api_team_log_id, team_tag, response_type, description)
+		SELECT (
+				SELECT _r.id
+				FROM nt_api_team_log_requests _r
+				WHERE _r.deleted_at IS NULL
+					AND _r.team_tag = $1
+				ORDER BY _r.created_at DESC
+				LIMIT 1
+			),
+			$2,
+			$1,
+			'NEW',
+			'Provisional log download'
+		RETURNING id`
+	err = sp.QueryRow(ctx, q, teamTag, logID).Scan(&requestID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to insert provisional request: %w", err)
+	}
+	_, err = records.Insert(ctx, sp, requestID)
+	if err != nil {
+		return nil, err
+	}
+	q = `UPDATE competitions SET request_id = $2 WHERE id = $1`
+	_, err = sp.Exec(ctx, q, competitionID, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to attach provisional request: %w", err)
+	}
+
+	// Rank them
+	q = `
+		SELECT r.user_id, r.category_id, r.score, r.eligible, r.ineligible_reason, r.rank, r.tied, r.reward
+		FROM competition_ranked_results r
+		WHERE r.competition_id = $1`
+	rows, err := sp.Query(ctx, q, competitionID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query provisional results: %w", err)
+	}
+	defer rows.Close()
+	output := [][]interface{}{}
+	for rows.Next() {
+		var (
+			userID           pgtype.UUID
+			categoryID       pgtype.UUID
+			score            pgtype.Numeric
+			eligible         bool
+			ineligibleReason pgtype.Text
+			rank             pgtype.Int4
+			tied             int32
+			reward           int32
+		)
+		err := rows.Scan(&userID, &categoryID, &score, &eligible, &ineligibleReason, &rank, &tied, &reward)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan provisional results: %w", err)
+		}
+		output = append(output, []interface{}{competitionID, userID, categoryID, score, eligible, ineligibleReason, rank, tied, reward})
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan provisional results: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,49 @@
+package records
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+)
+
+// DB is a database connection (pool or transaction) the records can be written with.
+type DB interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+// Insert records each member's stats between a team log request and the one before it.
+// The members' counters of both logs are compared, only members that have raced are recorded.
+// Returns how many records were inserted.
+func Insert(ctx context.Context, conn DB, requestID string) (int64, error) {
+	q := `
+		INSERT INTO user_records (request_id, user_id, played, typed, errs, secs, from_at, to_at)
+		SELECT $1 AS request_id,
+			(
+				SELECT _u.id
+				FROM users _u
+				WHERE _u.reference_id = (m1->>'userID')::int
+				LIMIT 1
+			) AS user_id,
+			((m1->>'played')::int - (m2->>'played')::int) AS played,
+			((m1->>'typed')::int - (m2->>'typed')::int) AS typed,
+			((m1->>'errs')::int - (m2->>'errs')::int) AS errs,
+			((m1->>'secs')::int - (m2->>'secs')::int) AS secs,
+			r2.created_at AS from_at,
+			r1.created_at AS to_at
+		FROM nt_api_team_log_requests r1				
+			INNER JOIN nt_api_team_log_requests r2 ON r2.id = r1.prev_id
+				AND r2.api_team_log_id != r1.api_team_log_id
+			INNER JOIN nt_api_team_logs l1 ON l1.id = r1.api_team_log_id AND json_typeof(l1.log_data->'data'->'members') = 'array'
+			INNER JOIN nt_api_team_logs l2 ON l2.id = r2.api_team_log_id AND json_typeof(l2.log_data->'data'->'members') = 'array'
+			INNER JOIN json_array_elements(l1.log_data->'data'->'members') AS m1 ON m1->>'userID' IS NOT NULL AND (m1->>'userID')::int NOT IN (31927399)
+			INNER JOIN json_array_elements(l2.log_data->'data'->'members') AS m2 ON (m1->>'userID')::int = (m2->>'userID')::int
+		WHERE r1.id = $1
+			AND r1.prev_id IS NOT NULL
+			AND ((m1->>'played')::int - (m2->>'played')::int) > 0`
+	tag, err := conn.Exec(ctx, q, requestID)
+	if err != nil {
+		return 0, fmt.Errorf("unable to insert team member records: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,182 @@
+package reports
+
+import (
+	"fmt"
+	"time"
+)
+
+// UnrankedRank is the rank given to the racers without a category rank (ineligible racers), so they're ranked last.
+const UnrankedRank = 2147483647
+
+// TimeRange only keeps the competitions (or records) within the times.
+type TimeRange struct {
+	TimeFrom time.Time
+	TimeTo   time.Time
+}
+
+// LeaderboardFilter narrows down the competition leaderboards, the same way as the GQL leaderboard arguments.
+type LeaderboardFilter struct {
+	// Category ranks the racers by a category instead of their total points.
+	Category *string
+	// Limit only keeps the top racers (all of them when 0).
+	Limit             int
+	IncludeIneligible bool
+	// UserIDs only keeps these racers (everyone when nil).
+	UserIDs []string
+}
+
+// LeaderboardQuery builds the query of the racers of competition leaderboards, one row per competition and racer:
+// competition_id, user_id, points, rank, total_points and position.
+// Without a category, racers are ordered by their points (including adjustments), otherwise by their category rank
+// and the rows also have the category's score, tied, eligible and ineligible_reason.
+// The position is the place of the racer in that order, the limit only keeps the top racers.
+// Started competitions use their provisional results.
+func LeaderboardQuery(competitionIDs []string, filter LeaderboardFilter) (string, []interface{}) {
+	args := []interface{}{competitionIDs, !filter.IncludeIneligible, filter.UserIDs != nil, filter.UserIDs}
+	q := ""
+	order := ""
+	if filter.Category == nil {
+		q = `
+			SELECT up.competition_id, up.user_id, SUM(up.points)::int AS points, 0 AS rank, SUM(up.points)::int AS total_points
+			FROM leaderboard_points up
+			WHERE up.competition_id = ANY($1)
+				AND (
+					NOT $2
+					OR EXISTS (
+						SELECT 1
+						FROM leaderboard_results _r
+						WHERE _r.competition_id = up.competition_id
+							AND _r.user_id = up.user_id
+							AND _r.eligible
+					)
+				)
+				AND (NOT $3 OR up.user_id = ANY($4::uuid[]))
+			GROUP BY up.competition_id, up.user_id`
+		order = "l.points DESC, l.user_id DESC"
+	} else {
+		args = append(args, *filter.Category)
+		q = `
+			SELECT r.competition_id,
+				r.user_id,
+				r.reward AS points,
+				coalesce(r.rank, ` + fmt.Sprint(UnrankedRank) + `) AS rank,
+				(
+					SELECT SUM(_p.points)
+					FROM leaderboard_points _p
+					WHERE _p.competition_id = r.competition_id
+						AND _p.user_id = r.user_id
+				)::int AS total_points,
+				r.score::float AS score,
+				r.tied,
+				r.eligible,
+				r.ineligible_reason
+			FROM leaderboard_results r
+				INNER JOIN categories cat ON cat.id = r.category_id
+			WHERE r.competition_id = ANY($1)
+				AND (NOT $2 OR r.eligible)
+				AND (NOT $3 OR r.user_id = ANY($4::uuid[]))
+				AND lower(cat.name) = lower($5)`
+		order = "l.rank ASC, l.user_id ASC"
+	}
+	q = `
+		SELECT l.*,
+			(row_number() OVER (PARTITION BY l.competition_id ORDER BY ` + order + `))::int AS position
+		FROM (` + q + `) l`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		q = `
+			SELECT l.*
+			FROM (` + q + `) l
+			WHERE l.position <= $` + fmt.Sprint(len(args))
+	}
+	return q, args
+}
+
+// StandingsFilter narrows down the event standings, the same way as the GQL standings arguments.
+type StandingsFilter struct {
+	TimeRange *TimeRange
+	// Category only counts the rewards of a category (adjustments are left out).
+	Category *string
+	// Dense ranks ties without skipping the following ranks.
+	Dense bool
+}
+
+// standingsConditions filters the competition results (aliased r, with their competition c and category cat) counted by the standings.
+func standingsConditions(eventID string, filter StandingsFilter) (string, string, []interface{}) {
+	args := []interface{}{eventID}
+	conditions := "c.event_id = $1"
+	if filter.TimeRange != nil {
+		args = append(args, filter.TimeRange.TimeFrom, filter.TimeRange.TimeTo)
+		conditions += fmt.Sprintf(" AND c.from_at >= $%d AND c.to_at <= $%d", len(args)-1, len(args))
+	}
+	categoryConditions := ""
+	if filter.Category != nil {
+		args = append(args, *filter.Category)
+		categoryConditions = fmt.Sprintf(" AND lower(cat.name) = lower($%d)", len(args))
+	}
+	return conditions, categoryConditions, args
+}
+
+// StandingsQuery builds the query ranking the racers of an event by their points, one row per racer:
+// user_id, rank, tied, total_points, rewards, competitions_won and gap_to_next_rank.
+// Ties share the same rank, the rows are ordered by total_points (most first).
+func StandingsQuery(eventID string, filter StandingsFilter) (string, []interface{}) {
+	conditions, categoryConditions, args := standingsConditions(eventID, filter)
+	rankFunction := "rank"
+	if filter.Dense {
+		rankFunction = "dense_rank"
+	}
+	adjustmentPoints := ""
+	if filter.Category == nil {
+		adjustmentPoints = `
+			UNION ALL
+			SELECT a.user_id, a.competition_id, 0, a.points, false
+			FROM adjustments a
+				INNER JOIN competitions c ON c.id = a.competition_id
+			WHERE a.deleted_at IS NULL AND ` + conditions
+	}
+	q := `
+		WITH points AS (
+			SELECT r.user_id, r.competition_id, r.reward, 0 AS adjustment, coalesce(r.rank = 1, false) AS won
+			FROM competition_results r
+				INNER JOIN competitions c ON c.id = r.competition_id
+				INNER JOIN categories cat ON cat.id = r.category_id
+			WHERE ` + conditions + categoryConditions + adjustmentPoints + `
+		), totals AS (
+			SELECT p.user_id,
+				SUM(p.reward + p.adjustment)::int AS total_points,
+				SUM(p.reward)::int AS rewards,
+				(COUNT(DISTINCT p.competition_id) FILTER (WHERE p.won))::int AS competitions_won
+			FROM points p
+			GROUP BY p.user_id
+		)
+		SELECT t.user_id,
+			(` + rankFunction + `() OVER (ORDER BY t.total_points DESC))::int AS rank,
+			(count(*) OVER (PARTITION BY t.total_points))::int AS tied,
+			t.total_points,
+			t.rewards,
+			t.competitions_won,
+			(SELECT MIN(_t.total_points) FROM totals _t WHERE _t.total_points > t.total_points) - t.total_points AS gap_to_next_rank
+		FROM totals t
+		ORDER BY t.total_points DESC`
+	return q, args
+}
+
+// StandingCategoriesQuery builds the query of the category breakdowns of the event standings, one row per racer and category:
+// user_id, category, rewards, competitions and wins (ordered by category).
+func StandingCategoriesQuery(eventID string, filter StandingsFilter) (string, []interface{}) {
+	conditions, categoryConditions, args := standingsConditions(eventID, filter)
+	q := `
+		SELECT r.user_id,
+			cat.name AS category,
+			SUM(r.reward)::int AS rewards,
+			COUNT(DISTINCT r.competition_id)::int AS competitions,
+			(COUNT(*) FILTER (WHERE r.rank = 1))::int AS wins
+		FROM competition_results r
+			INNER JOIN competitions c ON c.id = r.competition_id
+			INNER JOIN categories cat ON cat.id = r.category_id
+		WHERE ` + conditions + categoryConditions + `
+		GROUP BY r.user_id, cat.name
+		ORDER BY MIN(cat.position), cat.name`
+	return q, args
+}
@@ -0,0 +1,104 @@
+package results
+
+import (
+	"context"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// DB is a database connection (pool or transaction) the results can be computed with.
+type DB interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// Recompute rebuilds the results of the given competitions.
+// The old results are replaced within a transaction, so readers keep seeing them until the new ones are ready.
+// The provisional results are dropped and the competitions are notified as updated once the new results are committed.
+func Recompute(ctx context.Context, conn DB, competitionIDs ...string) error {
+	if len(competitionIDs) == 0 {
+		return nil
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to start recomputing results: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `DELETE FROM competition_results WHERE competition_id = ANY($1)`
+	_, err = tx.Exec(ctx, q, competitionIDs)
+	if err != nil {
+		return fmt.Errorf("unable to clear competition results: %w", err)
+	}
+	q = `
+		INSERT INTO competition_results (competition_id, user_id, category_id, score, eligible, ineligible_reason, rank, tied, reward)
+		SELECT r.competition_id, r.user_id, r.category_id, r.score, r.eligible, r.ineligible_reason, r.rank, r.tied, r.reward
+		FROM competition_ranked_results r
+		WHERE r.competition_id = ANY($1)`
+	_, err = tx.Exec(ctx, q, competitionIDs)
+	if err != nil {
+		return fmt.Errorf("unable to compute competition results: %w", err)
+	}
+	q = `DELETE FROM competition_provisional_results WHERE competition_id = ANY($1)`
+	_, err = tx.Exec(ctx, q, competitionIDs)
+	if err != nil {
+		return fmt.Errorf("unable to clear provisional results: %w", err)
+	}
+	err = notify.Competitions(ctx, tx, competitionIDs...)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to finish recomputing results: %w", err)
+	}
+	return nil
+}
+
+// RecomputeUser rebuilds the results of the finished competitions the user has raced in, optionally only those of an event.
+// Used when something only affecting one racer changes (e.g. they're disqualified or excluded).
+// Returns the ids of the recomputed competitions.
+func RecomputeUser(ctx context.Context, conn DB, userID string, eventID *string) ([]string, error) {
+	q := `
+		SELECT c.id::text
+		FROM competitions c
+		WHERE c.status = 'FINISHED'
+			AND c.deleted_at IS NULL
+			AND ($2::uuid IS NULL OR c.event_id = $2)
+			AND EXISTS (
+				SELECT 1
+				FROM competition_user_stats s
+				WHERE s.competition_id = c.id
+					AND s.user_id = $1
+			)
+		ORDER BY c.to_at`
+	rows, err := conn.Query(ctx, q, userID, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query user competitions: %w", err)
+	}
+	defer rows.Close()
+	competitionIDs := []string{}
+	for rows.Next() {
+		id := ""
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to scan user competitions: %w", err)
+		}
+		competitionIDs = append(competitionIDs, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan user competitions: %w", err)
+	}
+	rows.Close()
+	err = Recompute(ctx, conn, competitionIDs...)
+	if err != nil {
+		return nil, err
+	}
+	return competitionIDs, nil
+}
@@ -0,0 +1,236 @@
+package results_test
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	_ "nt-folly-xmaxx-comp/internal/app/migrate/migrations"
+	"nt-folly-xmaxx-comp/internal/pkg/results"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	_ "github.com/jackc/pgx/v4/stdlib"
+)
+
+// benchDBURLEnv is the connection string of the database the benchmarks run against.
+// It's migrated up and seeded by the benchmarks, so it must be a disposable database (see make bench-results).
+const benchDBURLEnv = "RESULTS_BENCH_DB_URL"
+
+const (
+	benchCompetitionCount = 1000
+	benchUserCount        = 50
+)
+
+// BenchmarkRefreshMaterializedView times the old approach: refreshing a materialized view of every competition.
+func BenchmarkRefreshMaterializedView(b *testing.B) {
+	ctx, tx, _ := setupBench(b)
+	q := `CREATE MATERIALIZED VIEW bench_competition_results AS SELECT * FROM competition_ranked_results WITH NO DATA`
+	_, err := tx.Exec(ctx, q)
+	if err != nil {
+		b.Fatalf("unable to create benchmark view: %s", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err = tx.Exec(ctx, `REFRESH MATERIALIZED VIEW bench_competition_results`)
+		if err != nil {
+			b.Fatalf("unable to refresh benchmark view: %s", err)
+		}
+	}
+}
+
+// BenchmarkRecomputeEvent times rebuilding the results of every competition of the event.
+func BenchmarkRecomputeEvent(b *testing.B) {
+	ctx, tx, competitionIDs := setupBench(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := results.Recompute(ctx, tx, competitionIDs...)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRecomputeOne times rebuilding the competition being closed (what each sync does).
+func BenchmarkRecomputeOne(b *testing.B) {
+	ctx, tx, competitionIDs := setupBench(b)
+	lastCompetitionID := competitionIDs[len(competitionIDs)-1]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := results.Recompute(ctx, tx, lastCompetitionID)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// setupBench migrates the benchmark database and seeds an event in a transaction rolled back once the benchmark is done.
+// Returns the ids of the event's competitions (ordered by time).
+func setupBench(b *testing.B) (context.Context, pgx.Tx, []string) {
+	b.Helper()
+	connString := os.Getenv(benchDBURLEnv)
+	if connString == "" {
+		b.Skipf("%s is not set", benchDBURLEnv)
+	}
+	ctx := context.Background()
+	err := migrateUp(connString)
+	if err != nil {
+		b.Fatalf("unable to migrate benchmark database: %s", err)
+	}
+	conn, err := pgxpool.Connect(ctx, connString)
+	if err != nil {
+		b.Fatalf("unable to connect to benchmark database: %s", err)
+	}
+	b.Cleanup(conn.Close)
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		b.Fatalf("unable to start benchmark: %s", err)
+	}
+	b.Cleanup(func() {
+		tx.Rollback(ctx)
+	})
+	competitionIDs, err := seedResultsData(ctx, tx, benchCompetitionCount, benchUserCount)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return ctx, tx, competitionIDs
+}
+
+func migrateUp(connString string) error {
+	conn, err := sql.Open("pgx", connString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	dbDriver, err := migratepgx.WithInstance(conn, &migratepgx.Config{})
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewWithDatabaseInstance("embed://", "pgx", dbDriver)
+	if err != nil {
+		return err
+	}
+	err = m.Up()
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// seedResultsData generates an event with finished competitions and random racer records.
+func seedResultsData(ctx context.Context, tx pgx.Tx, competitionCount int, userCount int) ([]string, error) {
+	hash := make([]byte, 32)
+	_, err := rand.Read(hash)
+	if err != nil {
+		return nil, err
+	}
+	timeFrom := time.Date(2000, time.January, 1, 0, 1, 0, 0, time.UTC)
+	timeTo := timeFrom.Add(time.Duration(competitionCount) * 10 * time.Minute)
+
+	eventID := ""
+	q := `
+		INSERT INTO events (name, team_tag, team_id, from_at, to_at)
+		VALUES ('Benchmark', 'BENCH', 0, $1, $2)
+		RETURNING id`
+	err = tx.QueryRow(ctx, q, timeFrom, timeTo).Scan(&eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	logID := ""
+	q = `
+		INSERT INTO nt_api_team_logs (hash, log_data)
+		VALUES ($1, '{}')
+		RETURNING id`
+	err = tx.QueryRow(ctx, q, hash).Scan(&logID)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		INSERT INTO event_categories (event_id, category_id, rewards)
+		SELECT $1, cat.id, cat.rewards
+		FROM categories cat
+		WHERE cat.deleted_at IS NULL`,
+		eventID,
+	)
+	batch.Queue(`
+		INSERT INTO users (reference_id, username, display_name, membership_type, status)
+		SELECT -g, 'bench_' || g, 'Bench ' || g, 'BASIC', 'ACTIVE'
+		FROM generate_series(1, $1) g`,
+		userCount,
+	)
+	batch.Queue(`
+		WITH r AS (
+			INSERT INTO nt_api_team_log_requests (api_team_log_id, team_tag, response_type, description, created_at)
+			SELECT $2, 'BENCH', 'NEW', 'Benchmark', $3::timestamptz + make_interval(mins => 10 * g)
+			FROM generate_series(1, $4) g
+			RETURNING id, created_at
+		)
+		INSERT INTO competitions (event_id, request_id, status, from_at, to_at)
+		SELECT $1, r.id, 'FINISHED', r.created_at - interval '10 minutes', r.created_at
+		FROM r`,
+		eventID, logID, timeFrom, competitionCount,
+	)
+	batch.Queue(`
+		INSERT INTO competition_categories (competition_id, category_id, rewards)
+		SELECT c.id, ec.category_id, ec.rewards
+		FROM competitions c
+			INNER JOIN event_categories ec ON ec.event_id = c.event_id
+		WHERE c.event_id = $1`,
+		eventID,
+	)
+	batch.Queue(`
+		INSERT INTO user_records (request_id, user_id, played, typed, errs, secs, from_at, to_at)
+		SELECT r.request_id, r.user_id, r.played, r.typed, (r.typed * random() * 0.05)::int, r.played * (20 + (random() * 30)::int), r.from_at, r.to_at
+		FROM (
+			SELECT c.request_id,
+				u.id AS user_id,
+				p.played,
+				p.played * (150 + (random() * 150)::int) AS typed,
+				c.from_at,
+				c.to_at
+			FROM competitions c
+				CROSS JOIN users u
+				CROSS JOIN LATERAL (SELECT 1 + (random() * 5)::int AS played) p
+			WHERE c.event_id = $1
+				AND u.reference_id < 0
+				AND random() < 0.6
+		) r`,
+		eventID,
+	)
+	batchRequest := tx.SendBatch(ctx, batch)
+	for i := 0; i < batch.Len(); i++ {
+		_, err = batchRequest.Exec()
+		if err != nil {
+			batchRequest.Close()
+			return nil, err
+		}
+	}
+	err = batchRequest.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `SELECT id::text FROM competitions WHERE event_id = $1 ORDER BY to_at`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	competitionIDs := []string{}
+	for rows.Next() {
+		id := ""
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		competitionIDs = append(competitionIDs, id)
+	}
+	return competitionIDs, rows.Err()
+}
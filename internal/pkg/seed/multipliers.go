@@ -0,0 +1,162 @@
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// DefaultMultiplierWeights is the original Xmaxx multiplier spread (roughly 6% 8x, 5% 4x and 10% 2x).
+var DefaultMultiplierWeights = []MultiplierWeight{
+	{Multiplier: 8, Weight: 6},
+	{Multiplier: 4, Weight: 5},
+	{Multiplier: 2, Weight: 10},
+	{Multiplier: 1, Weight: 79},
+}
+
+// MultiplierSchedule declares how the competition multipliers are assigned.
+// Rules are checked in order of explicit, happy hours and then the weighted random.
+type MultiplierSchedule struct {
+	Seed       int64                `json:"seed"`
+	Weights    []MultiplierWeight   `json:"weights"`
+	HappyHours []HappyHour          `json:"happyHours"`
+	Explicit   []ExplicitMultiplier `json:"explicit"`
+}
+
+// MultiplierWeight is the chance of a multiplier being picked randomly.
+type MultiplierWeight struct {
+	Multiplier int `json:"multiplier"`
+	Weight     int `json:"weight"`
+}
+
+// HappyHour is a fixed multiplier between clock times (HH:MM in the event timezone, the end is exclusive).
+type HappyHour struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Multiplier int    `json:"multiplier"`
+}
+
+// ExplicitMultiplier sets the multiplier of the competition starting at the given time.
+type ExplicitMultiplier struct {
+	At         time.Time `json:"at"`
+	Multiplier int       `json:"multiplier"`
+}
+
+// LoadMultiplierSchedule reads a multiplier schedule from a JSON file.
+func LoadMultiplierSchedule(path string) (*MultiplierSchedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read multiplier schedule: %w", err)
+	}
+	schedule := &MultiplierSchedule{}
+	err = json.Unmarshal(data, schedule)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse multiplier schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// Validate checks whether the schedule rules are usable.
+func (s *MultiplierSchedule) Validate() error {
+	totalWeight := 0
+	for _, w := range s.Weights {
+		if w.Multiplier < 1 {
+			return fmt.Errorf("weighted multiplier must be at least 1 (got %d)", w.Multiplier)
+		}
+		if w.Weight < 0 {
+			return fmt.Errorf("multiplier weight must not be negative (got %d)", w.Weight)
+		}
+		totalWeight += w.Weight
+	}
+	if len(s.Weights) > 0 && totalWeight == 0 {
+		return fmt.Errorf("multiplier weights must not all be zero")
+	}
+	for _, h := range s.HappyHours {
+		if h.Multiplier < 1 {
+			return fmt.Errorf("happy hour multiplier must be at least 1 (got %d)", h.Multiplier)
+		}
+		if _, err := parseClock(h.From); err != nil {
+			return fmt.Errorf("happy hour from is invalid: %w", err)
+		}
+		if _, err := parseClock(h.To); err != nil {
+			return fmt.Errorf("happy hour to is invalid: %w", err)
+		}
+	}
+	for _, e := range s.Explicit {
+		if e.Multiplier < 1 {
+			return fmt.Errorf("explicit multiplier must be at least 1 (got %d)", e.Multiplier)
+		}
+	}
+	return nil
+}
+
+// NewRand creates the random source used by the weighted multipliers.
+func (s *MultiplierSchedule) NewRand() *rand.Rand {
+	return rand.New(rand.NewSource(s.Seed))
+}
+
+// Multiplier returns the multiplier of the competition starting at fromAt.
+// Happy hours use the clock of fromAt's location, so it should be in the event timezone.
+// A random number is always drawn so the schedule stays the same for a seed, whichever rule is used.
+func (s *MultiplierSchedule) Multiplier(rnd *rand.Rand, fromAt time.Time) int {
+	multiplier := s.weightedMultiplier(rnd)
+
+	for _, h := range s.HappyHours {
+		if h.includes(fromAt) {
+			multiplier = h.Multiplier
+		}
+	}
+	for _, e := range s.Explicit {
+		if e.At.Equal(fromAt) {
+			multiplier = e.Multiplier
+		}
+	}
+	return multiplier
+}
+
+// weightedMultiplier picks a random multiplier using the weights.
+func (s *MultiplierSchedule) weightedMultiplier(rnd *rand.Rand) int {
+	totalWeight := 0
+	for _, w := range s.Weights {
+		totalWeight += w.Weight
+	}
+	if totalWeight == 0 {
+		return 1
+	}
+	n := rnd.Intn(totalWeight)
+	for _, w := range s.Weights {
+		if n < w.Weight {
+			return w.Multiplier
+		}
+		n -= w.Weight
+	}
+	return 1
+}
+
+// includes checks whether the time falls within the happy hour.
+func (h HappyHour) includes(t time.Time) bool {
+	from, err := parseClock(h.From)
+	if err != nil {
+		return false
+	}
+	to, err := parseClock(h.To)
+	if err != nil {
+		return false
+	}
+	clock := t.Hour()*60 + t.Minute()
+	if from <= to {
+		return clock >= from && clock < to
+	}
+	return clock >= from || clock < to
+}
+
+// parseClock converts a HH:MM clock time into minutes since midnight.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,150 @@
+package seed
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestHappyHourIncludes(t *testing.T) {
+	day := func(hour int, min int) time.Time {
+		return time.Date(2021, time.December, 1, hour, min, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name      string
+		happyHour HappyHour
+		at        time.Time
+		want      bool
+	}{
+		{"same day start", HappyHour{From: "18:00", To: "20:00"}, day(18, 0), true},
+		{"same day within", HappyHour{From: "18:00", To: "20:00"}, day(19, 31), true},
+		{"same day end is exclusive", HappyHour{From: "18:00", To: "20:00"}, day(20, 0), false},
+		{"same day before", HappyHour{From: "18:00", To: "20:00"}, day(17, 59), false},
+		{"overnight before midnight", HappyHour{From: "22:00", To: "02:00"}, day(23, 1), true},
+		{"overnight after midnight", HappyHour{From: "22:00", To: "02:00"}, day(1, 51), true},
+		{"overnight end is exclusive", HappyHour{From: "22:00", To: "02:00"}, day(2, 0), false},
+		{"overnight midday", HappyHour{From: "22:00", To: "02:00"}, day(12, 0), false},
+		{"empty", HappyHour{From: "10:00", To: "10:00"}, day(10, 0), false},
+		{"invalid clock", HappyHour{From: "25:00", To: "02:00"}, day(1, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.happyHour.includes(tt.at); got != tt.want {
+				t.Errorf("includes(%s) = %t, want %t", tt.at.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHappyHourIncludesLocalClock(t *testing.T) {
+	loc, err := time.LoadLocation("Australia/Brisbane")
+	if err != nil {
+		t.Skipf("timezone data not available: %s", err)
+	}
+	h := HappyHour{From: "18:00", To: "20:00"}
+	// 08:30 UTC is 18:30 in Brisbane
+	at := time.Date(2021, time.December, 1, 8, 30, 0, 0, time.UTC)
+	if h.includes(at) {
+		t.Errorf("includes() = true in UTC, want false")
+	}
+	if !h.includes(at.In(loc)) {
+		t.Errorf("includes() = false in Brisbane, want true")
+	}
+}
+
+func TestWeightedMultiplier(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []MultiplierWeight
+		want    map[int]bool
+	}{
+		{"no weights", nil, map[int]bool{1: true}},
+		{"zero weights", []MultiplierWeight{{Multiplier: 8, Weight: 0}}, map[int]bool{1: true}},
+		{"single weight", []MultiplierWeight{{Multiplier: 4, Weight: 1}}, map[int]bool{4: true}},
+		{
+			"skips zero weights",
+			[]MultiplierWeight{{Multiplier: 8, Weight: 0}, {Multiplier: 2, Weight: 3}, {Multiplier: 1, Weight: 1}},
+			map[int]bool{2: true, 1: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &MultiplierSchedule{Weights: tt.weights}
+			rnd := rand.New(rand.NewSource(1))
+			for i := 0; i < 100; i++ {
+				if got := s.weightedMultiplier(rnd); !tt.want[got] {
+					t.Fatalf("weightedMultiplier() = %d, want one of %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiplierRules(t *testing.T) {
+	at := time.Date(2021, time.December, 1, 19, 1, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		schedule MultiplierSchedule
+		want     int
+	}{
+		{
+			name:     "weighted",
+			schedule: MultiplierSchedule{Weights: []MultiplierWeight{{Multiplier: 2, Weight: 1}}},
+			want:     2,
+		},
+		{
+			name: "happy hour over weighted",
+			schedule: MultiplierSchedule{
+				Weights:    []MultiplierWeight{{Multiplier: 2, Weight: 1}},
+				HappyHours: []HappyHour{{From: "18:00", To: "20:00", Multiplier: 4}},
+			},
+			want: 4,
+		},
+		{
+			name: "explicit over happy hour",
+			schedule: MultiplierSchedule{
+				HappyHours: []HappyHour{{From: "18:00", To: "20:00", Multiplier: 4}},
+				Explicit:   []ExplicitMultiplier{{At: at.In(time.FixedZone("AEST", 10*60*60)), Multiplier: 8}},
+			},
+			want: 8,
+		},
+		{
+			name: "explicit at another time",
+			schedule: MultiplierSchedule{
+				Explicit: []ExplicitMultiplier{{At: at.Add(10 * time.Minute), Multiplier: 8}},
+			},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Multiplier(tt.schedule.NewRand(), at); got != tt.want {
+				t.Errorf("Multiplier() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiplierScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule MultiplierSchedule
+		wantErr  bool
+	}{
+		{"default", MultiplierSchedule{Weights: DefaultMultiplierWeights}, false},
+		{"empty", MultiplierSchedule{}, false},
+		{"zero multiplier", MultiplierSchedule{Weights: []MultiplierWeight{{Multiplier: 0, Weight: 1}}}, true},
+		{"negative weight", MultiplierSchedule{Weights: []MultiplierWeight{{Multiplier: 2, Weight: -1}}}, true},
+		{"all zero weights", MultiplierSchedule{Weights: []MultiplierWeight{{Multiplier: 2, Weight: 0}}}, true},
+		{"invalid happy hour", MultiplierSchedule{HappyHours: []HappyHour{{From: "6pm", To: "20:00", Multiplier: 2}}}, true},
+		{"invalid explicit", MultiplierSchedule{Explicit: []ExplicitMultiplier{{Multiplier: 0}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
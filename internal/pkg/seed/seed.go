@@ -0,0 +1,229 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"nt-folly-xmaxx-comp/internal/pkg/notify"
+	"nt-folly-xmaxx-comp/internal/pkg/utils"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var (
+	ErrAlreadySeeded = fmt.Errorf("data already seeded")
+)
+
+// CompetitionConfig contains the settings used to seed an event and its competitions.
+type CompetitionConfig struct {
+	EventName string
+	TeamTag   string
+	TeamID    int
+	Rules     string
+
+	TimeFrom time.Time
+	TimeTo   time.Time
+	// Timezone is the IANA timezone the competition windows and happy hours are worked out in (UTC if blank).
+	Timezone string
+	// WindowMinutes is the length of each competition.
+	// Windows of whole days follow the calendar days of the timezone.
+	WindowMinutes int
+
+	// TiePolicy is how tied racers are paid (SHARE, FULL or BREAK).
+	TiePolicy string
+	// TieBreaker is the metric used to break ties with the BREAK tie policy.
+	TieBreaker          *string
+	TieBreakerDirection string
+
+	// MinRaces, MinSecs and MinTyped are the activity needed to be ranked in a competition.
+	MinRaces int
+	MinSecs  int
+	MinTyped int
+
+	// PayoutRate is the in-game cash paid for each reward point.
+	PayoutRate float64
+
+	Multipliers *MultiplierSchedule
+}
+
+// PlannedCompetition is a competition window that will be seeded.
+type PlannedCompetition struct {
+	FromAt     time.Time
+	ToAt       time.Time
+	Multiplier int
+}
+
+// PlanCompetition works out the competition windows and their multipliers without saving them.
+func PlanCompetition(config CompetitionConfig) ([]PlannedCompetition, error) {
+	if config.Multipliers == nil {
+		config.Multipliers = &MultiplierSchedule{Weights: DefaultMultiplierWeights}
+	}
+	err := config.Multipliers.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid multiplier schedule: %w", err)
+	}
+
+	if config.WindowMinutes <= 0 {
+		return nil, fmt.Errorf("window length must be at least 1 minute")
+	}
+	loc, err := utils.LoadTimezone(config.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	timeFrom := utils.TimeRoundIn(config.TimeFrom, loc)
+	timeTo := utils.TimeRoundIn(config.TimeTo, loc)
+	if !timeFrom.Before(timeTo) {
+		return nil, fmt.Errorf("time from must be before time to")
+	}
+
+	rnd := config.Multipliers.NewRand()
+	output := []PlannedCompetition{}
+	for {
+		fromAt := timeFrom
+		toAt := timeFrom.Add(time.Minute * time.Duration(config.WindowMinutes))
+		if config.WindowMinutes%(24*60) == 0 {
+			toAt = timeFrom.AddDate(0, 0, config.WindowMinutes/(24*60))
+		}
+
+		output = append(output, PlannedCompetition{
+			FromAt:     fromAt,
+			ToAt:       toAt,
+			Multiplier: config.Multipliers.Multiplier(rnd, fromAt),
+		})
+
+		timeFrom = toAt
+		if timeFrom.Equal(timeTo) || timeFrom.After(timeTo) {
+			break
+		}
+	}
+	return output, nil
+}
+
+// SetupCompetition seeds in the event and its competition data.
+// Events of the same team can't overlap as they would share the same team logs.
+func SetupCompetition(ctx context.Context, conn *pgxpool.Pool, config CompetitionConfig) (string, error) {
+	if config.EventName == "" || config.TeamTag == "" || config.TeamID <= 0 {
+		return "", fmt.Errorf("event name, team tag and team id are required")
+	}
+	if config.Multipliers == nil {
+		config.Multipliers = &MultiplierSchedule{Weights: DefaultMultiplierWeights}
+	}
+	if config.MinRaces < 1 {
+		config.MinRaces = 1
+	}
+	if config.Timezone == "" {
+		config.Timezone = "UTC"
+	}
+	if config.PayoutRate < 0 {
+		return "", fmt.Errorf("payout rate must not be negative")
+	}
+	plan, err := PlanCompetition(config)
+	if err != nil {
+		return "", err
+	}
+	timeFrom := plan[0].FromAt
+	timeTo := plan[len(plan)-1].ToAt
+
+	count := 0
+	q := `
+		SELECT COUNT(*)
+		FROM events
+		WHERE deleted_at IS NULL
+			AND team_tag = $1
+			AND from_at < $3
+			AND to_at > $2`
+	err = conn.QueryRow(ctx, q, config.TeamTag, timeFrom, timeTo).Scan(&count)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if already seeded: %w", err)
+	}
+	if count > 0 {
+		return "", ErrAlreadySeeded
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to start seeding: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Create the event with the default category rewards
+	eventID := ""
+	q = `
+		INSERT INTO events (name, team_tag, team_id, rules, timezone, window_minutes, tie_policy, tie_breaker, tie_breaker_direction, min_races, min_secs, min_typed, payout_rate, from_at, to_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id`
+	err = tx.QueryRow(ctx, q,
+		config.EventName, config.TeamTag, config.TeamID, config.Rules, config.Timezone, config.WindowMinutes,
+		config.TiePolicy, config.TieBreaker, config.TieBreakerDirection,
+		config.MinRaces, config.MinSecs, config.MinTyped, config.PayoutRate, timeFrom, timeTo,
+	).Scan(&eventID)
+	if err != nil {
+		return "", fmt.Errorf("failed to create event: %w", err)
+	}
+	q = `
+		INSERT INTO event_categories (event_id, category_id, rewards)
+		SELECT $1, cat.id, cat.rewards
+		FROM categories cat
+		WHERE cat.deleted_at IS NULL`
+	_, err = tx.Exec(ctx, q, eventID)
+	if err != nil {
+		return "", fmt.Errorf("failed to create event categories: %w", err)
+	}
+
+	// Record the multiplier schedule so it can be replayed
+	scheduleRules, err := json.Marshal(config.Multipliers)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode multiplier schedule: %w", err)
+	}
+	scheduleID := ""
+	q = `
+		INSERT INTO multiplier_schedules (seed, rules)
+		VALUES ($1, $2)
+		RETURNING id`
+	err = tx.QueryRow(ctx, q, config.Multipliers.Seed, scheduleRules).Scan(&scheduleID)
+	if err != nil {
+		return "", fmt.Errorf("failed to record multiplier schedule: %w", err)
+	}
+
+	batch := &pgx.Batch{}
+	for _, c := range plan {
+		q := `
+			WITH c AS (
+				INSERT INTO competitions (event_id, multiplier, multiplier_schedule_id, tie_policy, tie_breaker, tie_breaker_direction, min_races, min_secs, min_typed, from_at, to_at)
+				SELECT e.id, $2, $3, e.tie_policy, e.tie_breaker, e.tie_breaker_direction, e.min_races, e.min_secs, e.min_typed, $4, $5
+				FROM events e
+				WHERE e.id = $1
+				RETURNING id, event_id
+			)
+			INSERT INTO competition_categories (competition_id, category_id, rewards)
+			SELECT c.id, ec.category_id, ec.rewards
+			FROM c
+				INNER JOIN event_categories ec ON ec.event_id = c.event_id`
+		batch.Queue(q, eventID, c.Multiplier, scheduleID, c.FromAt, c.ToAt)
+	}
+
+	batchRequest := tx.SendBatch(ctx, batch)
+	for i := 0; i < batch.Len(); i++ {
+		_, err = batchRequest.Exec()
+		if err != nil {
+			batchRequest.Close()
+			return "", fmt.Errorf("failed to seed the database: %w", err)
+		}
+	}
+	err = batchRequest.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to seed the database: %w", err)
+	}
+	err = notify.BumpVersion(ctx, tx)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to finish seeding: %w", err)
+	}
+	return eventID, nil
+}
@@ -0,0 +1,188 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanCompetition(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        CompetitionConfig
+		wantCount     int
+		wantFirstFrom string
+		wantLastTo    string
+		// wantHours are the lengths of the windows (all of them the same when there's only one)
+		wantHours []float64
+		wantErr   bool
+	}{
+		{
+			name: "ten minute windows",
+			config: CompetitionConfig{
+				TimeFrom:      time.Date(2021, time.December, 1, 0, 5, 0, 0, time.UTC),
+				TimeTo:        time.Date(2021, time.December, 1, 1, 5, 0, 0, time.UTC),
+				WindowMinutes: 10,
+			},
+			wantCount:     6,
+			wantFirstFrom: "2021-12-01T00:01:00Z",
+			wantLastTo:    "2021-12-01T01:01:00Z",
+			wantHours:     []float64{1.0 / 6},
+		},
+		{
+			name: "last window runs past time to",
+			config: CompetitionConfig{
+				TimeFrom:      time.Date(2021, time.December, 1, 0, 1, 0, 0, time.UTC),
+				TimeTo:        time.Date(2021, time.December, 1, 0, 31, 0, 0, time.UTC),
+				WindowMinutes: 20,
+			},
+			wantCount:     2,
+			wantFirstFrom: "2021-12-01T00:01:00Z",
+			wantLastTo:    "2021-12-01T00:41:00Z",
+			wantHours:     []float64{1.0 / 3},
+		},
+		{
+			name: "whole days across daylight saving start",
+			config: CompetitionConfig{
+				// Sydney moves forward an hour on the 3rd of October 2021
+				TimeFrom:      time.Date(2021, time.October, 1, 14, 1, 0, 0, time.UTC),
+				TimeTo:        time.Date(2021, time.October, 3, 14, 1, 0, 0, time.UTC),
+				Timezone:      "Australia/Sydney",
+				WindowMinutes: 24 * 60,
+			},
+			wantCount:     3,
+			wantFirstFrom: "2021-10-02T00:01:00+10:00",
+			wantLastTo:    "2021-10-05T00:01:00+11:00",
+			wantHours:     []float64{24, 23, 24},
+		},
+		{
+			name: "whole days across daylight saving end",
+			config: CompetitionConfig{
+				// New York moves back an hour on the 7th of November 2021
+				TimeFrom:      time.Date(2021, time.November, 6, 12, 1, 0, 0, time.UTC),
+				TimeTo:        time.Date(2021, time.November, 8, 12, 1, 0, 0, time.UTC),
+				Timezone:      "America/New_York",
+				WindowMinutes: 24 * 60,
+			},
+			wantCount:     2,
+			wantFirstFrom: "2021-11-06T08:01:00-04:00",
+			wantLastTo:    "2021-11-08T08:01:00-05:00",
+			wantHours:     []float64{25, 24},
+		},
+		{
+			name: "invalid window",
+			config: CompetitionConfig{
+				TimeFrom: time.Date(2021, time.December, 1, 0, 1, 0, 0, time.UTC),
+				TimeTo:   time.Date(2021, time.December, 2, 0, 1, 0, 0, time.UTC),
+			},
+			wantErr: true,
+		},
+		{
+			name: "time to before time from",
+			config: CompetitionConfig{
+				TimeFrom:      time.Date(2021, time.December, 2, 0, 1, 0, 0, time.UTC),
+				TimeTo:        time.Date(2021, time.December, 1, 0, 1, 0, 0, time.UTC),
+				WindowMinutes: 10,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown timezone",
+			config: CompetitionConfig{
+				TimeFrom:      time.Date(2021, time.December, 1, 0, 1, 0, 0, time.UTC),
+				TimeTo:        time.Date(2021, time.December, 2, 0, 1, 0, 0, time.UTC),
+				Timezone:      "Mars/Olympus_Mons",
+				WindowMinutes: 10,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.config.Timezone != "" && !tt.wantErr {
+				if _, err := time.LoadLocation(tt.config.Timezone); err != nil {
+					t.Skipf("timezone data not available: %s", err)
+				}
+			}
+			plan, err := PlanCompetition(tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("PlanCompetition() = %d competitions, want an error", len(plan))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanCompetition() error = %v", err)
+			}
+			if len(plan) != tt.wantCount {
+				t.Fatalf("PlanCompetition() = %d competitions, want %d", len(plan), tt.wantCount)
+			}
+			if got := plan[0].FromAt.Format(time.RFC3339); got != tt.wantFirstFrom {
+				t.Errorf("first competition from = %s, want %s", got, tt.wantFirstFrom)
+			}
+			if got := plan[len(plan)-1].ToAt.Format(time.RFC3339); got != tt.wantLastTo {
+				t.Errorf("last competition to = %s, want %s", got, tt.wantLastTo)
+			}
+			for i, c := range plan {
+				if i > 0 && !c.FromAt.Equal(plan[i-1].ToAt) {
+					t.Errorf("competition %d starts at %s, want %s", i, c.FromAt, plan[i-1].ToAt)
+				}
+				wantHours := tt.wantHours[0]
+				if len(tt.wantHours) > 1 {
+					wantHours = tt.wantHours[i]
+				}
+				if got := c.ToAt.Sub(c.FromAt).Hours(); got != wantHours {
+					t.Errorf("competition %d lasts %gh, want %gh", i, got, wantHours)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanCompetitionSeed(t *testing.T) {
+	config := func(seed int64) CompetitionConfig {
+		return CompetitionConfig{
+			TimeFrom:      time.Date(2021, time.December, 1, 0, 1, 0, 0, time.UTC),
+			TimeTo:        time.Date(2021, time.December, 8, 0, 1, 0, 0, time.UTC),
+			WindowMinutes: 10,
+			Multipliers: &MultiplierSchedule{
+				Seed:       seed,
+				Weights:    DefaultMultiplierWeights,
+				HappyHours: []HappyHour{{From: "22:00", To: "02:00", Multiplier: 2}},
+			},
+		}
+	}
+	multipliers := func(plan []PlannedCompetition) []int {
+		output := []int{}
+		for _, c := range plan {
+			output = append(output, c.Multiplier)
+		}
+		return output
+	}
+
+	first, err := PlanCompetition(config(42))
+	if err != nil {
+		t.Fatalf("PlanCompetition() error = %v", err)
+	}
+	second, err := PlanCompetition(config(42))
+	if err != nil {
+		t.Fatalf("PlanCompetition() error = %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("PlanCompetition() gave different schedules for the same seed")
+	}
+	other, err := PlanCompetition(config(43))
+	if err != nil {
+		t.Fatalf("PlanCompetition() error = %v", err)
+	}
+	if reflect.DeepEqual(multipliers(first), multipliers(other)) {
+		t.Errorf("PlanCompetition() gave the same multipliers for different seeds")
+	}
+	for _, c := range first {
+		if c.FromAt.Hour() >= 22 || c.FromAt.Hour() < 2 {
+			if c.Multiplier != 2 {
+				t.Fatalf("competition at %s has multiplier %d, want the happy hour's 2", c.FromAt.Format(time.RFC3339), c.Multiplier)
+			}
+		}
+	}
+}
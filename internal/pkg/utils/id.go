@@ -0,0 +1,9 @@
+package utils
+
+import "github.com/jackc/pgtype"
+
+// IsValidID checks whether the id is a UUID, so it can be compared against the UUID columns.
+func IsValidID(id string) bool {
+	var uuid pgtype.UUID
+	return uuid.Set(id) == nil
+}
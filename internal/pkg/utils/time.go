@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadTimezone loads an IANA timezone (e.g. Australia/Brisbane). A blank name is UTC.
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// TimeRoundIn rounds down a time the same way as TimeRound, using the clock of the given timezone.
+func TimeRoundIn(input time.Time, loc *time.Location) time.Time {
+	return TimeRound(input.In(loc))
+}
+
+// DayBounds returns the start of the day (midnight) of the time in the given timezone and the start of the next day.
+// Days are calendar days, so they can be 23 or 25 hours long when daylight saving changes.
+func DayBounds(input time.Time, loc *time.Location) (time.Time, time.Time) {
+	input = input.In(loc)
+	startAt := time.Date(input.Year(), input.Month(), input.Day(), 0, 0, 0, 0, loc)
+	return startAt, startAt.AddDate(0, 0, 1)
+}